@@ -23,37 +23,46 @@ package animal
 
 import (
 	//"fmt"
-	"math/rand"
 
 	//"os"
+	"math/rand"
 
 	"gonum.org/v1/gonum/mat"
 )
 
-var Rng *rand.Rand
-
 // Generate a foundation animal - sire and dam unknown
-func GenFoundation(a *Animal, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) {
+func (st *State) GenFoundation(a *Animal, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) {
+	st.genUnrelated(a, gvCholesky, rvCholesky, st.Rng.Genetic, st.Rng.Residual)
+}
+
+// Generate the breeding values and residuals of an animal with unknown parents.
+// With a genome they come from haplotypes drawn from the founder frequencies.
+func (st *State) genUnrelated(a *Animal, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky, genetic *rand.Rand, residual *rand.Rand) {
 
 	//fmt.Printf("Animal: %d\n", a.Id)
 	//fmt.Printf("Cholesky order: %d\n", gvCholesky.Size())
-	_, col := gvCholesky.Dims()
-	v := make([]float64, col)
-	for i := range v {
-		v[i] = Rng.NormFloat64()
-	}
-	b := mat.NewVecDense(len(v), v)
-	var t mat.TriDense
-	gvCholesky.LTo(&t)
+	if st.Genome != nil {
+		st.founderHaplotypes(a, genetic)
+		a.BreedingValue = st.genomicValue(a.Id)
+	} else {
+		_, col := gvCholesky.Dims()
+		v := make([]float64, col)
+		for i := range v {
+			v[i] = genetic.NormFloat64()
+		}
+		b := mat.NewVecDense(len(v), v)
+		var t mat.TriDense
+		gvCholesky.LTo(&t)
 
-	var bv mat.VecDense
-	bv.MulVec(&t, b)
-	a.BreedingValue = &bv
+		var bv mat.VecDense
+		bv.MulVec(&t, b)
+		a.BreedingValue = &bv
+	}
 
-	_, col = rvCholesky.Dims()
-	v = make([]float64, col)
+	_, col := rvCholesky.Dims()
+	v := make([]float64, col)
 	for i := range v {
-		v[i] = Rng.NormFloat64()
+		v[i] = residual.NormFloat64()
 	}
 	rv := mat.NewVecDense(len(v), v)
 	var tr mat.TriDense
@@ -71,14 +80,14 @@ func GenFoundation(a *Animal, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky)
 }
 
 // Generate the breed composition of a foundation animal from the CowHerdBreedComposition: key
-func GenFoundationBreedComposition(a *Animal) {
+func (st *State) GenFoundationBreedComposition(a *Animal) {
 
-	p := rand.Float64()
+	p := st.Rng.Composition.Float64()
 
-	for i := range FoundationCowHerdBreedCompositionTable {
-		if p <= FoundationCowHerdBreedCompositionTable[i].Proportion {
+	for i := range st.FoundationCowHerdBreedCompositionTable {
+		if p <= st.FoundationCowHerdBreedCompositionTable[i].Proportion {
 			a.BreedComposition = make(map[string]float64)
-			for key, element := range FoundationCowHerdBreedCompositionTable[i].BreedProportions {
+			for key, element := range st.FoundationCowHerdBreedCompositionTable[i].BreedProportions {
 				a.BreedComposition[key] = element
 			}
 			break
@@ -88,14 +97,18 @@ func GenFoundationBreedComposition(a *Animal) {
 }
 
 // Generate the breed composition of the initial bull battery animal from the BullBatteryBreedComposition: key
-func GenBullBatteryBreedComposition(a *Animal) {
+func (st *State) GenBullBatteryBreedComposition(a *Animal) {
+	st.genBullBatteryBreedComposition(a, st.Rng.Composition)
+}
+
+func (st *State) genBullBatteryBreedComposition(a *Animal, rng *rand.Rand) {
 
-	p := rand.Float64()
+	p := rng.Float64()
 
-	for i := range BullBatteryBreedCompositionTable {
-		if p <= BullBatteryBreedCompositionTable[i].Proportion {
+	for i := range st.BullBatteryBreedCompositionTable {
+		if p <= st.BullBatteryBreedCompositionTable[i].Proportion {
 			a.BreedComposition = make(map[string]float64)
-			for key, element := range BullBatteryBreedCompositionTable[i].BreedProportions {
+			for key, element := range st.BullBatteryBreedCompositionTable[i].BreedProportions {
 				a.BreedComposition[key] = element
 			}
 			break
@@ -118,54 +131,74 @@ func WhatSex(i int) (code string) {
 
 // Generate an animal from a mating
 // Determine if the heifer or calf died in calving
-func GenFromMating(a *Animal, year int, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) {
+func (st *State) GenFromMating(a *Animal, year int, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) {
 
 	// a is the cow bred
 
+	rec := a.BreedingRecords[len(a.BreedingRecords)-1]
+
 	var n Animal
-	n.Id = AnimalId(len(Records)) + 1 // There is no ID = 0.  That is the unknown
-	n.Sire = a.BreedingRecords[len(a.BreedingRecords)-1].Bull
+	n.Id = AnimalId(len(st.Records)) + 1 // There is no ID = 0.  That is the unknown
+	n.Sire = rec.Bull
 	n.Dam = a.Id
-	n.BirthDate = a.BreedingRecords[len(a.BreedingRecords)-1].CalvingDate
+	n.BirthDate = rec.CalvingDate
 	n.YearBorn = year
 	n.HerdName = a.HerdName
-	sx := rand.Intn(2)
-	n.Sex = WhatSex(sx)
+	if rec.FemaleProportion > 0 { // Sexed semen
+		n.Sex = Steer
+		if st.Rng.Sex.Float64() < rec.FemaleProportion {
+			n.Sex = Heifer
+		}
+	} else {
+		sx := st.Rng.Sex.Intn(2)
+		n.Sex = WhatSex(sx)
+	}
 
 	// Calculate parent average BV
 	_, col := gvCholesky.Dims()
 	pAve := mat.NewVecDense(col, nil)
-	sbv := Records[n.Sire].BreedingValue
-	dbv := a.BreedingValue
-	pAve.AddVec(sbv, dbv)
-
-	// Generate the medelian sample
-	v := make([]float64, col)
-	for i := range v {
-		v[i] = Rng.NormFloat64()
+	dam := a
+	if rec.Donor > 0 { // a is the recipient of an embryo
+		n.Dam = rec.Donor
+		dam = &st.Records[n.Dam-1]
+		if st.recipients == nil {
+			st.recipients = make(map[AnimalId]AnimalId)
+		}
+		st.recipients[n.Id] = a.Id
 	}
-	b := mat.NewVecDense(col, v)
-	var t mat.TriDense
-	gvCholesky.LTo(&t)
-
-	var c mat.VecDense
-	c.MulVec(&t, b)
-	var mendleSample mat.VecDense
-	mendleSample.MulVec(&t, b)
-
-	// Set the new animal's BV
-	newBV := mat.NewVecDense(col, nil)
-	for i := 0; i < mendleSample.Len(); i++ {
-		// sqrt(.5) = .707106781
-		bv := mendleSample.At(i, 0)*.707106781 + pAve.At(i, 0)*.5
-		newBV.SetVec(i, bv) // Calculate the average
+	pAve.AddVec(st.Records[n.Sire-1].BreedingValue, dam.BreedingValue)
+
+	if st.Genome != nil {
+		n.BreedingValue = st.inheritGenome(&n, &st.Records[n.Sire-1], dam)
+	} else {
+		// Generate the medelian sample
+		v := make([]float64, col)
+		for i := range v {
+			v[i] = st.Rng.Genetic.NormFloat64()
+		}
+		b := mat.NewVecDense(col, v)
+		var t mat.TriDense
+		gvCholesky.LTo(&t)
+
+		var c mat.VecDense
+		c.MulVec(&t, b)
+		var mendleSample mat.VecDense
+		mendleSample.MulVec(&t, b)
+
+		// Set the new animal's BV
+		newBV := mat.NewVecDense(col, nil)
+		for i := 0; i < mendleSample.Len(); i++ {
+			// sqrt(.5) = .707106781
+			bv := mendleSample.At(i, 0)*.707106781 + pAve.At(i, 0)*.5
+			newBV.SetVec(i, bv) // Calculate the average
+		}
+		n.BreedingValue = newBV
 	}
-	n.BreedingValue = newBV
 
 	_, col = rvCholesky.Dims()
 	r := make([]float64, col)
 	for i := range r {
-		r[i] = Rng.NormFloat64()
+		r[i] = st.Rng.Residual.NormFloat64()
 	}
 	d := mat.NewVecDense(col, r)
 	var tr mat.TriDense
@@ -175,21 +208,21 @@ func GenFromMating(a *Animal, year int, gvCholesky mat.Cholesky, rvCholesky mat.
 	newR.MulVec(&tr, d)
 	n.Residual = newR
 
-	SetBreedComposition(&n) // Determine the new animals breed composition
+	st.SetBreedComposition(&n) // Determine the new animals breed composition
 
-	diedCalving(a, &n)
+	st.diedCalving(a, &n)
 
-	Records = append(Records, n)
+	st.Records = append(st.Records, n)
 
-	Herds[n.HerdName].SumBirthDates[year] += float64(n.BirthDate)
-	Herds[n.HerdName].NBorn[year] += 1.
+	st.Herds[n.HerdName].SumBirthDates[year] += float64(n.BirthDate)
+	st.Herds[n.HerdName].NBorn[year] += 1.
 }
 
 // Determine an animal's breed composition from sire and dam
-func SetBreedComposition(a *Animal) {
+func (st *State) SetBreedComposition(a *Animal) {
 
-	sire := Records[a.Sire]
-	dam := Records[a.Dam]
+	sire := st.Records[a.Sire-1]
+	dam := st.Records[a.Dam-1]
 
 	a.BreedComposition = make(map[string]float64)
 
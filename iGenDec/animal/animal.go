@@ -24,15 +24,13 @@ SOFTWARE.
 package animal
 
 import (
-	"os"
-
 	"gonum.org/v1/gonum/mat"
 	//"gonum.org/v1/gonum/stat/distuv"
 )
 
 type Date int // Simulation date
 
-var SexCodes []string
+var SexCodes = []string{Bull, Heifer, Cow, Steer}
 
 const (
 	Bull   string = "M" // Breeding male or calf
@@ -57,13 +55,14 @@ type BreedingRec struct {
 	Bred        Bred     // Open or Pregnant
 	Bull        AnimalId // Id of sire
 	CalvingDate Date     // Simulation date of calving
+
+	FemaleProportion float64  // Heifer calves from the sexed semen used, 0 for conventional
+	Donor            AnimalId // Genetic dam of a transferred embryo, 0 when the calf is the cow's own
+	CalvingEase      int      // Calving ease category of the calving, 1 is the easiest, 0 without a calving ease model
 }
 
 type Trait string
 
-var TraitList = []Trait{}
-var TraitMean = make(map[string]float64) // Means of traits mapped to trait name.  Coms from the Traits: key in master.hjson
-
 type Bv int
 
 // This is the animal class
@@ -128,20 +127,12 @@ type BreedEffects_t struct {
 	Effects   map[string]float64 // Array of breed effects in order of the breeds in the 1st row of the BreedEffects: key in master.hjson
 }
 
-var Breeds map[Component_t]BreedEffects_t // See animal.go and technical documentation for this struct type
-var BreedsList []string                   // A list of the breeds in the master.hjson.  It is the 1st line of the BreedsEffects: key
-
-var HeterosisCodes map[string]string // The breed to breed classification categories in the HeterosisCodes: key in master.hjson
-var HeterosisCrossClasses []string   // The cross class designations from the HeterosisValues talbe 1st line in the master.hjson
-
 type HeterosisValues_t struct {
 	TraitName string
 	Component string
 	Values    map[string]float64 // These are mapped by the string of HeterosisCrossClasses
 }
 
-var HeterosisValues map[Component_t]HeterosisValues_t // These are mapped according to their Component_t
-
 // Sex by aod by breed by trait
 type BTS_t struct {
 	Breed string
@@ -150,25 +141,12 @@ type BTS_t struct {
 	Aod   int
 }
 
-var BreedTraitSexAod map[BTS_t]float64 // mapped by Breed then trait
-
 // Age effects in days
 type InterceptSlope_t struct {
 	Slope float64
 	Age   float64
 }
 
-var TraitAgeEffects map[string]InterceptSlope_t
-
-// Optional file to write out a phenotype components for debugging
-var PhenotypeFile string        // File name to write output
-var PhenotypeOutputTrait string // A valid trait name in the master.hjson
-var PhenotypeFilePointer *os.File
-var StayPhenotypeFilePointer *os.File // For debugging cow conception routines
-var HPPhenotypeFilePointer *os.File
-var CDPhenotypeFilePointer *os.File
-var CarcassPhenotypeFile *os.File // For optional write of slaughter cattle phenotypes QG,YQ, etc.
-
 //var HeiferPregnancyDistribution distuv.Normal
 
 type Aum_t struct {
@@ -179,26 +157,9 @@ type Aum_t struct {
 	Location    int     // For debug where the call came from
 }
 
-var CalfAumAt500 float64 // Amount of Aum at 500 lbs calf
-var CowAumAt1000 float64 // Amount of AUM at 1000 lbs animal
-
-var BumpComponent *string // Name,Name of the component  to bump the bulls 1 unit after burnin
-
-var BullMerit []float64 // Genetic merit of the foundation bulls fro meritFoundationBulls key
-
 type Sales_t struct {
-	NheadOpen float64 // number of head sold open - e.g., cows
-	NheadOld  float64 // number of head sold old
-	CumWt     float64 // cumulative weight of nhead
+	NheadOpen    float64 // number of head sold open - e.g., cows
+	NheadOld     float64 // number of head sold old
+	NheadSurplus float64 // number of bred heifers sold from the surplus kept for development
+	CumWt        float64 // cumulative weight of nhead
 }
-
-var WtCullCows map[int]Sales_t
-
-var IndexType string
-var IndexTerminal bool
-var BackgroundDays float64
-var DaysOnFeed float64
-
-var CDVar float64 // phenotypic variance for CDF
-
-var ComponentList []Component_t
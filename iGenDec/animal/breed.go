@@ -27,8 +27,6 @@ import (
 	"math/rand"
 	"os"
 
-	"github.com/blgolden/iGenDecModel/iGenDec/logger"
-
 	"gonum.org/v1/gonum/mat"
 	//"gonum.org/v1/gonum/stat/distuv"
 )
@@ -39,39 +37,37 @@ func min(x, y int) int {
 	}
 	return y
 }
-func randRange(min, max int) int {
+func randRange(rng *rand.Rand, min, max int) int {
 	if max-min <= 0 {
 		return min
 	}
-	return rand.Intn(max-min) + min
+	return rng.Intn(max-min) + min
 }
 
 // Refresh the list of active cows in the herd
-func ActiveCows(herd *Herd) []*Animal {
+func (st *State) ActiveCows(herd *Herd) []*Animal {
 	var cows []*Animal
-	for r := range Records {
-		if Records[r].Active && Records[r].Sex == Cow && Records[r].HerdName == herd.HerdName {
-			cows = append(cows, &Records[r])
+	for r := range st.Records {
+		if st.Records[r].Active && st.Records[r].Sex == Cow && st.Records[r].HerdName == herd.HerdName {
+			cows = append(cows, &st.Records[r])
 		}
 	}
 	return cows
 }
 
-var NHeifersBred map[int]int
-
 // Refresh the list of active cows in the herd
-func ActiveBulls(herd *Herd) []*Animal {
+func (st *State) ActiveBulls(herd *Herd) []*Animal {
 	var bulls []*Animal
-	for r := range Records {
-		if Records[r].Active && Records[r].Sex == Bull && Records[r].HerdName == herd.HerdName {
-			bulls = append(bulls, &Records[r])
+	for r := range st.Records {
+		if st.Records[r].Active && st.Records[r].Sex == Bull && st.Records[r].HerdName == herd.HerdName {
+			bulls = append(bulls, &st.Records[r])
 		}
 	}
 	return bulls
 }
-func GestationLengthError() int {
+func GestationLengthError(rng *rand.Rand) int {
 
-	r := rand.NormFloat64() * 5.0
+	r := rng.NormFloat64() * 5.0
 	er := int(r)
 	return er
 }
@@ -101,45 +97,52 @@ func is2YoaHeifer(a *Animal, herd *Herd, year int) bool {
 }
 
 // Select the replacement heifers to enter the cow herd
-func Replace(herd *Herd, year int) {
+func (st *State) Replace(herd *Herd, year int) {
 
-	herd.Cows = ActiveCows(herd)
+	herd.Cows = st.ActiveCows(herd)
 
 	nReplacements := herd.NumberCows - len(herd.Cows)
 	var replace int
 
 	if year == 1 || nReplacements == 0 {
-		if *logger.OutputMode == "verbose" && year > 1 {
+		if st.OutputMode == "verbose" && year > 1 {
 			fmt.Printf("\nNo replacements needed in the %v herd, year: %d\n", herd.HerdName, year)
 		}
 		return
 	}
 
-	// THIS WILL BE MODIFIED TO SELECT HEIFERS BY INDEX
-	// For now it just grabs the first available.
-	for i := herd.Cows[len(herd.Cows)-1].Id - 1; i < AnimalId(len(Records)-1); i++ {
+	if st.HeiferSelection != "" && st.HeiferSelection != SelectFirst {
+		replace = st.selectHeifers(herd, year, nReplacements)
+		if replace >= nReplacements {
+			herd.Cows = st.ActiveCows(herd)
+			return
+		}
+	}
+
+	// Without a selection policy it just grabs the first available.
+	for i := herd.Cows[len(herd.Cows)-1].Id - 1; i < AnimalId(len(st.Records)-1); i++ {
 		//for i := range Records {
-		if is2YoaHeifer(&Records[i], herd, year) {
-			Records[i].Active = true
-			Records[i].Sex = Cow
-			Records[i].DateCowEntered = int(herd.SumBirthDates[year-1]/herd.NBorn[year-1]) + 205 + 365
+		if st.isReplacement(&st.Records[i], herd, year) {
+			st.Records[i].Active = true
+			st.Records[i].Sex = Cow
+			st.Records[i].DateCowEntered = int(herd.SumBirthDates[year-1]/herd.NBorn[year-1]) + 205 + 365
 			replace++
 			if replace == nReplacements {
-				if *logger.OutputMode == "verbose" {
+				if st.OutputMode == "verbose" {
 					fmt.Printf("Replaced %d cows with heifers in the %v herd, year: %d\n", nReplacements, herd.HerdName, year)
 				}
-				herd.Cows = ActiveCows(herd)
+				herd.Cows = st.ActiveCows(herd)
 				return
 			}
 		}
 
 	}
-	if *logger.OutputMode == "verbose" {
+	if st.OutputMode == "verbose" {
 		fmt.Printf("\nWARNING: Insufficient replacement females to maintain %v herd\n", herd.HerdName)
 		fmt.Printf("\tNeeded: %d but had %d available in year %d\n", nReplacements, replace, year)
 	} // need to add logging
 
-	herd.Cows = ActiveCows(herd)
+	herd.Cows = st.ActiveCows(herd)
 }
 
 // Has this animal reached puberty
@@ -159,48 +162,90 @@ func Puberty(herd *Herd, i int, bredDate Date) bool {
 }
 
 // Breed the cow herds
-func Breed(herd *Herd, year int) {
+func (st *State) Breed(herd *Herd, year int) {
 	//fmt.Printf("LOC 1 %d %d\n", len(herd.Cows), year)
 
-	herd.Cows = ActiveCows(herd)
-	herd.Bulls = ActiveBulls(herd)
+	herd.Cows = st.ActiveCows(herd)
+	herd.Bulls = st.ActiveBulls(herd)
 
-	Replace(herd, year) // Set the replacement heifers to active cows
+	st.Replace(herd, year) // Set the replacement heifers to active cows
 
-	CowsExposedPerYear[year] = len(herd.Cows)
+	st.CowsExposedPerYear[year] = len(herd.Cows)
 
 	var sumSquared float64
 	var sum float64
 	var n float64
-	var nCycles = int(herd.BreedingSeasonLen/21 + 1)
 
 	//fmt.Println("LOC BR", year, herd.CowConceptionRate, acum, cycp)
 
+	terminal := st.terminalCows(herd, year)
+	sireCount := make(map[string]int) // Cows bred to each breed of the mating plan
+	synchronized := st.BreedingCosts[year]
+	delayed := 0 // Cows whose natural service was delayed by dystocia
+
 	for i := range herd.Cows {
 
 		var thisBreeding BreedingRec
 
 		// is this a heifer
 		thisAgeAtBreedingStart := Date((year-1)*365) + herd.StartBreeding - herd.Cows[i].BirthDate
+		isHeifer := thisAgeAtBreedingStart < 365+365/2 // Yearling heifer
+
+		// An embryo or fixed-time AI first then the cleanup bulls for the rest of the season
+		var start int // Days from the start of breeding natural service begins
+		class := SyncCows
+		if isHeifer {
+			class = SyncHeifers
+		}
+		if et := herd.transfer(class); et != nil && st.Rng.AI.Float64() < et.Proportion {
+			if embryo, pregnant := st.transferEmbryo(et, year); pregnant {
+				herd.conceived(&thisBreeding, &st.Records[embryo.Sire-1], et.TransferDay, year)
+				thisBreeding.Donor = embryo.Dam
+			}
+			start = et.CleanupDay
+		} else if p := herd.protocol(class); p != nil {
+			if sire, pregnant := st.inseminate(herd, herd.Cows[i], p, terminal[herd.Cows[i].Id], year); pregnant {
+				herd.conceived(&thisBreeding, sire, p.AIDay, year)
+				thisBreeding.FemaleProportion = p.SexedFemales
+			}
+			start = p.CleanupDay
+		}
+		if thisBreeding.Bred {
+			if herd.MatingPlan != "" {
+				sireCount[bullBreed(herd, &st.Records[thisBreeding.Bull-1])]++
+			}
+			if isHeifer {
+				st.NHeifersBred[year]++
+			}
+		}
+
+		// A hard calving last year delays the return to estrus
+		if delay := herd.postpartumDelay(herd.Cows[i]); delay > start && !thisBreeding.Bred {
+			start = delay
+			delayed++
+		}
+
+		var nCycles int
+		if !thisBreeding.Bred && start < int(herd.BreedingSeasonLen) {
+			nCycles = (int(herd.BreedingSeasonLen)-start)/21 + 1
+		}
 
 		for cycle := 1; cycle <= nCycles; cycle++ { // cycle is estrus
 
-			clen := min(int(herd.BreedingSeasonLen)-(cycle-1)*21, 21)
+			clen := min(int(herd.BreedingSeasonLen)-start-(cycle-1)*21, 21)
 			propClen := float64(clen) / 21.0
 
-			breddate := Date((cycle-1)*21+randRange(1, clen)) + herd.StartBreeding
+			breddate := Date(start+(cycle-1)*21+randRange(st.Rng.Breeding, 1, clen)) + herd.StartBreeding
 
 			var p float64
 
-			isHeifer := false
 			//var geneticDirectEffect float64
 
 			// Do this here because the age effects were impactful
-			if thisAgeAtBreedingStart < 365+365/2 { // Yearling heifer
-				p = HeiferPregnancyPhenotype(*herd.Cows[i], Date((year-1)*365)+breddate) + herd.Mean3CycleRate*propClen
-				isHeifer = true
+			if isHeifer {
+				p = st.HeiferPregnancyPhenotype(*herd.Cows[i], Date((year-1)*365)+breddate) + herd.Mean3CycleRate*propClen
 			} else { // This is a cow
-				stay := StayAtAgePhenotype(*herd.Cows[i], Date((year-1)*365)+breddate) + herd.Mean3CycleRate
+				stay := st.StayAtAgePhenotype(*herd.Cows[i], Date((year-1)*365)+breddate) + herd.Mean3CycleRate
 				p = Stay2Concept21days(stay) * propClen
 				//fmt.Println("LOC_2", stay, p, herd.Mean3CycleRate, herd.CowConceptionRate, propClen, cycle, herd.Cows[i].Id)
 				//p += herd.CowConceptionRate
@@ -216,17 +261,21 @@ func Breed(herd *Herd, year int) {
 				thisBreeding.DateBred = breddate
 				thisBreeding.YearBred = year
 				thisBreeding.Bred = true
-				thisBreeding.Bull = herd.Bulls[randRange(0, len(herd.Bulls)-1)].Id
+				sire := st.sireFor(herd, herd.Cows[i], terminal[herd.Cows[i].Id])
+				thisBreeding.Bull = sire.Id
+				if herd.MatingPlan != "" {
+					sireCount[bullBreed(herd, sire)]++
+				}
 				thisBreeding.CalvingDate = Date((year-1)*365) + thisBreeding.DateBred + GestationLength() // need to change to account for GL std dev
 
 				// Initialize the calving difficulty distribution
 				//if isHeifer && herd.Cows[i].YearBorn == 0 && cycle == 1 {
 				if isHeifer && cycle == 1 {
-					pheno := CalvingDifficultyPhenotype(*herd.Cows[i], thisBreeding)
+					pheno := st.CalvingDifficultyPhenotype(*herd.Cows[i], thisBreeding)
 					sumSquared += pheno * pheno
 					sum += pheno
 					n += 1.0
-					NHeifersBred[year]++
+					st.NHeifersBred[year]++
 				}
 
 				break
@@ -234,7 +283,7 @@ func Breed(herd *Herd, year int) {
 		}
 		if !thisBreeding.Bred {
 			thisBreeding.Bred = Open
-			thisBreeding.DateBred = Date(randRange(1, int(herd.BreedingSeasonLen)))
+			thisBreeding.DateBred = Date(randRange(st.Rng.Breeding, 1, int(herd.BreedingSeasonLen)))
 			thisBreeding.YearBred = year
 		}
 
@@ -244,37 +293,47 @@ func Breed(herd *Herd, year int) {
 	/*if n > 0 {
 		// Initialize the calving difficulty distribution
 		herd.CalvingDifficultyDistribution.Sigma = math.Sqrt((sumSquared - (sum * (sum / n))) / (n - 1.0))
-		herd.CalvingDifficultyDistribution.Mu = TraitMean["CD"]
-		Herds[herd.HerdName] = *herd
+		herd.CalvingDifficultyDistribution.Mu = st.TraitMean["CD"]
+		st.Herds[herd.HerdName] = *herd
 	} else {*/
 	// This is initialized in initSimulation
-	herd.CalvingDifficultyDistribution.Sigma = math.Sqrt(CDVar)
-	herd.CalvingDifficultyDistribution.Mu = TraitMean["CD"]
+	if len(herd.Synchronization)+len(herd.EmbryoTransfer) > 0 && st.OutputMode == "verbose" {
+		st.printSynchronization(herd, year, synchronized)
+	}
+	if len(herd.Dystocia) > 0 && st.OutputMode == "verbose" {
+		fmt.Printf("Cows with a postpartum delay after dystocia in the %v herd, year %d: %d\n", herd.HerdName, year, delayed)
+	}
+	if herd.MatingPlan != "" && st.OutputMode == "verbose" {
+		fmt.Printf("Cows bred by sire breed in the %v herd, year %d: %v\n", herd.HerdName, year, sireCount)
+	}
 
-	Herds[herd.HerdName] = *herd
+	herd.CalvingDifficultyDistribution.Sigma = math.Sqrt(st.CDVar)
+	herd.CalvingDifficultyDistribution.Mu = st.TraitMean["CD"]
+
+	st.Herds[herd.HerdName] = *herd
 	//}
 }
 
-func DumpBreedingRecords() {
+func (st *State) DumpBreedingRecords() {
 
-	if BreedingRecordsDumpFile == "" {
+	if st.BreedingRecordsDumpFile == "" {
 		return
 	}
 
 	var f *os.File
-	f, _ = os.Create(BreedingRecordsDumpFile)
+	f, _ = os.Create(st.BreedingRecordsDumpFile)
 
 	defer f.Close()
 
-	for i := range Records {
+	for i := range st.Records {
 		//fmt.Fprintf(f, "%d - ", thisHerd.Cows[i].Id)
-		if Records[i].BreedComposition != nil {
-			for j := range Records[i].BreedingRecords {
-				r := Records[i].BreedingRecords[j]
+		if st.Records[i].BreedComposition != nil {
+			for j := range st.Records[i].BreedingRecords {
+				r := st.Records[i].BreedingRecords[j]
 				if r.Bred {
-					fmt.Fprintln(f, Records[i].Id, r.DateBred, r.Bred, r.Bull, r.CalvingDate, r.YearBred)
+					fmt.Fprintln(f, st.Records[i].Id, r.DateBred, r.Bred, r.Bull, r.CalvingDate, r.YearBred)
 				} else {
-					fmt.Fprintln(f, Records[i].Id, r.DateBred, r.Bred, 0, r.YearBred)
+					fmt.Fprintln(f, st.Records[i].Id, r.DateBred, r.Bred, 0, r.YearBred)
 				}
 			}
 		}
@@ -282,15 +341,15 @@ func DumpBreedingRecords() {
 }
 
 // Cull the open cows and older than maximum age allowed in master.hjson
-func CullOpen(herd *Herd, year int) {
+func (st *State) CullOpen(herd *Herd, year int) {
 
-	herd.Cows = ActiveCows(herd)
+	herd.Cows = st.ActiveCows(herd)
 
 	var h HerdYear_t
 	h.Herd = herd.HerdName
 	h.Year = year
 
-	b := BreedingRecordsYearTable[h]
+	b := st.BreedingRecordsYearTable[h]
 
 	for r := range herd.Cows {
 		thisCow := herd.Cows[r]
@@ -310,23 +369,28 @@ func CullOpen(herd *Herd, year int) {
 						herd.Cows[r].DateCowCulled = int(thisCow.Dead)
 					}
 
-					CullAum(herd.Cows[r], year, 2)
+					st.CullAum(herd.Cows[r], year, 2)
 
 				} else {
 					b.HeifersBred++
 				}
 			} else {
 				b.CowsExposed++
-				if thisCow.BreedingRecords[len(thisCow.BreedingRecords)-1].Bred == Open {
+				if thisCow.Dead > 0 { // Only with a calving ease model
+					b.CowsDiedCalving++
+					herd.Cows[r].Active = false
+					herd.Cows[r].DateCowCulled = int(thisCow.Dead)
+					st.CullAum(herd.Cows[r], year, 3)
+				} else if thisCow.BreedingRecords[len(thisCow.BreedingRecords)-1].Bred == Open {
 					b.CowsCulledOpen++
 					herd.Cows[r].Active = false
 					herd.Cows[r].DateCowCulled = int(herd.SumBirthDates[year]/herd.NBorn[year]) + 205
-					CullAum(herd.Cows[r], year, 3)
-					w := MatureWeightAtAgePhenotype(*thisCow, Date(herd.Cows[r].DateCowCulled))
-					s := WtCullCows[year]
+					st.CullAum(herd.Cows[r], year, 3)
+					w := st.MatureWeightAtAgePhenotype(*thisCow, Date(herd.Cows[r].DateCowCulled))
+					s := st.WtCullCows[year]
 					s.CumWt += w
 					s.NheadOpen++
-					WtCullCows[year] = s
+					st.WtCullCows[year] = s
 				} else {
 					b.CowsBred++
 				}
@@ -336,23 +400,23 @@ func CullOpen(herd *Herd, year int) {
 	/*
 		fmt.Printf("Year %d %v Herd Breeding Summary:\n", year, herd.HerdName)
 		fmt.Printf("\tCows exposed:        % 5d\n", len(herd.Cows))
-		herd.Cows = ActiveCows(herd)
+		herd.Cows = st.ActiveCows(herd)
 		fmt.Printf("\tCows bred:           % 5d\n", len(herd.Cows))
 		fmt.Printf("\tCows culled open:    % 5d\n", culledOpen)
 	*/
 
-	BreedingRecordsYearTable[h] = b
+	st.BreedingRecordsYearTable[h] = b
 }
 
 // Cull the open cows and older than maximum age allowed in master.hjson
-func CullOld(herd *Herd, year int) {
+func (st *State) CullOld(herd *Herd, year int) {
 	var h HerdYear_t
 	h.Herd = herd.HerdName
 	h.Year = year
 
-	b := BreedingRecordsYearTable[h]
+	b := st.BreedingRecordsYearTable[h]
 
-	herd.Cows = ActiveCows(herd)
+	herd.Cows = st.ActiveCows(herd)
 
 	for r := range herd.Cows {
 		thisCow := herd.Cows[r]
@@ -360,31 +424,31 @@ func CullOld(herd *Herd, year int) {
 		if herd.Cows[r].Active {
 			thisAge := int(math.Round(float64(year*365-int(thisCow.BirthDate)) / 365.))
 
-			if thisAge >= MaxCowAge {
+			if thisAge >= st.MaxCowAge {
 				herd.Cows[r].Active = false
 				herd.Cows[r].DateCowCulled = int(herd.SumBirthDates[year]/herd.NBorn[year]) + 205
-				CullAum(herd.Cows[r], year, 4)
+				st.CullAum(herd.Cows[r], year, 4)
 
 				b.CowsCulledOld++
 
-				w := MatureWeightAtAgePhenotype(*thisCow, Date(year*365))
-				s := WtCullCows[year]
+				w := st.MatureWeightAtAgePhenotype(*thisCow, Date(year*365))
+				s := st.WtCullCows[year]
 				s.CumWt += w
 				s.NheadOld++
-				WtCullCows[year] = s
+				st.WtCullCows[year] = s
 			}
 		}
 	}
 
-	BreedingRecordsYearTable[h] = b
+	st.BreedingRecordsYearTable[h] = b
 
-	herd.Cows = ActiveCows(herd)
+	herd.Cows = st.ActiveCows(herd)
 
 	return
 }
 
 // Determine the AUM a cow consumed in the year it was culled
-func CullAum(thisCow *Animal, year int, loc int) {
+func (st *State) CullAum(thisCow *Animal, year int, loc int) {
 
 	nMonths := int(float64(thisCow.DateCowCulled-year*365)/30.42) + 1
 
@@ -398,26 +462,27 @@ func CullAum(thisCow *Animal, year int, loc int) {
 		}
 		a.MonthOfYear = curMonth
 		a.Year = yr
-		w := MatureWeightAtAgePhenotype(*thisCow, Date(year*365+curMonth*30))
-		frac := w / 1000. * CowAumAt1000
+		w := st.MatureWeightAtAgePhenotype(*thisCow, Date(year*365+curMonth*30))
+		frac := w / 1000. * st.CowAumAt1000
 		a.Aum = frac
 		a.Location = loc
-		Records[thisCow.Id-1].CowAum = append(Records[thisCow.Id-1].CowAum, a)
+		st.Records[thisCow.Id-1].CowAum = append(st.Records[thisCow.Id-1].CowAum, a)
 	}
 	//fmt.Println("\nLOC CULL", thisCow.Id, Records[thisCow.Id-1].CowAum)
 }
 
 // Calculate monthly AUM consumption of new animal to yearling age
-func DetermineAumToWeaning(newCalf *Animal) {
+func (st *State) DetermineAumToWeaning(newCalf *Animal) {
 
-	ww, ok := WeaningWtPhenotype(*newCalf)
-	bw, _ := Phenotype(*newCalf, "BW")
+	ww, ok := st.WeaningWtPhenotype(*newCalf)
+	bw, _ := st.Phenotype(*newCalf, "BW")
 
 	if !ok {
-		panic(ok)
+		st.fail(&PhenotypeError{Id: newCalf.Id, Trait: "WW"})
+		return
 	}
 
-	aveWd := Herds[newCalf.HerdName].SumBirthDates[newCalf.YearBorn]/Herds[newCalf.HerdName].NBorn[newCalf.YearBorn] + 205.0
+	aveWd := st.Herds[newCalf.HerdName].SumBirthDates[newCalf.YearBorn]/st.Herds[newCalf.HerdName].NBorn[newCalf.YearBorn] + 205.0
 	mw := aveWd/365. - float64(int(aveWd/365.))
 	monthWeaned := int(mw*12.) + 1
 	if monthWeaned > 12 {
@@ -445,7 +510,7 @@ func DetermineAumToWeaning(newCalf *Animal) {
 	var Aum Aum_t
 	Aum.Weight = cumWt
 	Aum.MonthOfYear = birthMonth
-	Aum.Aum = cumWt / 500. * CalfAumAt500
+	Aum.Aum = cumWt / 500. * st.CalfAumAt500
 
 	Aum.Year = newCalf.YearBorn
 
@@ -468,7 +533,7 @@ func DetermineAumToWeaning(newCalf *Animal) {
 
 		var Aumn Aum_t
 		Aumn.Weight = wt
-		Aumn.Aum = wt / 500. * CalfAumAt500
+		Aumn.Aum = wt / 500. * st.CalfAumAt500
 		cumAum += Aumn.Aum
 		month++
 		if month > 12 {
@@ -485,42 +550,43 @@ func DetermineAumToWeaning(newCalf *Animal) {
 
 // Calculate this animal's total feedlot feed intake
 // And slaughter weight
-func DetermineFeedlotFeedIntake(newCalf *Animal) {
+func (st *State) DetermineFeedlotFeedIntake(newCalf *Animal) {
 
-	feedlotDailyFeedIntake, _ := Phenotype(*newCalf, "FI")
+	feedlotDailyFeedIntake, _ := st.Phenotype(*newCalf, "FI")
 
-	newCalf.FeedlotTotalFeedIntake = feedlotDailyFeedIntake * DaysOnFeed
+	newCalf.FeedlotTotalFeedIntake = feedlotDailyFeedIntake * st.DaysOnFeed
 
-	newCalf.CarcassWeight, _ = Phenotype(*newCalf, "HCW")
+	newCalf.CarcassWeight, _ = st.Phenotype(*newCalf, "HCW")
 	stdSlaughterWeight := newCalf.CarcassWeight / .63 // 63% dressing percentage
 
 	inWeight := newCalf.AumWeanThruBackgrounding[len(newCalf.AumWeanThruBackgrounding)-1].Weight
 
 	// feedlotAverageDailyGain := (stdSlaughterWeight - inWeight) / 140. // Standard feeding period length for the avg HCW
-	feedlotAverageDailyGain := (stdSlaughterWeight - inWeight) / DaysOnFeed // Changed to reflect user's input means
+	feedlotAverageDailyGain := (stdSlaughterWeight - inWeight) / st.DaysOnFeed // Changed to reflect user's input means
 
 	//fmt.Println("LOC 0", newCalf.Id, inWeight, feedlotAverageDailyGain, feedlotDailyFeedIntake, DaysOnFeed)
-	newCalf.HarvestWeight = inWeight + feedlotAverageDailyGain*DaysOnFeed
+	newCalf.HarvestWeight = inWeight + feedlotAverageDailyGain*st.DaysOnFeed
 
-	newCalf.MarblingScore, _ = Phenotype(*newCalf, "MS")
-	newCalf.BackFatThickness, _ = Phenotype(*newCalf, "FAT")
-	newCalf.RibEyArea, _ = Phenotype(*newCalf, "REA")
+	newCalf.MarblingScore, _ = st.Phenotype(*newCalf, "MS")
+	newCalf.BackFatThickness, _ = st.Phenotype(*newCalf, "FAT")
+	newCalf.RibEyArea, _ = st.Phenotype(*newCalf, "REA")
 
 }
 
 // Calculate monthly AUM consumption of new animal to yearling age
 // This also determines the weight at the end of the background period
-func DetermineAumThruBackgrounding(newCalf *Animal) {
+func (st *State) DetermineAumThruBackgrounding(newCalf *Animal) {
 
-	ww, ok := WeaningWtPhenotype(*newCalf)
-	yw, _ := Phenotype(*newCalf, "YW")
+	ww, ok := st.WeaningWtPhenotype(*newCalf)
+	yw, _ := st.Phenotype(*newCalf, "YW")
 
 	if !ok {
-		panic(ok)
+		st.fail(&PhenotypeError{Id: newCalf.Id, Trait: "WW"})
+		return
 	}
 
 	// Date weaned
-	aveWd := Herds[newCalf.HerdName].SumBirthDates[newCalf.YearBorn]/Herds[newCalf.HerdName].NBorn[newCalf.YearBorn] + 205.0
+	aveWd := st.Herds[newCalf.HerdName].SumBirthDates[newCalf.YearBorn]/st.Herds[newCalf.HerdName].NBorn[newCalf.YearBorn] + 205.0
 	mw := aveWd/365. - float64(int(aveWd/365.))
 	monthWeaned := int(mw*12.) + 1
 	if monthWeaned > 12 {
@@ -528,7 +594,7 @@ func DetermineAumThruBackgrounding(newCalf *Animal) {
 	}
 
 	ageAtWeaning := aveWd - float64(newCalf.BirthDate)
-	AgeAtBackground := ageAtWeaning + BackgroundDays
+	AgeAtBackground := ageAtWeaning + st.BackgroundDays
 	ageAtYearling := ageAtWeaning + 160
 
 	adg := (yw - ww) / (ageAtYearling - ageAtWeaning)
@@ -546,9 +612,9 @@ func DetermineAumThruBackgrounding(newCalf *Animal) {
 	var Aum Aum_t
 	Aum.Weight = cumWt
 	Aum.MonthOfYear = monthWeaned
-	Aum.Aum = cumWt / 500. * CalfAumAt500
+	Aum.Aum = cumWt / 500. * st.CalfAumAt500
 
-	yearWeaned := int((aveWd + BackgroundDays) / 365.) //NOT SURE THIS IS RIGHT I think it is
+	yearWeaned := int((aveWd + st.BackgroundDays) / 365.) //NOT SURE THIS IS RIGHT I think it is
 	Aum.Year = yearWeaned
 
 	newCalf.AumWeanThruBackgrounding = append(newCalf.AumWeanThruBackgrounding, Aum)
@@ -570,7 +636,7 @@ func DetermineAumThruBackgrounding(newCalf *Animal) {
 
 		var Aumn Aum_t
 		Aumn.Weight = wt
-		Aumn.Aum = wt / 500. * CalfAumAt500
+		Aumn.Aum = wt / 500. * st.CalfAumAt500
 		cumAum += Aumn.Aum
 		month++
 		if month > 12 {
@@ -586,27 +652,31 @@ func DetermineAumThruBackgrounding(newCalf *Animal) {
 }
 
 // Calve and produce phenotypes for the calves for entire life not just this year.
-func Calve(herd *Herd, year int, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) {
+func (st *State) Calve(herd *Herd, year int, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) {
 
-	herd.Cows = ActiveCows(herd)
+	herd.Cows = st.ActiveCows(herd)
 
-	newCalves := len(Records)
+	newCalves := len(st.Records)
 
 	for i, c := range herd.Cows {
 		if c.BreedingRecords[len(c.BreedingRecords)-1].Bred != Open {
-			GenFromMating(herd.Cows[i], year, gvCholesky, rvCholesky)
+			st.GenFromMating(herd.Cows[i], year, gvCholesky, rvCholesky)
 		}
 	}
 
-	for i := newCalves; i < len(Records); i++ {
-		DetermineAumToWeaning(&Records[i])
+	if herd.MatingPlan != "" && st.OutputMode == "verbose" {
+		st.printCalfComposition(herd, year, newCalves)
+	}
+
+	for i := newCalves; i < len(st.Records); i++ {
+		st.DetermineAumToWeaning(&st.Records[i])
 
-		if IndexType != "weaning" { // everything marketed after weaning is going to need Aum through backgrounding
+		if st.IndexType != "weaning" { // everything marketed after weaning is going to need Aum through backgrounding
 
-			DetermineAumThruBackgrounding(&Records[i]) // Even calf feds get 1 day so that a feedlot inweight can be taken
+			st.DetermineAumThruBackgrounding(&st.Records[i]) // Even calf feds get 1 day so that a feedlot inweight can be taken
 
-			if IndexType == "fatcattle" || IndexType == "slaughtercattle" {
-				DetermineFeedlotFeedIntake(&Records[i]) // It also calculates and stores slaughter weight
+			if st.IndexType == "fatcattle" || st.IndexType == "slaughtercattle" {
+				st.DetermineFeedlotFeedIntake(&st.Records[i]) // It also calculates and stores slaughter weight
 			}
 
 		}
@@ -615,7 +685,12 @@ func Calve(herd *Herd, year int, gvCholesky mat.Cholesky, rvCholesky mat.Cholesk
 
 // Did the cow or calf die in calving?
 // This is called from GenFromMating() in GenBV.go
-func diedCalving(cow *Animal, calf *Animal) {
+func (st *State) diedCalving(cow *Animal, calf *Animal) {
+
+	if len(st.Herds[cow.HerdName].CalvingEase) > 0 {
+		st.calvingEase(cow, calf)
+		return
+	}
 
 	// Is this a heifer - if not then no issue
 	cowAge := calf.BirthDate - cow.BirthDate
@@ -624,10 +699,10 @@ func diedCalving(cow *Animal, calf *Animal) {
 	}
 
 	for _, b := range cow.BreedingRecords {
-		cd := CalvingDifficultyPhenotype(*calf, b)
-		prob := Herds[Records[calf.Dam].HerdName].CalvingDifficultyDistribution.CDF(cd)
+		cd := st.CalvingDifficultyPhenotype(*calf, b)
+		prob := st.Herds[cow.HerdName].CalvingDifficultyDistribution.CDF(cd)
 		//fmt.Println("LOC 3", cow.Id, prob, cd, Herds[Records[calf.Dam].HerdName].CalvingDifficultyDistribution, Herds[Records[calf.Dam].HerdName].InitialCalvingDeathLessRate)
-		if prob >= 1.-Herds[Records[calf.Dam].HerdName].InitialCalvingDeathLessRate {
+		if prob >= 1.-st.Herds[cow.HerdName].InitialCalvingDeathLessRate {
 			calf.Dead = calf.BirthDate
 			cow.Dead = calf.BirthDate
 			//fmt.Println("LOC 6", calf.Id, calf.Dam, calf.Dead, calf.YearBorn)
@@ -0,0 +1,107 @@
+// bulls.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Cull the bulls that have finished their service life or were injured and
+// buy yearling bulls from the bull battery to keep the bull to cow ratio.
+// Herds without bull management keep their foundation bulls.
+func (st *State) ManageBulls(herd *Herd, year int, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) {
+
+	if herd.BullServiceLife == 0 {
+		return
+	}
+
+	h := HerdYear_t{Herd: herd.HerdName, Year: year}
+	t := st.BreedingRecordsYearTable[h]
+
+	var kept int
+	for _, b := range st.ActiveBulls(herd) {
+		injured := st.Rng.Bulls.Float64() < herd.BullInjuryRate // Drawn for every bull so the stream doesn't depend on age
+		switch {
+		case year-(b.YearBorn+1) >= herd.BullServiceLife: // A yearling's first season is YearBorn+1
+			b.Active = false
+			t.BullsCulledOld++
+		case injured:
+			b.Active = false
+			t.BullsCulledInjured++
+		default:
+			kept++
+		}
+	}
+
+	need := int(math.Ceil(float64(herd.NumberCows) / herd.CowsPerBull))
+	if n := len(sireBreeds(herd)); need < n { // At least one bull of each breed of the mating plan
+		need = n
+	}
+	for i := kept; i < need; i++ {
+		st.buyBull(herd, year, gvCholesky, rvCholesky)
+		t.BullsBought++
+	}
+
+	if st.OutputMode == "verbose" {
+		fmt.Printf("Bulls in the %v herd year %d: culled %d old and %d injured, bought %d\n",
+			herd.HerdName, year, t.BullsCulledOld, t.BullsCulledInjured, t.BullsBought)
+	}
+
+	st.BreedingRecordsYearTable[h] = t
+	herd.Bulls = st.ActiveBulls(herd)
+}
+
+// A yearling bull from the bull battery.  His merit is that of the foundation
+// bulls moved by the genetic trend for the years since the foundation, plus
+// the bump when bought after the burnin.
+func (st *State) buyBull(herd *Herd, year int, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) {
+
+	var a Animal
+	a.Id = AnimalId(len(st.Records)) + 1
+	a.Sex = Bull
+	a.YearBorn = year - 1
+	a.BirthDate = Date((year-2)*365) + herd.StartBreeding + GestationLength()
+	a.Active = true
+	a.HerdName = herd.HerdName
+
+	st.genUnrelated(&a, gvCholesky, rvCholesky, st.Rng.Bulls, st.Rng.Bulls)
+
+	st.genBullBatteryBreedComposition(&a, st.Rng.Bulls)
+	st.setPlanBreed(herd, &a, st.ActiveBulls(herd))
+
+	for j, m := range st.BullMerit {
+		a.BreedingValue.SetVec(j, a.BreedingValue.AtVec(j)+m)
+	}
+	for j, m := range st.BullTrend {
+		a.BreedingValue.SetVec(j, a.BreedingValue.AtVec(j)+m*float64(year))
+	}
+	if year > st.Burnin {
+		for j, m := range st.BullBump {
+			a.BreedingValue.SetVec(j, a.BreedingValue.AtVec(j)+m)
+		}
+	}
+
+	st.Records = append(st.Records, a)
+}
@@ -0,0 +1,222 @@
+// calvingEase.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"fmt"
+	"math"
+)
+
+// A row of calvingEase: in the hjson.  A herd's rows are in order from the
+// easiest category to the hardest.
+type CalvingEase_t struct {
+	Category  string  // For example unassisted, easyPull, hardPull or caesarean
+	CalfDeath float64 // Probability the calf dies
+	CowDeath  float64 // Probability the cow dies
+	Labor     float64 // Labor cost per calving
+	Vet       float64 // Veterinary cost per calving
+}
+
+// The thresholds of the dams of an age from calvingEaseFrequencies: in the hjson
+type CalvingEaseAge_t struct {
+	Age        int       // Dam age in years, also used for older dams up to the next row
+	Cumulative []float64 // Proportion of calvings in each category or an easier one
+}
+
+// Carry-over effects of a calving ease category from dystocia: in the hjson
+type Dystocia_t struct {
+	Category        int     // Index in the herd's CalvingEase
+	Age             int     // Dam age in years, also used for older dams up to the next row
+	PostpartumDelay int     // Days into the next breeding season before the cow cycles
+	WeaningPenalty  float64 // Subtracted from the calf's weaning weight
+}
+
+// A year's calvings by category and what they cost
+type CalvingCost_t struct {
+	Calvings   int
+	Categories map[string]int
+	CalfDeaths int
+	CowDeaths  int
+	Labor      float64
+	Vet        float64
+}
+
+// The thresholds of a dam's age.  Dams younger than the first row use it.
+func (herd *Herd) calvingEaseAge(age int) *CalvingEaseAge_t {
+	a := &herd.CalvingEaseAges[0]
+	for i := range herd.CalvingEaseAges {
+		if herd.CalvingEaseAges[i].Age <= age {
+			a = &herd.CalvingEaseAges[i]
+		}
+	}
+	return a
+}
+
+// The category of a calving at prob of the base calving difficulty
+// distribution, the first whose cumulative frequency is above it
+func (a *CalvingEaseAge_t) category(prob float64) int {
+	k := 0
+	for k < len(a.Cumulative)-1 && prob >= a.Cumulative[k] {
+		k++
+	}
+	return k
+}
+
+// Place a calving in a category of the herd's calving ease model.  The
+// thresholds are on the scale of the calving difficulty distribution of the
+// base population, where they give the input category frequencies of dams of
+// that age, so genetic change moves calvings between the categories.  The
+// thresholds of the dam's age stand in for the age of dam effect, so the
+// calf is scored as if its dam were mature.
+func (st *State) calvingEase(cow *Animal, calf *Animal) {
+
+	herd := st.Herds[cow.HerdName]
+	rec := cow.BreedingRecords[len(cow.BreedingRecords)-1]
+
+	age := int(math.Round(float64(calf.BirthDate-cow.BirthDate) / 365.))
+	thresholds := herd.calvingEaseAge(age)
+
+	cd := st.CalvingDifficultyPhenotype(*calf, rec)
+	if aod := st.WhatAod(*calf); aod != matureAod {
+		cd += st.sexAodEffect("CD", *calf, matureAod) - st.sexAodEffect("CD", *calf, aod)
+	}
+	prob := herd.CalvingDifficultyDistribution.CDF(cd)
+	k := thresholds.category(prob)
+	category := herd.CalvingEase[k]
+	cow.BreedingRecords[len(cow.BreedingRecords)-1].CalvingEase = k + 1
+
+	c := st.CalvingCosts[calf.YearBorn]
+	if c.Categories == nil {
+		c.Categories = make(map[string]int)
+	}
+	c.Calvings++
+	c.Categories[category.Category]++
+	c.Labor += category.Labor
+	c.Vet += category.Vet
+
+	// Both are drawn so the stream does not depend on the outcome
+	calfDies := st.Rng.Calving.Float64() < category.CalfDeath
+	cowDies := st.Rng.Calving.Float64() < category.CowDeath
+	if calfDies {
+		calf.Dead = calf.BirthDate
+		c.CalfDeaths++
+	}
+	if cowDies {
+		cow.Dead = calf.BirthDate
+		c.CowDeaths++
+	}
+	st.CalvingCosts[calf.YearBorn] = c
+}
+
+// The carry-over effects of a category for a dam's age, nil when there are none.
+// Dams younger than the first row of the category use it.
+func (herd *Herd) dystocia(category int, age int) *Dystocia_t {
+	var d *Dystocia_t
+	for i := range herd.Dystocia {
+		x := &herd.Dystocia[i]
+		if x.Category == category && (d == nil || x.Age <= age) {
+			d = x
+		}
+	}
+	return d
+}
+
+// The effects of a cow's calving, nil when there are none
+func (herd *Herd) calvingEffects(cow *Animal, r *BreedingRec) *Dystocia_t {
+	if r.CalvingEase == 0 {
+		return nil
+	}
+	age := int(math.Round(float64(r.CalvingDate-cow.BirthDate) / 365.))
+	return herd.dystocia(r.CalvingEase-1, age)
+}
+
+// Days into the breeding season before a cow cycles after a hard calving
+// the year before
+func (herd *Herd) postpartumDelay(cow *Animal) int {
+	if len(herd.Dystocia) == 0 || len(cow.BreedingRecords) == 0 {
+		return 0
+	}
+	if d := herd.calvingEffects(cow, &cow.BreedingRecords[len(cow.BreedingRecords)-1]); d != nil {
+		return d.PostpartumDelay
+	}
+	return 0
+}
+
+// Weaning weight lost by a calf born with assistance
+func (st *State) weaningPenalty(calf *Animal) float64 {
+	herd := st.Herds[calf.HerdName]
+	if len(herd.Dystocia) == 0 {
+		return 0
+	}
+	dam := st.maternalDam(calf)
+	if dam == nil {
+		return 0
+	}
+	for i := range dam.BreedingRecords {
+		r := &dam.BreedingRecords[i]
+		if r.Bred == Pregnant && r.Bull == calf.Sire && r.CalvingDate == calf.BirthDate {
+			if d := herd.calvingEffects(dam, r); d != nil {
+				return d.WeaningPenalty
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// Categories of the herds' calving ease models in order, herds by name
+func (st *State) CalvingEaseCategories() []string {
+	var categories []string
+	seen := make(map[string]bool)
+	for _, n := range st.HerdNames {
+		for _, c := range st.Herds[n].CalvingEase {
+			if !seen[c.Category] {
+				seen[c.Category] = true
+				categories = append(categories, c.Category)
+			}
+		}
+	}
+	return categories
+}
+
+// Print the calvings in each category and the deaths and costs by year
+func (st *State) PrintCalvingEase() {
+	categories := st.CalvingEaseCategories()
+	if len(categories) == 0 {
+		return
+	}
+
+	fmt.Printf("\nCalving ease:\n")
+	fmt.Printf("Year  Calvings")
+	for _, c := range categories {
+		fmt.Printf(" %10s", c)
+	}
+	fmt.Printf(" Calves Died  Cows Died    $ Labor      $ Vet\n")
+	for y := st.Burnin + 1; y <= st.Burnin+st.YearsPlanningHorizon; y++ {
+		c := st.CalvingCosts[y]
+		fmt.Printf("%4d  %8d", y, c.Calvings)
+		for _, k := range categories {
+			fmt.Printf(" %10d", c.Categories[k])
+		}
+		fmt.Printf(" %11d %10d %10.2f %10.2f\n", c.CalfDeaths, c.CowDeaths, c.Labor, c.Vet)
+	}
+}
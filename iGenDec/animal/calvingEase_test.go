@@ -0,0 +1,117 @@
+// calvingEase_test.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// A herd whose calves' calving difficulty is the base distribution plus an
+// age of dam effect for 2 and 3 year old dams
+func calvingEaseHerd() (*State, Herd) {
+	st := NewState(3, "")
+	st.Traits = []string{"CD"}
+	st.Components = []string{"CD, D"}
+	st.BreedsList = []string{"Angus"}
+	st.TraitMean["CD"] = 1
+	st.BreedTraitSexAod = map[BTS_t]float64{
+		{Breed: "Angus", Trait: "CD", Sex: Steer, Aod: 0}: 2,
+		{Breed: "Angus", Trait: "CD", Sex: Steer, Aod: 1}: .8,
+	}
+	st.CalvingCosts = make(map[int]CalvingCost_t)
+
+	herd := Herd{
+		HerdName: "Spring",
+		CalvingEase: []CalvingEase_t{
+			{Category: "unassisted"},
+			{Category: "easyPull", CalfDeath: .02, Labor: 10},
+			{Category: "hardPull", CalfDeath: .1, CowDeath: .02, Labor: 40, Vet: 50},
+			{Category: "caesarean", CalfDeath: .2, CowDeath: .1, Labor: 60, Vet: 400},
+		},
+		CalvingEaseAges: []CalvingEaseAge_t{
+			{Age: 2, Cumulative: []float64{.6, .85, .95, 1}},
+			{Age: 3, Cumulative: []float64{.8, .95, .99, 1}},
+			{Age: 5, Cumulative: []float64{.9, .98, .995, 1}},
+		},
+		CalvingDifficultyDistribution: distuv.Normal{Mu: 1, Sigma: math.Sqrt(2)},
+	}
+	st.Herds = map[string]Herd{herd.HerdName: herd}
+	return st, herd
+}
+
+// Calvings of the base population through calvingEase fall in the categories
+// at the input frequencies of their dams' ages, with the deaths and costs of
+// those categories
+func TestCalvingEaseThresholds(t *testing.T) {
+	st, herd := calvingEaseHerd()
+	rng := rand.New(rand.NewSource(1))
+
+	const n = 40000
+	for _, age := range []int{2, 3, 6} {
+		year := age // Each age's calvings are tallied in a year of their own
+		st.Records = []Animal{{Id: 1, Sex: Cow, HerdName: herd.HerdName, BreedComposition: map[string]float64{"Angus": 1}}}
+		cow := &st.Records[0]
+		for i := 0; i < n; i++ {
+			calf := Animal{Id: 2, Dam: 1, Sex: Steer, BirthDate: Date(age * 365), YearBorn: year,
+				BreedComposition: map[string]float64{"Angus": 1},
+				BreedingValue:    mat.NewVecDense(1, []float64{rng.NormFloat64()}), // Genetic and residual variances of 1
+				Residual:         mat.NewVecDense(1, []float64{rng.NormFloat64()})}
+			cow.BreedingRecords = []BreedingRec{{Bred: Pregnant, CalvingDate: calf.BirthDate}}
+			st.calvingEase(cow, &calf)
+		}
+
+		c := st.CalvingCosts[year]
+		if c.Calvings != n {
+			t.Fatalf("dam age %d: %d calvings, want %d", age, c.Calvings, n)
+		}
+		cumulative := herd.calvingEaseAge(age).Cumulative
+		var calfDeaths, cowDeaths, labor, vet float64
+		for k, category := range herd.CalvingEase {
+			want := cumulative[k]
+			if k > 0 {
+				want -= cumulative[k-1]
+			}
+			got := float64(c.Categories[category.Category]) / n
+			if math.Abs(got-want) > .01 {
+				t.Errorf("dam age %d %s frequency %.4f, want %.4f", age, category.Category, got, want)
+			}
+			calfDeaths += want * category.CalfDeath
+			cowDeaths += want * category.CowDeath
+			labor += float64(c.Categories[category.Category]) * category.Labor
+			vet += float64(c.Categories[category.Category]) * category.Vet
+		}
+		if got := float64(c.CalfDeaths) / n; math.Abs(got-calfDeaths) > .005 {
+			t.Errorf("dam age %d calf deaths %.4f, want %.4f", age, got, calfDeaths)
+		}
+		if got := float64(c.CowDeaths) / n; math.Abs(got-cowDeaths) > .005 {
+			t.Errorf("dam age %d cow deaths %.4f, want %.4f", age, got, cowDeaths)
+		}
+		if math.Abs(c.Labor-labor) > 1e-6 || math.Abs(c.Vet-vet) > 1e-6 {
+			t.Errorf("dam age %d labor %v and vet %v, want %v and %v", age, c.Labor, c.Vet, labor, vet)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// embryo.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Embryo transfer for a class of a herd's females.  Recipients are drawn at
+// Proportion of the class and take an embryo rather than their own
+// protocol's AI, those left open go to the cleanup bulls from CleanupDay.
+type EmbryoTransfer_t struct {
+	Class         string  // heifers or cows
+	Proportion    float64 // Of the class used as recipients
+	TransferDay   int     // Days from the start of breeding, taken as the day of conception
+	PregnancyRate float64 // Proportion of recipients pregnant
+	Cost          float64 // Per transfer, the embryo is extra
+	CleanupDay    int     // Days from the start of breeding the cleanup bulls go in
+	Embryos       []int   // Positions in Embryos, one is drawn for each recipient
+}
+
+// A line of purchased embryos.  Its sire and dam are in Records but never
+// in a herd, so an embryo calf's direct genetics are theirs while its
+// recipient gives only the maternal effects.
+type Embryo_t struct {
+	Name  string
+	Breed string
+	Cost  float64         // Per embryo
+	Merit map[int]float64 // Of both parents, added to the foundation bulls' merit by genetic component
+	Sire  AnimalId        // Set by MakeEmbryoDonors
+	Dam   AnimalId
+}
+
+// Make the parents of the purchased embryos.  Their merit is the foundation
+// bulls' plus the line's own for the components given, the rest is drawn.
+func (st *State) MakeEmbryoDonors(gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) {
+
+	for k := range st.Embryos {
+		e := &st.Embryos[k]
+
+		for _, sex := range []string{Bull, Cow} {
+			var a Animal
+			a.Id = AnimalId(len(st.Records)) + 1
+			a.Sex = sex
+
+			st.genUnrelated(&a, gvCholesky, rvCholesky, st.Rng.AI, st.Rng.AI)
+			a.BreedComposition = map[string]float64{e.Breed: 1}
+
+			for j, m := range st.BullMerit {
+				a.BreedingValue.SetVec(j, a.BreedingValue.AtVec(j)+m)
+			}
+			for j, m := range e.Merit {
+				var base float64
+				if j < len(st.BullMerit) {
+					base = st.BullMerit[j]
+				}
+				a.BreedingValue.SetVec(j, base+m)
+			}
+
+			st.Records = append(st.Records, a)
+			if sex == Bull {
+				e.Sire = a.Id
+			} else {
+				e.Dam = a.Id
+			}
+		}
+
+		if st.OutputMode == "verbose" {
+			fmt.Printf("Embryos %v are from sire %d and dam %d\n", e.Name, e.Sire, e.Dam)
+		}
+	}
+}
+
+// The herd's embryo transfer for a class, nil when it has none
+func (herd *Herd) transfer(class string) *EmbryoTransfer_t {
+	for i := range herd.EmbryoTransfer {
+		if herd.EmbryoTransfer[i].Class == class {
+			return &herd.EmbryoTransfer[i]
+		}
+	}
+	return nil
+}
+
+// Transfer an embryo of a line drawn from the program's
+func (st *State) transferEmbryo(et *EmbryoTransfer_t, year int) (embryo *Embryo_t, pregnant bool) {
+
+	embryo = &st.Embryos[et.Embryos[randRange(st.Rng.AI, 0, len(et.Embryos))]]
+	pregnant = st.Rng.AI.Float64() < et.PregnancyRate
+
+	c := st.BreedingCosts[year]
+	c.Transfers++
+	c.ETCost += et.Cost + embryo.Cost
+	if pregnant {
+		c.ETPregnant++
+	}
+	st.BreedingCosts[year] = c
+
+	return embryo, pregnant
+}
+
+// The cow that carried and raised an animal, its recipient when it was a
+// transferred embryo otherwise its dam.  Nil when the dam is unknown.
+func (st *State) maternalDam(a *Animal) *Animal {
+	if r, ok := st.recipients[a.Id]; ok {
+		return &st.Records[r-1]
+	}
+	if a.Dam > 0 {
+		return &st.Records[a.Dam-1]
+	}
+	return nil
+}
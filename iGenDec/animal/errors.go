@@ -0,0 +1,71 @@
+// errors
+//
+// Errors found while simulating the herd
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"fmt"
+)
+
+// A cross of two breeds has no heterosis value for a trait
+type HeterosisError struct {
+	Trait      string
+	Component  string // D or M
+	CrossClass string // Heterosis codes of the two breeds - e.g., BTxBI
+}
+
+func (e *HeterosisError) Error() string {
+	return fmt.Sprintf("no HeterosisValues entry for %s,%s in cross class %s", e.Trait, e.Component, e.CrossClass)
+}
+
+// A phenotype that the model needs could not be made for an animal
+type PhenotypeError struct {
+	Id    AnimalId
+	Trait string
+}
+
+func (e *PhenotypeError) Error() string {
+	return fmt.Sprintf("cannot make the %s phenotype of animal %d", e.Trait, e.Id)
+}
+
+// The ageDist proportions of the foundation cows do not add to 1
+type AgeDistError struct {
+	Total float64
+}
+
+func (e *AgeDistError) Error() string {
+	return fmt.Sprintf("cow herd ageDist proportions add to %g, not 1.0", e.Total)
+}
+
+// Record the first error of the per-animal calculations that cannot return
+// one.  The simulation stops at the end of the year.
+func (st *State) fail(err error) {
+	if st.err == nil {
+		st.err = err
+	}
+}
+
+// The first error found while simulating, or nil
+func (st *State) Err() error {
+	return st.err
+}
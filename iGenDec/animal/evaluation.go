@@ -0,0 +1,430 @@
+// evaluation.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"fmt"
+	"math"
+)
+
+// Traits the yearly genetic evaluation can use.  They are recorded on the
+// calves born before the evaluation year, WW and YW only on calves that lived.
+var EvaluationTraits = []string{"BW", "WW", "YW"}
+
+// One trait of the yearly animal model evaluation
+type EvaluationTrait_t struct {
+	Trait    string
+	Direct   int           // Index of the direct component in ComponentList
+	Maternal int           // Index of the maternal component, -1 when the trait has none
+	G        [2][2]float64 // Genetic covariance matrix of the direct and maternal components
+	Re       float64       // Residual variance
+}
+
+// Estimated breeding values and their accuracies in ComponentList order.
+// Components that are not evaluated are 0 with 0 accuracy.
+type Ebv_t struct {
+	Value    []float64
+	Accuracy []float64
+}
+
+// How the EBVs of the youngest calves with records compare to the true breeding values
+type EvaluationSummary_t struct {
+	Year         int
+	Component    Component_t
+	N            int
+	MeanTrue     float64
+	MeanEbv      float64
+	MeanAccuracy float64
+	Correlation  float64
+}
+
+// Iterate until the residual of the equations is this fraction of the right hand side
+const (
+	evaluationTolerance  = 1e-8
+	evaluationIterations = 2000
+)
+
+// A phenotype in the evaluation
+type evalRecord_t struct {
+	animal int // Records position of the calf
+	dam    int // Records position of the dam, -1 when unknown
+	cg     int // Contemporary group
+	y      float64
+}
+
+// Calves of a herd, year, sex and age of dam are contemporaries
+type contemporaries_t struct {
+	Herd string
+	Year int
+	Male bool
+	Aod  int
+}
+
+// An off diagonal element of a row of the inverse relationship matrix
+type aInv_t struct {
+	j int
+	v float64
+}
+
+// Evaluate the traits of the evaluation with the records available at the
+// start of the year.  The EBVs replace those of the previous evaluation.
+func (st *State) Evaluate(year int) {
+
+	if len(st.Evaluation) == 0 {
+		return
+	}
+
+	// Phenotype writes the debugging file for its output trait
+	defer func(trait string) { st.PhenotypeOutputTrait = trait }(st.PhenotypeOutputTrait)
+	st.PhenotypeOutputTrait = ""
+
+	st.Ebv = make([]Ebv_t, len(st.Records))
+	for i := range st.Ebv {
+		st.Ebv[i].Value = make([]float64, len(st.ComponentList))
+		st.Ebv[i].Accuracy = make([]float64, len(st.ComponentList))
+	}
+	st.EvaluationYear = year
+
+	diag, off := st.relationshipInverse()
+	for _, t := range st.Evaluation {
+		st.evaluateTrait(t, year, diag, off)
+	}
+
+	st.summarizeEvaluation(year)
+}
+
+// The latest EBVs of an animal, false when it was born after the evaluation
+func (st *State) EstimatedBreedingValue(id AnimalId) (Ebv_t, bool) {
+	if id < 1 || int(id) > len(st.Ebv) {
+		return Ebv_t{}, false
+	}
+	return st.Ebv[id-1], true
+}
+
+// Henderson's rules for the inverse of the numerator relationship matrix
+// of everything in Records, with inbreeding
+func (st *State) relationshipInverse() (diag []float64, off [][]aInv_t) {
+
+	st.updateInbreeding()
+
+	diag = make([]float64, len(st.Records))
+	off = make([][]aInv_t, len(st.Records))
+
+	for i := range st.Records {
+		var parents []int
+		if s := st.Records[i].Sire; s > 0 {
+			parents = append(parents, int(s)-1)
+		}
+		if d := st.Records[i].Dam; d > 0 {
+			parents = append(parents, int(d)-1)
+		}
+		alpha := 1 / st.mendelian[i]
+
+		diag[i] += alpha
+		for _, p := range parents {
+			off[i] = append(off[i], aInv_t{j: p, v: -alpha / 2})
+			off[p] = append(off[p], aInv_t{j: i, v: -alpha / 2})
+			for _, q := range parents {
+				if p == q {
+					diag[p] += alpha / 4
+				} else {
+					off[p] = append(off[p], aInv_t{j: q, v: alpha / 4})
+				}
+			}
+		}
+	}
+	return diag, off
+}
+
+// Solve the animal model mixed model equations of one trait by preconditioned
+// conjugate gradients, iterating on the records with a diagonal preconditioner.
+// The model has contemporary groups, direct and, if the trait has one, maternal
+// breeding values.  Breed and heterosis effects are not fitted so they are
+// partly absorbed into the EBVs the way a within herd evaluation would.
+func (st *State) evaluateTrait(t EvaluationTrait_t, year int, diag []float64, off [][]aInv_t) {
+
+	n := len(st.Records)
+	maternal := t.Maternal >= 0
+
+	var recs []evalRecord_t
+	groups := make(map[contemporaries_t]int)
+	for i := range st.Records {
+		a := &st.Records[i]
+		if a.Sire == 0 || a.YearBorn < 1 || a.YearBorn >= year { // Bought in or not yet recorded
+			continue
+		}
+		if a.Dead != 0 && t.Trait != "BW" {
+			continue
+		}
+		y, ok := st.PhenotypeAtMeanAge(*a, t.Trait) // Weights are adjusted to a standard age
+		if t.Trait == "BW" {
+			y, ok = st.Phenotype(*a, t.Trait)
+		}
+		if !ok {
+			continue
+		}
+		c := contemporaries_t{Herd: a.HerdName, Year: a.YearBorn, Male: a.Sex == Steer || a.Sex == Bull, Aod: st.WhatAod(*a)}
+		g, ok := groups[c]
+		if !ok {
+			g = len(groups)
+			groups[c] = g
+		}
+		recs = append(recs, evalRecord_t{animal: i, dam: int(st.maternalDam(a).Id) - 1, cg: g, y: y})
+	}
+	if len(recs) == 0 {
+		return
+	}
+
+	own := make([][]int, n)    // Records of each animal
+	calves := make([][]int, n) // Records of each dam's calves
+	progeny := make([]float64, n)
+	for r, rec := range recs {
+		own[rec.animal] = append(own[rec.animal], r)
+		if rec.dam >= 0 {
+			calves[rec.dam] = append(calves[rec.dam], r)
+			progeny[rec.dam]++
+		}
+		if s := st.Records[rec.animal].Sire; s > 0 {
+			progeny[s-1]++
+		}
+	}
+
+	// Residual variance times the inverse of G
+	var k00, k01, k11 float64
+	if maternal {
+		det := t.G[0][0]*t.G[1][1] - t.G[0][1]*t.G[0][1]
+		k00 = t.Re * t.G[1][1] / det
+		k01 = -t.Re * t.G[0][1] / det
+		k11 = t.Re * t.G[0][0] / det
+	} else {
+		k00 = t.Re / t.G[0][0]
+	}
+
+	// Unknowns are the contemporary groups, then the direct and maternal values
+	ng := len(groups)
+	nm := 0
+	if maternal {
+		nm = n
+	}
+	size := ng + n + nm
+
+	// Left hand side times x by iteration on the records
+	lhs := func(x, y []float64) {
+		for i := range y {
+			y[i] = 0
+		}
+		for _, rec := range recs {
+			f := x[rec.cg] + x[ng+rec.animal]
+			if maternal {
+				f += .5 * x[ng+n+rec.dam]
+			}
+			y[rec.cg] += f
+			y[ng+rec.animal] += f
+			if maternal {
+				y[ng+n+rec.dam] += .5 * f
+			}
+		}
+		for i := 0; i < n; i++ {
+			xa, xm := x[ng+i], 0.
+			if maternal {
+				xm = x[ng+n+i]
+			}
+			y[ng+i] += diag[i] * (k00*xa + k01*xm)
+			if maternal {
+				y[ng+n+i] += diag[i] * (k01*xa + k11*xm)
+			}
+			for _, e := range off[i] {
+				ja, jm := x[ng+e.j], 0.
+				if maternal {
+					jm = x[ng+n+e.j]
+				}
+				y[ng+i] += e.v * (k00*ja + k01*jm)
+				if maternal {
+					y[ng+n+i] += e.v * (k01*ja + k11*jm)
+				}
+			}
+		}
+	}
+
+	rhs := make([]float64, size)
+	pre := make([]float64, size) // Inverse of the diagonal
+	for _, rec := range recs {
+		rhs[rec.cg] += rec.y
+		rhs[ng+rec.animal] += rec.y
+		pre[rec.cg]++
+		pre[ng+rec.animal]++
+		if maternal {
+			rhs[ng+n+rec.dam] += .5 * rec.y
+			pre[ng+n+rec.dam] += .25
+		}
+	}
+	for i := 0; i < n; i++ {
+		pre[ng+i] += diag[i] * k00
+		if maternal {
+			pre[ng+n+i] += diag[i] * k11
+		}
+	}
+	for i := range pre {
+		pre[i] = 1 / pre[i]
+	}
+
+	x, iter := conjugateGradients(lhs, rhs, pre)
+	a := x[ng : ng+n]
+	m := x[ng+n:]
+
+	// Reliabilities from daughter equivalents after VanRaden and Wiggans (1991).
+	// An own record is 1 and each recorded progeny (4-h2)/h2 records' worth.
+	vp := t.G[0][0] + .25*t.G[1][1] + .5*t.G[0][1] + t.Re
+	lambda := (vp - t.G[0][0]) / t.G[0][0]
+	h2 := t.G[0][0] / vp
+	de := make([]float64, n)
+	for i := range de {
+		de[i] = float64(len(own[i])) + progeny[i]*lambda*h2/(4-h2)
+	}
+	rel := st.reliabilities(de, lambda)
+	for i := range st.Ebv {
+		st.Ebv[i].Value[t.Direct] = a[i]
+		st.Ebv[i].Accuracy[t.Direct] = math.Sqrt(rel[i])
+	}
+
+	if maternal {
+		// Half the dam's maternal value is in each calf's record
+		lambda = (vp - .25*t.G[1][1]) / t.G[1][1]
+		for i := range de {
+			de[i] = .25 * float64(len(calves[i]))
+		}
+		rel = st.reliabilities(de, lambda)
+		for i := range st.Ebv {
+			st.Ebv[i].Value[t.Maternal] = m[i]
+			st.Ebv[i].Accuracy[t.Maternal] = math.Sqrt(rel[i])
+		}
+	}
+
+	if st.OutputMode == "verbose" {
+		fmt.Printf("Evaluated %s in year %d: %d records, %d contemporary groups, %d iterations\n", t.Trait, year, len(recs), len(groups), iter)
+	}
+}
+
+// Solve equations whose left hand side lhs multiplies a vector by
+// preconditioned conjugate gradients, pre the inverse of the diagonal
+func conjugateGradients(lhs func(x, y []float64), rhs, pre []float64) (x []float64, iter int) {
+	size := len(rhs)
+	x = make([]float64, size)
+	r := append([]float64(nil), rhs...)
+	z := make([]float64, size)
+	q := make([]float64, size)
+	for i := range z {
+		z[i] = pre[i] * r[i]
+	}
+	p := append([]float64(nil), z...)
+	rz := dot(r, z)
+	tolerance := evaluationTolerance * evaluationTolerance * dot(rhs, rhs)
+	for ; iter < evaluationIterations && dot(r, r) > tolerance; iter++ {
+		lhs(p, q)
+		alpha := rz / dot(p, q)
+		for i := range x {
+			x[i] += alpha * p[i]
+			r[i] -= alpha * q[i]
+			z[i] = pre[i] * r[i]
+		}
+		rzNew := dot(r, z)
+		beta := rzNew / rz
+		rz = rzNew
+		for i := range p {
+			p[i] = z[i] + beta*p[i]
+		}
+	}
+	return x, iter
+}
+
+// Add the parent average to each animal's daughter equivalents.  Parents
+// are earlier in Records than their calves so one pass is enough.
+func (st *State) reliabilities(de []float64, lambda float64) []float64 {
+	rel := make([]float64, len(de))
+	for i := range de {
+		var pa float64
+		if s := st.Records[i].Sire; s > 0 {
+			pa += rel[s-1] / 4
+		}
+		if d := st.Records[i].Dam; d > 0 {
+			pa += rel[d-1] / 4
+		}
+		total := de[i] + lambda*pa/(1-pa)
+		rel[i] = total / (total + lambda)
+	}
+	return rel
+}
+
+// Compare the EBVs of the calves born last year to their true breeding values
+func (st *State) summarizeEvaluation(year int) {
+
+	for _, t := range st.Evaluation {
+		comps := []int{t.Direct}
+		if t.Maternal >= 0 {
+			comps = append(comps, t.Maternal)
+		}
+		for _, c := range comps {
+			s := EvaluationSummary_t{Year: year, Component: st.ComponentList[c]}
+			var tt, ee, te float64
+			for i := range st.Ebv {
+				a := &st.Records[i]
+				if a.Sire == 0 || a.YearBorn != year-1 {
+					continue
+				}
+				tv := a.BreedingValue.AtVec(c)
+				ev := st.Ebv[i].Value[c]
+				s.N++
+				s.MeanTrue += tv
+				s.MeanEbv += ev
+				s.MeanAccuracy += st.Ebv[i].Accuracy[c]
+				tt += tv * tv
+				ee += ev * ev
+				te += tv * ev
+			}
+			if s.N == 0 {
+				continue
+			}
+			f := float64(s.N)
+			s.MeanTrue /= f
+			s.MeanEbv /= f
+			s.MeanAccuracy /= f
+			vt := tt/f - s.MeanTrue*s.MeanTrue
+			ve := ee/f - s.MeanEbv*s.MeanEbv
+			if vt > 0 && ve > 0 {
+				s.Correlation = (te/f - s.MeanTrue*s.MeanEbv) / math.Sqrt(vt*ve)
+			}
+			st.EvaluationTable = append(st.EvaluationTable, s)
+
+			if st.OutputMode == "verbose" {
+				fmt.Printf("Evaluation %d %s,%s calves %d: mean true %.3f mean EBV %.3f accuracy %.3f correlation %.3f\n",
+					year, s.Component.TraitName, s.Component.Component, s.N, s.MeanTrue, s.MeanEbv, s.MeanAccuracy, s.Correlation)
+			}
+		}
+	}
+}
+
+func dot(x, y []float64) (s float64) {
+	for i := range x {
+		s += x[i] * y[i]
+	}
+	return s
+}
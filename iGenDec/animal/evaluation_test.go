@@ -0,0 +1,148 @@
+// evaluation_test.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// A small pedigree, parents before their calves.  5 is from a full sib
+// mating, 8 from a half sib mating and 9 from a sire and his inbred daughter.
+func testPedigree() *State {
+	st := NewState(1, "")
+	for i, p := range [][2]AnimalId{{0, 0}, {0, 0}, {1, 2}, {1, 2}, {3, 4}, {0, 0}, {1, 6}, {3, 7}, {3, 5}} {
+		st.Records = append(st.Records, Animal{Id: AnimalId(i + 1), Sire: p[0], Dam: p[1]})
+	}
+	return st
+}
+
+// The numerator relationship matrix of Records by the tabular method
+func tabularRelationships(st *State) *mat.SymDense {
+	n := len(st.Records)
+	a := mat.NewSymDense(n, nil)
+	for i := 0; i < n; i++ {
+		s, d := int(st.Records[i].Sire)-1, int(st.Records[i].Dam)-1
+		for j := 0; j < i; j++ {
+			var v float64
+			if s >= 0 {
+				v += .5 * a.At(j, s)
+			}
+			if d >= 0 {
+				v += .5 * a.At(j, d)
+			}
+			a.SetSym(i, j, v)
+		}
+		aii := 1.
+		if s >= 0 && d >= 0 {
+			aii += .5 * a.At(s, d)
+		}
+		a.SetSym(i, i, aii)
+	}
+	return a
+}
+
+// The inverse relationship matrix from Henderson's rules as a dense matrix
+func denseInverse(diag []float64, off [][]aInv_t) *mat.Dense {
+	n := len(diag)
+	ainv := mat.NewDense(n, n, nil)
+	for i := range diag {
+		ainv.Set(i, i, ainv.At(i, i)+diag[i])
+		for _, e := range off[i] {
+			ainv.Set(i, e.j, ainv.At(i, e.j)+e.v)
+		}
+	}
+	return ainv
+}
+
+func TestRelationshipInverse(t *testing.T) {
+	st := testPedigree()
+	diag, off := st.relationshipInverse()
+
+	var product mat.Dense
+	product.Mul(denseInverse(diag, off), tabularRelationships(st))
+	n := len(diag)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			want := 0.
+			if i == j {
+				want = 1
+			}
+			if got := product.At(i, j); math.Abs(got-want) > 1e-12 {
+				t.Errorf("A inverse times A [%d,%d] = %v, want %v", i, j, got, want)
+			}
+		}
+	}
+}
+
+// A mean and breeding values with records on six of the animals, h2 1/3
+func TestConjugateGradients(t *testing.T) {
+	st := testPedigree()
+	diag, off := st.relationshipInverse()
+	ainv := denseInverse(diag, off)
+
+	n := len(st.Records)
+	size := 1 + n
+	k := 2.
+	m := mat.NewDense(size, size, nil)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			m.Set(1+i, 1+j, k*ainv.At(i, j))
+		}
+	}
+	rhs := make([]float64, size)
+	for _, r := range []struct {
+		animal int
+		y      float64
+	}{{2, 10}, {3, 14}, {4, 9}, {6, 12}, {7, 7}, {8, 11}} {
+		for _, i := range []int{0, 1 + r.animal} {
+			for _, j := range []int{0, 1 + r.animal} {
+				m.Set(i, j, m.At(i, j)+1)
+			}
+			rhs[i] += r.y
+		}
+	}
+	pre := make([]float64, size)
+	for i := range pre {
+		pre[i] = 1 / m.At(i, i)
+	}
+
+	lhs := func(x, y []float64) {
+		mat.NewVecDense(size, y).MulVec(m, mat.NewVecDense(size, x))
+	}
+	x, iter := conjugateGradients(lhs, rhs, pre)
+	if iter > size {
+		t.Errorf("took %d iterations for %d equations", iter, size)
+	}
+
+	var want mat.VecDense
+	if err := want.SolveVec(m, mat.NewVecDense(size, rhs)); err != nil {
+		t.Fatal(err)
+	}
+	for i := range x {
+		if math.Abs(x[i]-want.AtVec(i)) > 1e-6 {
+			t.Errorf("solution %d = %v, want %v", i, x[i], want.AtVec(i))
+		}
+	}
+}
@@ -25,35 +25,27 @@ import (
 	"fmt"
 	//"os"
 	//"math"
-	"strconv"
-
-	"github.com/blgolden/iGenDecModel/iGenDec/logger"
 
 	"gonum.org/v1/gonum/mat"
 	// "gonum.org/v1/gonum/stat/distuv"
 )
 
-var Traits []string
-var Components []string
-
-// For the culling policy it is the length of ageDist in master.hjson
-var MaxCowAge int
-
 // Foundation bulls
-func MakeFoundationBulls(
+func (st *State) MakeFoundationBulls(
 	gvCholesky mat.Cholesky,
-	rvCholesky mat.Cholesky,
-	param map[string]interface{}) {
+	rvCholesky mat.Cholesky) {
 
-	for _, h := range Herds {
+	for _, n := range st.HerdNames {
+		h := st.Herds[n]
 		thisHerd := &h
 
-		nBulls := int(param["nFoundationBulls"].(float64))
-		if *logger.OutputMode == "verbose" {
+		nBulls := st.NFoundationBulls
+		if st.OutputMode == "verbose" {
 			fmt.Println("Foundation bulls for: ", thisHerd.HerdName)
 			fmt.Println("Number of foundation bulls:", nBulls)
 		}
-		idCounter := AnimalId(len(Records))
+		idCounter := AnimalId(len(st.Records))
+		var made []*Animal
 		for i := 0; i < nBulls; i++ {
 
 			idCounter++
@@ -64,65 +56,68 @@ func MakeFoundationBulls(
 			a.BirthDate = 0
 			a.Active = true
 			a.HerdName = h.HerdName
+			if h.BullServiceLife > 0 { // Spread the ages so they are not all culled the same year
+				a.YearBorn = -(i % h.BullServiceLife)
+			}
 
-			GenFoundation(&a, gvCholesky, rvCholesky) // Make this Bull
+			st.GenFoundation(&a, gvCholesky, rvCholesky) // Make this Bull
 
-			GenBullBatteryBreedComposition(&a)
+			st.GenBullBatteryBreedComposition(&a)
+			st.setPlanBreed(thisHerd, &a, made)
+			made = append(made, &a)
 
-			for j, m := range BullMerit {
+			for j, m := range st.BullMerit {
 				bv := a.BreedingValue.AtVec(j)
 				bv += m
 				a.BreedingValue.SetVec(j, bv)
 			}
 
-			Records = append(Records, a) // Create a record
+			st.Records = append(st.Records, a) // Create a record
 
 		}
-		h.Bulls = ActiveBulls(&h)
+		h.Bulls = st.ActiveBulls(&h)
 	}
 }
 
 // Make a herd of foundation cows
-func MakeFoundationCowHerd(
+func (st *State) MakeFoundationCowHerd(
 	gvCholesky mat.Cholesky,
-	rvCholesky mat.Cholesky,
-	param map[string]interface{}) (cowHerdSize int) {
+	rvCholesky mat.Cholesky) (cowHerdSize int, err error) {
 
-	if *logger.OutputMode == "verbose" {
-		if len(Herds) > 1 {
-			fmt.Printf("Making %d foundation herds\n", len(Herds))
+	if st.OutputMode == "verbose" {
+		if len(st.Herds) > 1 {
+			fmt.Printf("Making %d foundation herds\n", len(st.Herds))
 		} else {
 			fmt.Printf("Making 1 foundation herd\n")
 		}
 	}
 
 	// Initial cow herd age distribution from the hjson file
-	array := param["ageDist"].([]interface{})
+	array := st.AgeDist
 
 	var k int
-	for _, h := range Herds {
+	for _, n := range st.HerdNames {
+		h := st.Herds[n]
 		cowHerdSize = h.NumberCows
 
-		if *logger.OutputMode == "verbose" {
+		if st.OutputMode == "verbose" {
 			fmt.Println("Foundation herd size:", cowHerdSize)
 		}
 		var v []float64 // The proportion at each age of cow in years
 		var totProp float64
 		for i := range array {
-			f, _ := strconv.ParseFloat(array[i].(string), 64)
-			v = append(v, f)
+			v = append(v, array[i])
 			totProp += v[i]
 		}
 		if totProp > 1.001 || totProp < .999 {
-			//fmt.Fprintf(os.Stderr, "Cow herd proportion does not add to 1.0: %g\n", totProp)
-			logger.LogWriterFatal("Cow herd proportion does not add to 1.0")
+			return 0, &AgeDistError{Total: totProp}
 		}
 
 		// loop through each cow age and make that proportion
 		// of foundation animals
 		var idCounter AnimalId
 		if k > 0 {
-			idCounter = AnimalId(len(Records))
+			idCounter = AnimalId(len(st.Records))
 		} else {
 			idCounter = 0
 		}
@@ -138,26 +133,26 @@ func MakeFoundationCowHerd(
 				var a Animal
 				a.Id = idCounter
 				a.Sex = Cow
-				a.BirthDate = Date((len(v)-i)*-1*365 + Rng.Intn(int(h.BreedingSeasonLen)) + GestationLengthError())
+				a.BirthDate = Date((len(v)-i)*-1*365 + st.Rng.Breeding.Intn(int(h.BreedingSeasonLen)) + GestationLengthError(st.Rng.Breeding))
 				a.YearBorn = (len(v) - i) * -1
 				a.Active = true
 				a.HerdName = h.HerdName
 
-				GenFoundation(&a, gvCholesky, rvCholesky) // Make this cow
+				st.GenFoundation(&a, gvCholesky, rvCholesky) // Make this cow
 
-				GenFoundationBreedComposition(&a)
+				st.GenFoundationBreedComposition(&a)
 
-				Records = append(Records, a) // Create a record
+				st.Records = append(st.Records, a) // Create a record
 
 				//herd[h].Cows = append(herd[h].Cows, &Records[len(Records)-1]) // List of active cows
 				//fmt.Printf("LOC 2 %p\n", &Records[len(Records)-1])
 			}
 		}
-		h.Cows = ActiveCows(&h)
+		h.Cows = st.ActiveCows(&h)
 	}
 
 	// for culling policy
-	MaxCowAge = len(array) + 1 // +1 because it starts at 2yoa
+	st.MaxCowAge = len(array) + 1 // +1 because it starts at 2yoa
 
 	return
 }
@@ -166,26 +161,26 @@ func GestationLength() Date {
 }
 
 // Make the foundation heifers
-func MakeFoundationHeifers(
+func (st *State) MakeFoundationHeifers(
 	gvCholesky mat.Cholesky,
-	rvCholesky mat.Cholesky,
-	param map[string]interface{}) (cowHerdSize int) {
+	rvCholesky mat.Cholesky) (cowHerdSize int) {
 
-	if *logger.OutputMode == "verbose" {
-		if len(Herds) > 1 {
-			fmt.Printf("Making foundation heifers for %d herds\n", len(Herds))
+	if st.OutputMode == "verbose" {
+		if len(st.Herds) > 1 {
+			fmt.Printf("Making foundation heifers for %d herds\n", len(st.Herds))
 		} else {
 			fmt.Printf("Making foundation heifers for 1 herd\n")
 		}
 	}
 
-	for _, h := range Herds {
+	for _, n := range st.HerdNames {
+		h := st.Herds[n]
 
 		cowHerdSize = h.NumberCows
 
 		nHeifers := int(.2 * float32(cowHerdSize))
 
-		idCounter := AnimalId(len(Records))
+		idCounter := AnimalId(len(st.Records))
 
 		for i := 0; i < nHeifers; i++ {
 
@@ -194,16 +189,16 @@ func MakeFoundationHeifers(
 			var a Animal
 			a.Id = idCounter
 			a.Sex = Heifer
-			a.BirthDate = h.StartBreeding + Date(Rng.Intn(int(h.BreedingSeasonLen))) + GestationLength() - 365
+			a.BirthDate = h.StartBreeding + Date(st.Rng.Breeding.Intn(int(h.BreedingSeasonLen))) + GestationLength() - 365
 			a.YearBorn = 0
 			a.Active = false
 			a.HerdName = h.HerdName
 
-			GenFoundation(&a, gvCholesky, rvCholesky) // Make this heifer
+			st.GenFoundation(&a, gvCholesky, rvCholesky) // Make this heifer
 
-			GenFoundationBreedComposition(&a)
+			st.GenFoundationBreedComposition(&a)
 
-			Records = append(Records, a) // Create a record
+			st.Records = append(st.Records, a) // Create a record
 		}
 
 	}
@@ -0,0 +1,303 @@
+// genome.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// A row of genome: in the hjson, chromosomes of the same length and loci
+type ChromosomeSet_t struct {
+	Number  int     // Chromosomes like this
+	Length  float64 // Morgans
+	Markers int     // SNP markers per chromosome
+	QTL     int     // QTL per chromosome
+}
+
+// The genome of the genomic mode.  The loci of all of the chromosomes,
+// markers and QTL together, are in order of chromosome and position.
+type Genome_t struct {
+	Chromosomes []Chromosome_t
+	Loci        []Locus_t
+	QTL         []int       // Index in Loci of each QTL
+	Effects     [][]float64 // Allele substitution effects of each QTL by genetic component
+}
+
+type Chromosome_t struct {
+	Length float64 // Morgans
+	First  int     // Index in Loci of its first locus
+	N      int     // Number of its loci
+}
+
+type Locus_t struct {
+	Position  float64 // Morgans from the start of the chromosome
+	QTL       bool
+	Frequency float64 // Founder frequency of the 1 allele
+}
+
+// An animal's paternal and maternal haplotypes, a bit for each locus
+type Haplotypes_t [2][]uint64
+
+// Founder allele frequencies are drawn between these
+const (
+	minimumFounderFrequency = .05
+	maximumFounderFrequency = .95
+)
+
+// Place the markers and QTL, draw their founder allele frequencies and the
+// QTL effects.  The effects are drawn from the genetic covariance matrix and
+// then scaled so the founders' genetic covariance is the matrix.
+func (st *State) MakeGenome(gvCholesky mat.Cholesky) error {
+
+	if len(st.GenomeLayout) == 0 {
+		return nil
+	}
+	rng := st.Rng.Genome
+
+	g := new(Genome_t)
+	for _, set := range st.GenomeLayout {
+		for c := 0; c < set.Number; c++ {
+			var loci []Locus_t
+			for i := 0; i < set.Markers+set.QTL; i++ {
+				loci = append(loci, Locus_t{
+					Position:  rng.Float64() * set.Length,
+					QTL:       i >= set.Markers,
+					Frequency: minimumFounderFrequency + rng.Float64()*(maximumFounderFrequency-minimumFounderFrequency)})
+			}
+			sort.SliceStable(loci, func(i, j int) bool { return loci[i].Position < loci[j].Position })
+
+			g.Chromosomes = append(g.Chromosomes, Chromosome_t{Length: set.Length, First: len(g.Loci), N: len(loci)})
+			g.Loci = append(g.Loci, loci...)
+		}
+	}
+
+	var lg mat.TriDense
+	gvCholesky.LTo(&lg)
+	n, _ := gvCholesky.Dims()
+
+	v := mat.NewSymDense(n, nil) // Founder genetic covariance of the drawn effects
+	for i, l := range g.Loci {
+		if !l.QTL {
+			continue
+		}
+		z := make([]float64, n)
+		for j := range z {
+			z[j] = rng.NormFloat64()
+		}
+		var a mat.VecDense
+		a.MulVec(&lg, mat.NewVecDense(n, z))
+		v.SymRankOne(v, 2*l.Frequency*(1-l.Frequency), &a)
+
+		g.QTL = append(g.QTL, i)
+		g.Effects = append(g.Effects, a.RawVector().Data)
+	}
+
+	var cv mat.Cholesky
+	if ok := cv.Factorize(v); !ok {
+		return errors.New("genome: the QTL effects do not span the genetic covariance matrix, more QTL are needed")
+	}
+	var lv, inv mat.TriDense
+	cv.LTo(&lv)
+	if err := inv.InverseTri(&lv); err != nil {
+		return fmt.Errorf("genome: %w", err)
+	}
+	var scale mat.Dense
+	scale.Mul(&lg, &inv)
+	for q := range g.Effects {
+		var a mat.VecDense
+		a.MulVec(&scale, mat.NewVecDense(n, g.Effects[q]))
+		g.Effects[q] = a.RawVector().Data
+	}
+
+	st.Genome = g
+	st.haplotypes = make(map[AnimalId]Haplotypes_t)
+
+	if st.OutputMode == "verbose" {
+		fmt.Printf("Genome: %d chromosomes, %d loci of which %d are QTL\n", len(g.Chromosomes), len(g.Loci), len(g.QTL))
+	}
+	return nil
+}
+
+func newHaplotype(n int) []uint64 {
+	return make([]uint64, (n+63)/64)
+}
+
+func allele(h []uint64, i int) uint64 {
+	return h[i/64] >> uint(i%64) & 1
+}
+
+func setAllele(h []uint64, i int) {
+	h[i/64] |= 1 << uint(i%64)
+}
+
+// Draw the haplotypes of an animal with unknown parents from the founder frequencies
+func (st *State) founderHaplotypes(a *Animal, rng *rand.Rand) {
+	var h Haplotypes_t
+	for k := range h {
+		h[k] = newHaplotype(len(st.Genome.Loci))
+		for i, l := range st.Genome.Loci {
+			if rng.Float64() < l.Frequency {
+				setAllele(h[k], i)
+			}
+		}
+	}
+	st.haplotypes[a.Id] = h
+}
+
+// A gamete of a parent.  Crossovers are a Poisson process along each
+// chromosome, one per Morgan, without interference.
+func (st *State) gamete(parent AnimalId, rng *rand.Rand) []uint64 {
+	h := st.haplotypes[parent]
+	g := newHaplotype(len(st.Genome.Loci))
+	for _, c := range st.Genome.Chromosomes {
+		from := rng.Intn(2)
+		next := rng.ExpFloat64()
+		for i := c.First; i < c.First+c.N; i++ {
+			for st.Genome.Loci[i].Position >= next {
+				from = 1 - from
+				next += rng.ExpFloat64()
+			}
+			if allele(h[from], i) == 1 {
+				setAllele(g, i)
+			}
+		}
+	}
+	return g
+}
+
+// The genomic breeding values of an animal from its QTL, as deviations from the founders
+func (st *State) genomicValue(id AnimalId) *mat.VecDense {
+	h := st.haplotypes[id]
+	v := mat.NewVecDense(len(st.Genome.Effects[0]), nil)
+	for q, i := range st.Genome.QTL {
+		x := float64(allele(h[0], i)+allele(h[1], i)) - 2*st.Genome.Loci[i].Frequency
+		if x != 0 {
+			v.AddScaledVec(v, x, mat.NewVecDense(len(st.Genome.Effects[q]), st.Genome.Effects[q]))
+		}
+	}
+	return v
+}
+
+// A calf's breeding values from the haplotypes it inherits.  What the
+// parents' breeding values have beyond their genomic values, such as the
+// merit of bought bulls, is passed on as their average.
+func (st *State) inheritGenome(n *Animal, sire *Animal, dam *Animal) *mat.VecDense {
+	st.haplotypes[n.Id] = Haplotypes_t{st.gamete(sire.Id, st.Rng.Genetic), st.gamete(dam.Id, st.Rng.Genetic)}
+
+	var pAve mat.VecDense
+	pAve.AddVec(sire.BreedingValue, dam.BreedingValue)
+	pAve.SubVec(&pAve, st.genomicValue(sire.Id))
+	pAve.SubVec(&pAve, st.genomicValue(dam.Id))
+	pAve.ScaleVec(.5, &pAve)
+
+	bv := st.genomicValue(n.Id)
+	bv.AddVec(bv, &pAve)
+	return bv
+}
+
+// Proportion of the QTL segregating in the calves born in a year and the
+// variance of their breeding values by genetic component
+type GenomeYear_t struct {
+	Year        int
+	Calves      int
+	Segregating float64
+	Variance    []float64
+}
+
+// The genome summary of the calves born in each year after the burnin
+func (st *State) GenomeByYear() []GenomeYear_t {
+	if st.Genome == nil {
+		return nil
+	}
+
+	calves := make(map[int][]*Animal)
+	for i := range st.Records {
+		a := &st.Records[i]
+		if a.YearBorn > st.Burnin && a.Sire > 0 && a.Dam > 0 {
+			calves[a.YearBorn] = append(calves[a.YearBorn], a)
+		}
+	}
+
+	var years []GenomeYear_t
+	for y := st.Burnin + 1; y <= st.Burnin+st.YearsPlanningHorizon; y++ {
+		c := calves[y]
+		if len(c) == 0 {
+			continue
+		}
+		t := GenomeYear_t{Year: y, Calves: len(c)}
+
+		segregating := 0
+		for _, i := range st.Genome.QTL {
+			ones := 0
+			for _, a := range c {
+				h := st.haplotypes[a.Id]
+				ones += int(allele(h[0], i) + allele(h[1], i))
+			}
+			if ones > 0 && ones < 2*len(c) {
+				segregating++
+			}
+		}
+		t.Segregating = float64(segregating) / float64(len(st.Genome.QTL))
+
+		n := c[0].BreedingValue.Len()
+		for j := 0; j < n; j++ {
+			var sum, sumSquared float64
+			for _, a := range c {
+				x := a.BreedingValue.AtVec(j)
+				sum += x
+				sumSquared += x * x
+			}
+			var v float64
+			if len(c) > 1 {
+				v = (sumSquared - sum*sum/float64(len(c))) / float64(len(c)-1)
+			}
+			t.Variance = append(t.Variance, v)
+		}
+		years = append(years, t)
+	}
+	return years
+}
+
+// Print the genome summary table
+func (st *State) PrintGenome() {
+	if st.Genome == nil {
+		return
+	}
+	fmt.Printf("\nGenome of the calves, QTL segregating and breeding value variance:\n")
+	fmt.Printf("Year   Calves  Segregating")
+	for _, c := range st.ComponentList {
+		fmt.Printf(" %10s", c.TraitName+","+c.Component)
+	}
+	fmt.Println()
+	for _, y := range st.GenomeByYear() {
+		fmt.Printf("%4d  %7d  %11.4f", y.Year, y.Calves, y.Segregating)
+		for _, v := range y.Variance {
+			fmt.Printf(" %10.3f", v)
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,68 @@
+// genome_test.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// The scaled QTL effects give the founders exactly the genetic covariance
+// matrix, the sum over QTL of 2pq times the outer product of the effects
+func TestGenomeScaling(t *testing.T) {
+	g := mat.NewSymDense(2, []float64{25, -6, -6, 16})
+	var chol mat.Cholesky
+	if !chol.Factorize(g) {
+		t.Fatal("covariance matrix is not positive definite")
+	}
+
+	st := NewState(7, "")
+	st.GenomeLayout = []ChromosomeSet_t{{Number: 3, Length: 1.2, Markers: 20, QTL: 10}}
+	if err := st.MakeGenome(chol); err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Genome.Loci) != 90 || len(st.Genome.QTL) != 30 {
+		t.Fatalf("%d loci and %d QTL, want 90 and 30", len(st.Genome.Loci), len(st.Genome.QTL))
+	}
+
+	v := mat.NewSymDense(2, nil)
+	for q, i := range st.Genome.QTL {
+		p := st.Genome.Loci[i].Frequency
+		v.SymRankOne(v, 2*p*(1-p), mat.NewVecDense(2, st.Genome.Effects[q]))
+	}
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			if math.Abs(v.At(i, j)-g.At(i, j)) > 1e-9 {
+				t.Errorf("founder genetic covariance [%d,%d] = %v, want %v", i, j, v.At(i, j), g.At(i, j))
+			}
+		}
+	}
+
+	// One QTL cannot give two components their covariance matrix
+	st = NewState(7, "")
+	st.GenomeLayout = []ChromosomeSet_t{{Number: 1, Length: 1, Markers: 5, QTL: 1}}
+	if err := st.MakeGenome(chol); err == nil {
+		t.Error("one QTL for two components did not fail")
+	}
+}
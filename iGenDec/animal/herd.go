@@ -24,33 +24,21 @@ SOFTWARE.
 package animal
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"os"
+	"sort"
 
 	"gonum.org/v1/gonum/mat"
 	"gonum.org/v1/gonum/stat/distuv"
 )
 
-var Records []Animal
-var Herds map[string]Herd // can be more than 1 such as spring v fall
-
-var CowAgeFile *os.File            // For cowagefilename: in master.hjson if exists
-var RecordsDumpFile = ""           // name of file in recordsdump:
-var BreedingRecordsDumpFile = ""   // name of file in breedingrecordsdump:
-var CowsExposedPerYear map[int]int // Counts of the number of cows exposed each year
-var Burnin int                     // Number of years to simulate before calculating the MEV
-var YearsPlanningHorizon int       // Total Number of years to run the simulation after the burnin for MEV calculation
-
 type BreedComposition_t struct { // Filled in by the CowHerdBreedComposition: key table
 	Proportion       float64 // cumulative 1st field of CowHerdBreedComposition: key so last value is 100% or 1.0
 	BreedProportions map[string]float64
 }
 
-var FoundationCowHerdBreedCompositionTable []BreedComposition_t
-var BullBatteryBreedCompositionTable []BreedComposition_t
-var CurrentCalvesBreedCompositionTable []BreedComposition_t
-
 type Herd struct { // There can be more than one
 
 	// These are the parameters read from herds: in the hjson
@@ -64,6 +52,27 @@ type Herd struct { // There can be more than one
 	CalvingDifficultyDistribution distuv.Normal // Unadjusted phenotype probability threshold for breeding set in MakeFoundationHeifers()
 	InitialCalvingDeathLessRate   float64       // Initial calving difficulty death loss rate
 
+	// From calvingEase: and calvingEaseFrequencies: in the hjson.  Without
+	// them heifers and their calves die at InitialCalvingDeathLessRate.
+	CalvingEase     []CalvingEase_t
+	CalvingEaseAges []CalvingEaseAge_t // By dam age, youngest first
+	Dystocia        []Dystocia_t       // From dystocia: in the hjson, by dam age, youngest first
+
+	// From bullManagement: in the hjson.  A zero BullServiceLife keeps the foundation bulls every year.
+	BullServiceLife int     // Breeding seasons a bull is used
+	CowsPerBull     float64 // Bulls are bought to keep this ratio
+	BullInjuryRate  float64 // Yearly proportion of bulls culled for injury
+
+	// From matingPlans: in the hjson.  No plan breeds cows to any of the herd's bulls.
+	MatingPlan         string   // rotation, terminal or rotaTerminal
+	RotationBreeds     []string // Sire breeds of a rotation in the order they are used
+	TerminalBreed      string   // Sire breed of the terminal part of a plan
+	TerminalProportion float64  // Proportion of the cows, oldest mature first, bred to terminal sires
+
+	// From synchronization: in the hjson.  Classes without a protocol are bred naturally.
+	Synchronization []Synchronization_t
+	EmbryoTransfer  []EmbryoTransfer_t // From embryoTransfer: in the hjson
+
 	// These are periodically reset
 	Cows   []*Animal // List of cows active in the herd
 	Calves []*Animal // List of pre-weaning calves active in the herd
@@ -80,27 +89,29 @@ type HerdYear_t struct {
 }
 
 type BreedingRecordsTable_t struct {
-	CowsExposed        int
-	CowsBred           int
-	CowsCulledOpen     int
-	CowsCulledOld      int
-	HeifersExposed     int
-	HeifersBred        int
-	HeifersCulledOpen  int
-	HeifersDiedCalving int
+	CowsExposed          int
+	CowsBred             int
+	CowsCulledOpen       int
+	CowsCulledOld        int
+	HeifersExposed       int
+	HeifersBred          int
+	HeifersCulledOpen    int
+	HeifersDiedCalving   int
+	CowsDiedCalving      int
+	HeifersCulledSurplus int
+	BullsBought          int
+	BullsCulledOld       int
+	BullsCulledInjured   int
 }
 
-var BreedingRecordsYearTable map[HerdYear_t]BreedingRecordsTable_t
-
 //var HeiferResetList []int // List of heifer locates in Records that need to be reset to heifer when bumping index components
-var CowResetList []Animal // List of Records[] to reset to active cows when bumping index components
 
-func DumpRecords() {
-	if RecordsDumpFile == "" {
+func (st *State) DumpRecords() {
+	if st.RecordsDumpFile == "" {
 		return
 	}
 
-	f, _ := os.Create(RecordsDumpFile)
+	f, _ := os.Create(st.RecordsDumpFile)
 	defer f.Close()
 	/*fmt.Fprintf(f, "RecNo ID Dam ")
 	for name, _ := range TraitMean {
@@ -108,11 +119,11 @@ func DumpRecords() {
 	}
 	fmt.Fprintf(f, "\n")*/
 
-	for i := 0; i < len(Records); i++ {
-		w := MatureWeightAtAgePhenotype(Records[i], Date(1735))
-		p := Records[i].BreedingValue.AtVec(13)
-		fmt.Fprintf(f, "%5d %5d %s %5d %f %f ", i, Records[i].Id, Records[i].Sex,
-			Records[i].BirthDate, w, p)
+	for i := 0; i < len(st.Records); i++ {
+		w := st.MatureWeightAtAgePhenotype(st.Records[i], Date(1735))
+		p := st.Records[i].BreedingValue.AtVec(13)
+		fmt.Fprintf(f, "%5d %5d %s %5d %f %f ", i, st.Records[i].Id, st.Records[i].Sex,
+			st.Records[i].BirthDate, w, p)
 		/*for t := range Traits {
 			if r, ok := Phenotype(Records[i], Traits[t]); ok {
 				fmt.Fprintf(f, "%5s: %7.2f ", Traits[t], r)
@@ -166,75 +177,88 @@ func PrintCowAgeDistribution(herds []Herd, thisYear int, param map[string]interf
 
 }
 */
-func WriteCowAgeDistribution(herds map[string]Herd, thisYear int, param map[string]interface{}) {
+func (st *State) WriteCowAgeDistribution(herds map[string]Herd, thisYear int) {
 
-	if CowAgeFile == nil {
+	if st.CowAgeFile == nil {
 		return
 	}
 
 	for _, h := range herds {
-		h.Cows = ActiveCows(&h)
+		h.Cows = st.ActiveCows(&h)
 
 		thisHerd := &h
 
-		var ageCounts = make([]int, MaxCowAge+1)
+		var ageCounts = make([]int, st.MaxCowAge+1)
 
 		for i := 0; i < len(thisHerd.Cows); i++ {
 
 			thisAge := int(math.Round((float64(
 				thisHerd.Cows[i].BreedingRecords[len(thisHerd.Cows[i].BreedingRecords)-1].CalvingDate-
 					thisHerd.Cows[i].BirthDate) / 365.)))
-			if thisAge > MaxCowAge {
-				thisAge = MaxCowAge
+			if thisAge > st.MaxCowAge {
+				thisAge = st.MaxCowAge
 			}
 
 			ageCounts[thisAge]++
 		}
 
-		fmt.Fprintf(CowAgeFile, "%5d ", thisYear)
+		fmt.Fprintf(st.CowAgeFile, "%5d ", thisYear)
 		for i := 2; i < len(ageCounts); i++ {
-			fmt.Fprintf(CowAgeFile, "%5d", ageCounts[i])
+			fmt.Fprintf(st.CowAgeFile, "%5d", ageCounts[i])
 		}
-		fmt.Fprintf(CowAgeFile, "\n")
+		fmt.Fprintf(st.CowAgeFile, "\n")
 	}
 
 }
 
 // Simulate a base set of records to calculate where the MEV deviate from
-func SimulateBase(Param map[string]interface{}, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) {
+func (st *State) SimulateBase(ctx context.Context, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) error {
 
 	// Must go 2 years beyond to get tthe heifers out, etc.
 
 	var bPlusPh int
-	if IndexTerminal {
-		bPlusPh = Burnin + YearsPlanningHorizon
+	if st.IndexTerminal {
+		bPlusPh = st.Burnin + st.YearsPlanningHorizon
 	} else {
-		bPlusPh = Burnin + YearsPlanningHorizon + 2
+		bPlusPh = st.Burnin + st.YearsPlanningHorizon + 2
 	}
 
-	for year := Burnin + 1; year <= bPlusPh; year++ {
-		for _, h := range Herds {
+	for year := st.Burnin + 1; year <= bPlusPh; year++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		st.Evaluate(year)
+		for _, n := range st.HerdNames {
+			h := st.Herds[n]
+
+			st.ManageBulls(&h, year, gvCholesky, rvCholesky)
+
+			st.Breed(&h, year)
 
-			Breed(&h, year)
+			st.Calve(&h, year, gvCholesky, rvCholesky)
 
-			Calve(&h, year, gvCholesky, rvCholesky)
+			st.CullOpen(&h, year)
 
-			CullOpen(&h, year)
+			st.WriteCowAgeDistribution(st.Herds, year-1)
 
-			WriteCowAgeDistribution(Herds, year-1, Param)
+			st.CullOld(&h, year)
 
-			CullOld(&h, year)
+			st.CullSurplusHeifers(&h, year)
 
-			DetermineCowAum(&h, year)
+			st.DetermineCowAum(&h, year)
 
 		}
+		if st.err != nil {
+			return st.err
+		}
 	}
+	return nil
 }
 
 // Determine the AUM consumption for active cows at end of year
 // Considering that some may be heifers that entered
-func DetermineCowAum(h *Herd, year int) {
-	h.Cows = ActiveCows(h)
+func (st *State) DetermineCowAum(h *Herd, year int) {
+	h.Cows = st.ActiveCows(h)
 
 	for _, c := range h.Cows {
 
@@ -251,13 +275,22 @@ func DetermineCowAum(h *Herd, year int) {
 			var aum Aum_t
 			aum.Year = year
 			aum.MonthOfYear = m
-			w := MatureWeightAtAgePhenotype(*c, Date(year*365+m*30))
-			aum.Aum = w / 1000. * CowAumAt1000
+			w := st.MatureWeightAtAgePhenotype(*c, Date(year*365+m*30))
+			aum.Aum = w / 1000. * st.CowAumAt1000
 			aum.Location = 1
 			if w > 0 {
-				Records[c.Id-1].CowAum = append(Records[c.Id-1].CowAum, aum)
+				st.Records[c.Id-1].CowAum = append(st.Records[c.Id-1].CowAum, aum)
 			}
 		}
 	}
 
 }
+
+// Set HerdNames from Herds
+func (st *State) SortHerdNames() {
+	st.HerdNames = st.HerdNames[:0]
+	for n := range st.Herds {
+		st.HerdNames = append(st.HerdNames, n)
+	}
+	sort.Strings(st.HerdNames)
+}
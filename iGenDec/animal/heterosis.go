@@ -0,0 +1,148 @@
+// heterosis.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mean heterozygosity, as a proportion of an F1's, of the calves born in a
+// year and of the cows that calved them
+type HeterosisYear_t struct {
+	Year   int
+	Calves int
+	Calf   float64
+	Cows   int
+	Cow    float64
+}
+
+// Breed compositions of an animal's parents.  Those of foundation and bought
+// in animals are unknown so they are taken to be the animal's own, which is
+// the heterozygosity retained by a composite of the same breeds.
+func (st *State) parentCompositions(a *Animal) (sire, dam map[string]float64) {
+	if a.Sire > 0 && a.Dam > 0 {
+		return st.Records[a.Sire-1].BreedComposition, st.Records[a.Dam-1].BreedComposition
+	}
+	return a.BreedComposition, a.BreedComposition
+}
+
+// An animal's heterozygosity by the cross class of its sire's and dam's breeds
+func (st *State) heterozygosity(a *Animal) map[string]float64 {
+	sire, dam := st.parentCompositions(a)
+	h := make(map[string]float64)
+	for _, sb := range st.BreedsList {
+		for _, db := range st.BreedsList {
+			if sb != db && sire[sb] > 0 && dam[db] > 0 {
+				h[st.HeterosisCodes[sb]+"x"+st.HeterosisCodes[db]] += sire[sb] * dam[db]
+			}
+		}
+	}
+	return h
+}
+
+// An animal's total heterozygosity as a proportion of an F1's
+func (st *State) retainedHeterosis(a *Animal) float64 {
+	sire, dam := st.parentCompositions(a)
+	h := 1.0
+	for _, b := range st.BreedsList {
+		h -= sire[b] * dam[b]
+	}
+	return h
+}
+
+// Heterosis of a component from heterozygosity by cross class.  A component
+// without a row in HeterosisValues has none.
+func (st *State) heterosisValue(c Component_t, h map[string]float64) (effect float64) {
+
+	hv, ok := st.HeterosisValues[c]
+	if !ok {
+		return 0
+	}
+
+	for class := range h {
+		if _, ok := hv.Values[class]; !ok {
+			if _, ok := hv.Values[reverseClass(class)]; !ok {
+				st.fail(&HeterosisError{Trait: c.TraitName, Component: c.Component, CrossClass: class})
+			}
+		}
+	}
+
+	// In the order of the table so the sum is the same every run
+	for _, class := range st.HeterosisCrossClasses {
+		p := h[class]
+		if r := reverseClass(class); r != class {
+			p += h[r]
+		}
+		effect += p * hv.Values[class]
+	}
+	return effect
+}
+
+// BTxBI is BIxBT
+func reverseClass(class string) string {
+	codes := strings.SplitN(class, "x", 2)
+	if len(codes) != 2 {
+		return class
+	}
+	return codes[1] + "x" + codes[0]
+}
+
+// Mean heterozygosity of the calves born each year and of the cows that calved them
+func (st *State) HeterosisByYear() []HeterosisYear_t {
+
+	var years []HeterosisYear_t
+	dams := make(map[AnimalId]int) // Year a dam was last counted
+	for i := range st.Records {
+		a := &st.Records[i]
+		if a.Sire == 0 || a.YearBorn < 1 {
+			continue
+		}
+		for len(years) < a.YearBorn {
+			years = append(years, HeterosisYear_t{Year: len(years) + 1})
+		}
+		y := &years[a.YearBorn-1]
+		y.Calves++
+		y.Calf += st.retainedHeterosis(a)
+		if dam := st.maternalDam(a); dams[dam.Id] != a.YearBorn {
+			dams[dam.Id] = a.YearBorn
+			y.Cows++
+			y.Cow += st.retainedHeterosis(dam)
+		}
+	}
+	for i := range years {
+		if years[i].Calves > 0 {
+			years[i].Calf /= float64(years[i].Calves)
+			years[i].Cow /= float64(years[i].Cows)
+		}
+	}
+	return years
+}
+
+// Print the yearly heterosis retained in the calves and cows
+func (st *State) PrintHeterosis() {
+	fmt.Printf("\nHeterosis retained, proportion of F1:\n")
+	fmt.Printf("Year   Calves      Calf     Cows       Cow\n")
+	for _, y := range st.HeterosisByYear() {
+		fmt.Printf("%4d  %7d  %8.4f  %7d  %8.4f\n", y.Year, y.Calves, y.Calf, y.Cows, y.Cow)
+	}
+}
@@ -0,0 +1,160 @@
+// inbreeding.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"container/heap"
+	"fmt"
+)
+
+// Mean inbreeding of the calves born in a year
+type InbreedingYear_t struct {
+	Year   int
+	Calves int
+	Mean   float64
+	Max    float64
+}
+
+// Return an animal's inbreeding coefficient.  Calves not yet in Records are
+// calculated from their sire and dam.
+func (st *State) Inbreeding(a *Animal) float64 {
+	st.updateInbreeding()
+	if a.Id > 0 && int(a.Id) <= len(st.inbreeding) {
+		return st.inbreeding[a.Id-1]
+	}
+	f, _ := st.inbreedingOf(a.Sire, a.Dam)
+	return f
+}
+
+// Calculate the coefficients of the animals added to Records since the last call
+func (st *State) updateInbreeding() {
+	for i := len(st.inbreeding); i < len(st.Records); i++ {
+		f, d := st.inbreedingOf(st.Records[i].Sire, st.Records[i].Dam)
+		st.inbreeding = append(st.inbreeding, f)
+		st.mendelian = append(st.mendelian, d)
+	}
+}
+
+// Inbreeding and Mendelian sampling variance of a calf of sire and dam by
+// Meuwissen and Luo (1992).  Animals with an unknown parent are not inbred.
+func (st *State) inbreedingOf(sire, dam AnimalId) (f, d float64) {
+
+	d = 1
+	for _, p := range []AnimalId{sire, dam} {
+		if p > 0 {
+			d -= .25 * (1 + st.inbreeding[p-1])
+		}
+	}
+	if sire == 0 || dam == 0 {
+		return 0, d
+	}
+
+	// Trace the ancestors from youngest to oldest.  Calves always have a
+	// larger Id than their parents so each is complete when it is reached.
+	x := map[AnimalId]float64{sire: .5, dam: .5}
+	ancestors := &idHeap{sire, dam}
+	heap.Init(ancestors)
+	aii := d // The calf's own term
+	for ancestors.Len() > 0 {
+		j := heap.Pop(ancestors).(AnimalId)
+		xj := x[j]
+		aii += xj * xj * st.mendelian[j-1]
+		for _, p := range []AnimalId{st.Records[j-1].Sire, st.Records[j-1].Dam} {
+			if p == 0 {
+				continue
+			}
+			if _, ok := x[p]; !ok {
+				heap.Push(ancestors, p)
+			}
+			x[p] += .5 * xj
+		}
+	}
+	return aii - 1, d
+}
+
+// Max heap of animal Ids
+type idHeap []AnimalId
+
+func (h idHeap) Len() int            { return len(h) }
+func (h idHeap) Less(i, j int) bool  { return h[i] > h[j] }
+func (h idHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *idHeap) Push(x interface{}) { *h = append(*h, x.(AnimalId)) }
+func (h *idHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// Change in a trait's phenotype from the inbreeding of the animal, for the
+// direct component, and of its dam, for the maternal component
+func (st *State) InbreedingEffect(trait string, a Animal) (effect float64) {
+
+	if len(st.InbreedingDepression) == 0 {
+		return 0
+	}
+
+	if v, ok := st.InbreedingDepression[Component_t{TraitName: trait, Component: "D"}]; ok {
+		effect += v * 100 * st.Inbreeding(&a)
+	}
+	if v, ok := st.InbreedingDepression[Component_t{TraitName: trait, Component: "M"}]; ok && a.Dam > 0 {
+		effect += v * 100 * st.Inbreeding(st.maternalDam(&a))
+	}
+	return effect
+}
+
+// Mean and largest inbreeding of the calves born each year
+func (st *State) InbreedingByYear() []InbreedingYear_t {
+
+	st.updateInbreeding()
+
+	var years []InbreedingYear_t
+	for i, a := range st.Records {
+		if a.Sire == 0 || a.YearBorn < 1 { // Foundation and bought in
+			continue
+		}
+		for len(years) < a.YearBorn {
+			years = append(years, InbreedingYear_t{Year: len(years) + 1})
+		}
+		y := &years[a.YearBorn-1]
+		y.Calves++
+		y.Mean += st.inbreeding[i]
+		if st.inbreeding[i] > y.Max {
+			y.Max = st.inbreeding[i]
+		}
+	}
+	for i := range years {
+		if years[i].Calves > 0 {
+			years[i].Mean /= float64(years[i].Calves)
+		}
+	}
+	return years
+}
+
+// Print the yearly inbreeding of the calves
+func (st *State) PrintInbreeding() {
+	fmt.Printf("\nInbreeding of calves born:\n")
+	fmt.Printf("Year   Calves    Mean F     Max F\n")
+	for _, y := range st.InbreedingByYear() {
+		fmt.Printf("%4d  %7d  %8.4f  %8.4f\n", y.Year, y.Calves, y.Mean, y.Max)
+	}
+}
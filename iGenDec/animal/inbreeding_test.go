@@ -0,0 +1,56 @@
+// inbreeding_test.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"math"
+	"testing"
+)
+
+// Textbook coefficients of the test pedigree, and its agreement with the
+// diagonal of the tabular relationship matrix
+func TestInbreeding(t *testing.T) {
+	st := testPedigree()
+	want := []float64{0, 0, 0, 0, .25, 0, 0, .125, .375}
+	a := tabularRelationships(st)
+	for i := range st.Records {
+		f := st.Inbreeding(&st.Records[i])
+		if math.Abs(f-want[i]) > 1e-12 {
+			t.Errorf("F of %d = %v, want %v", i+1, f, want[i])
+		}
+		if math.Abs(f-(a.At(i, i)-1)) > 1e-12 {
+			t.Errorf("F of %d = %v, the tabular method gives %v", i+1, f, a.At(i, i)-1)
+		}
+	}
+
+	// Mendelian sampling variances, 1 - (2 + Fsire + Fdam) / 4 with both parents known
+	for i, d := range map[int]float64{0: 1, 2: .5, 4: .5, 6: .5, 8: .4375} {
+		if math.Abs(st.mendelian[i]-d) > 1e-12 {
+			t.Errorf("Mendelian variance of %d = %v, want %v", i+1, st.mendelian[i], d)
+		}
+	}
+
+	// A calf not yet in Records, 5 and 9 are related by 1
+	if f := st.Inbreeding(&Animal{Sire: 9, Dam: 5}); math.Abs(f-.5) > 1e-12 {
+		t.Errorf("F of a calf of 9 and 5 = %v, want .5", f)
+	}
+}
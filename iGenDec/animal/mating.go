@@ -0,0 +1,195 @@
+// mating.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Mating plans from the matingPlans: key
+const (
+	PlanRotation     = "rotation"     // Cows are bred to the breed after their sire's in RotationBreeds
+	PlanTerminal     = "terminal"     // The oldest mature cows are bred to TerminalBreed, the rest to the bull battery
+	PlanRotaTerminal = "rotaTerminal" // Terminal on the oldest mature cows, a rotation on the rest
+)
+
+var MatingPlans = []string{PlanRotation, PlanTerminal, PlanRotaTerminal}
+
+// Cows must be this old at the start of breeding to be mated to a terminal sire
+const terminalMinimumAge = 3 * 365
+
+// Breeds the herd's bulls are bought in.  An empty breed is a bull from the
+// bull battery, which serves the cows not in the terminal part of a plan.
+func sireBreeds(herd *Herd) []string {
+	switch herd.MatingPlan {
+	case PlanRotation:
+		return herd.RotationBreeds
+	case PlanTerminal:
+		return []string{herd.TerminalBreed, ""}
+	case PlanRotaTerminal:
+		return append([]string{herd.TerminalBreed}, herd.RotationBreeds...)
+	}
+	return nil
+}
+
+// The plan breed of a bull, empty if he is not purebred for one
+func bullBreed(herd *Herd, b *Animal) string {
+	for _, breed := range sireBreeds(herd) {
+		if breed != "" && b.BreedComposition[breed] == 1 {
+			return breed
+		}
+	}
+	return ""
+}
+
+// Make a bull purebred for the plan breed with the fewest bulls in the herd
+func (st *State) setPlanBreed(herd *Herd, b *Animal, bulls []*Animal) {
+
+	breeds := sireBreeds(herd)
+	if len(breeds) == 0 {
+		return
+	}
+
+	count := make(map[string]int)
+	for _, other := range bulls {
+		count[bullBreed(herd, other)]++
+	}
+	breed := breeds[0]
+	for _, s := range breeds[1:] {
+		if count[s] < count[breed] {
+			breed = s
+		}
+	}
+	if breed != "" {
+		b.BreedComposition = map[string]float64{breed: 1}
+	}
+}
+
+// The oldest cows at least terminalMinimumAge, up to TerminalProportion of the herd
+func (st *State) terminalCows(herd *Herd, year int) map[AnimalId]bool {
+
+	terminal := make(map[AnimalId]bool)
+	if herd.TerminalBreed == "" {
+		return terminal
+	}
+
+	start := Date((year-1)*365) + herd.StartBreeding
+	var mature []*Animal
+	for _, c := range herd.Cows {
+		if start-c.BirthDate >= terminalMinimumAge {
+			mature = append(mature, c)
+		}
+	}
+	sort.SliceStable(mature, func(i, j int) bool { return mature[i].BirthDate < mature[j].BirthDate })
+
+	n := int(math.Round(herd.TerminalProportion * float64(len(herd.Cows))))
+	for i := 0; i < n && i < len(mature); i++ {
+		terminal[mature[i].Id] = true
+	}
+	return terminal
+}
+
+// The rotation breed a cow is bred to, the one after her sire's breed.  Cows
+// whose sire is not of the rotation are bred to the rotation breed least in
+// their own composition.
+func (st *State) rotationBreed(herd *Herd, cow *Animal) string {
+
+	if cow.Sire > 0 {
+		s := bullBreed(herd, &st.Records[cow.Sire-1])
+		for k, b := range herd.RotationBreeds {
+			if b == s {
+				return herd.RotationBreeds[(k+1)%len(herd.RotationBreeds)]
+			}
+		}
+	}
+
+	breed := herd.RotationBreeds[0]
+	for _, b := range herd.RotationBreeds[1:] {
+		if cow.BreedComposition[b] < cow.BreedComposition[breed] {
+			breed = b
+		}
+	}
+	return breed
+}
+
+// The breed a cow is bred to under the herd's mating plan.  Empty is a bull
+// from the bull battery.
+func (st *State) planBreed(herd *Herd, cow *Animal, terminal bool) string {
+	switch {
+	case terminal:
+		return herd.TerminalBreed
+	case herd.MatingPlan == PlanRotation || herd.MatingPlan == PlanRotaTerminal:
+		return st.rotationBreed(herd, cow)
+	}
+	return ""
+}
+
+// Choose the bull for a cow under the herd's mating plan.  Without a plan,
+// or when the herd has no bull of the breed, any of the herd's bulls.
+func (st *State) sireFor(herd *Herd, cow *Animal, terminal bool) *Animal {
+
+	breed := st.planBreed(herd, cow, terminal)
+
+	bulls := herd.Bulls
+	if herd.MatingPlan != "" {
+		var matched []*Animal
+		for _, b := range herd.Bulls {
+			if bullBreed(herd, b) == breed {
+				matched = append(matched, b)
+			}
+		}
+		if len(matched) > 0 {
+			bulls = matched
+		} else if st.OutputMode == "verbose" {
+			fmt.Printf("WARNING: no %q bull in the %v herd for cow %d\n", breed, herd.HerdName, cow.Id)
+		}
+	}
+	return bulls[randRange(st.Rng.Breeding, 0, len(bulls)-1)]
+}
+
+// Heifers sired by a terminal bull are all sold
+func (st *State) terminalSired(herd *Herd, a *Animal) bool {
+	return herd.TerminalBreed != "" && a.Sire > 0 && bullBreed(herd, &st.Records[a.Sire-1]) == herd.TerminalBreed
+}
+
+// A heifer that can enter the herd as a 2 year old
+func (st *State) isReplacement(a *Animal, herd *Herd, year int) bool {
+	return is2YoaHeifer(a, herd, year) && !st.terminalSired(herd, a)
+}
+
+// Print the mean breed composition of the calves born this year
+func (st *State) printCalfComposition(herd *Herd, year int, first int) {
+	mean := make(map[string]float64)
+	n := len(st.Records) - first
+	for i := first; i < len(st.Records); i++ {
+		for b, p := range st.Records[i].BreedComposition {
+			mean[b] += p / float64(n)
+		}
+	}
+	fmt.Printf("Breed composition of the %d calves born in the %v herd, year %d:", n, herd.HerdName, year)
+	for _, b := range st.BreedsList {
+		fmt.Printf(" %s %.3f", b, mean[b])
+	}
+	fmt.Println()
+}
@@ -28,14 +28,31 @@ import (
 	"strings"
 
 	"github.com/blgolden/iGenDecModel/iGenDec/logger"
+
+	"gonum.org/v1/gonum/mat"
 )
 
-var ResidualStayStdDev float64
-var ResidualHpStdDev float64
+// Stayability and heifer pregnancy need a residual std dev so stubbed in here
+func (st *State) SetResidualStdDevs(residual mat.Symmetric) {
+	rindx := st.ResidualIndex("STAY")
+	if rindx >= 0 {
+		st.ResidualStayStdDev = math.Sqrt(residual.At(rindx, rindx))
+		if st.OutputMode == "verbose" {
+			fmt.Println("The Stayability residual standard deviation is: ", st.ResidualStayStdDev)
+		}
+	}
+	rindx = st.ResidualIndex("HP")
+	if rindx >= 0 {
+		st.ResidualHpStdDev = math.Sqrt(residual.At(rindx, rindx))
+		if st.OutputMode == "verbose" {
+			fmt.Println("The Hepfer Pregnancy residual standard deviation is: ", st.ResidualHpStdDev)
+		}
+	}
+}
 
 // Return the trait index in the traits array list
-func ResidualIndex(traitName string) (index int) {
-	for i, v := range Traits {
+func (st *State) ResidualIndex(traitName string) (index int) {
+	for i, v := range st.Traits {
 		if v == traitName {
 			return i
 		}
@@ -45,8 +62,8 @@ func ResidualIndex(traitName string) (index int) {
 }
 
 // Return the index of this genetic component in the genetic variance matrix
-func GeneticIndex(traitName string, comp string) (index int) {
-	for i, v := range Components {
+func (st *State) GeneticIndex(traitName string, comp string) (index int) {
+	for i, v := range st.Components {
 		splt := strings.Split(v, ",")
 		if splt[0] == traitName {
 			if strings.TrimSpace(splt[1]) == comp { // comp = D or M
@@ -70,16 +87,17 @@ func GeneticEffect(indx int, animal Animal) (u float64) {
 }
 
 // Return the breed effect on
-func BreedEffect(trait string, thisAnimal Animal) (effect float64) {
+func (st *State) BreedEffect(trait string, thisAnimal Animal) (effect float64) {
 
 	effect = 0.0
 
 	// For efficiency do this first
 	// Should already know if thisAnimal.Dam != 0
 	var m = Component_t{trait, "M"}
-	if mat, ok := Breeds[m]; ok && thisAnimal.YearBorn > 0 { // the && allows us to initialize the CD distribution
-		for key, breed := range Records[thisAnimal.Dam-1].BreedComposition {
-			effect += mat.Effects[key] * breed
+	if mat, ok := st.Breeds[m]; ok && thisAnimal.YearBorn > 0 { // the && allows us to initialize the CD distribution
+		dam := st.maternalDam(&thisAnimal).BreedComposition
+		for _, b := range st.BreedsList { // In order so the sum is the same every run
+			effect += mat.Effects[b] * dam[b]
 		}
 	}
 
@@ -87,87 +105,41 @@ func BreedEffect(trait string, thisAnimal Animal) (effect float64) {
 	var d = Component_t{trait, "D"}
 
 	// There will always be a direct effect
-	for key, breed := range thisAnimal.BreedComposition {
-		effect += Breeds[d].Effects[key] * breed
+	for _, b := range st.BreedsList {
+		effect += st.Breeds[d].Effects[b] * thisAnimal.BreedComposition[b]
 	}
 
 	return effect
 
 }
 
-// Return the heterosis effect
-func HeterosisEffect(trait string, thisAnimal Animal) (effect float64, l bool) {
-
-	sire := Records[thisAnimal.Sire].BreedComposition
-	dam := Records[thisAnimal.Dam].BreedComposition
-
-	// Does this trait have a maternal heterosis effect
-	var mval HeterosisValues_t
-	Ok := true
-	var m Component_t
-	m.TraitName = trait
-	m.Component = "M"
-
-	dsire := sire
-	ddam := dam
-	if mval, Ok = HeterosisValues[m]; Ok {
-		if Records[thisAnimal.Dam].Sire > 0 && Records[thisAnimal.Sire].Dam > 0 {
-			dsire = Records[thisAnimal.Sire].BreedComposition
-			ddam = Records[thisAnimal.Dam].BreedComposition
-		}
-		for msbreed, mspct := range dsire {
-			for mdbreed, mdpct := range ddam {
-				if msbreed != mdbreed {
-					mcode := HeterosisCodes[msbreed] + "x" + HeterosisCodes[mdbreed]
-					var mhval float64
-					ok := true
-					if mhval, ok = mval.Values[mcode]; !ok {
-						code := HeterosisCodes[mdbreed] + "x" + HeterosisCodes[msbreed]
-						if mhval, ok = mval.Values[code]; !ok {
-							logger.LogWriterFatal("Cannot find a maternal heterosis value for " + code + " Trait: " + trait)
-						}
-					}
-					effect += mspct * mdpct * mhval
-				}
-			}
-		}
-	}
+// Return the heterosis effect, direct from the animal's heterozygosity and
+// maternal from its dam's
+func (st *State) HeterosisEffect(trait string, thisAnimal Animal) (effect float64, l bool) {
 
-	effect = 0.0
+	effect = st.heterosisValue(Component_t{TraitName: trait, Component: "D"}, st.heterozygosity(&thisAnimal))
 
-	for sbreed, spct := range sire {
-		for dbreed, dpct := range dam {
-			if sbreed != dbreed {
-				code := HeterosisCodes[sbreed] + "x" + HeterosisCodes[dbreed]
-				var c Component_t
-				c.TraitName = trait
-				c.Component = "D"
-				var hval float64
-				ok := true
-				if hval, ok = HeterosisValues[c].Values[code]; !ok {
-					code = HeterosisCodes[dbreed] + "x" + HeterosisCodes[sbreed]
-					if hval, ok = HeterosisValues[c].Values[code]; !ok {
-						logger.LogWriterFatal("Cannot find a heterosis value for " + code + "Trait:" + trait)
-					}
-				}
-				effect += spct * dpct * hval
-			}
-		}
+	m := Component_t{TraitName: trait, Component: "M"}
+	if _, ok := st.HeterosisValues[m]; ok && thisAnimal.Dam > 0 {
+		effect += st.heterosisValue(m, st.heterozygosity(st.maternalDam(&thisAnimal)))
 	}
 
 	l = true
 	return effect, l
 }
 
+// The BIF aod category of 5 through 9 year old dams
+const matureAod = 3
+
 // Return the BIF aod catagory where 0=2yoa, 1=3yoa, 2=4yoa, 3=5 thru9yoa, and 10 = >=10 yoa
-func WhatAod(thisAnimal Animal) (aod int) {
+func (st *State) WhatAod(thisAnimal Animal) (aod int) {
 
 	if thisAnimal.Dam <= 0 {
-		aod = 3 // Mature cow
+		aod = matureAod // Mature cow
 		return aod
 	}
 
-	age := thisAnimal.BirthDate - Records[thisAnimal.Dam].BirthDate
+	age := thisAnimal.BirthDate - st.maternalDam(&thisAnimal).BirthDate
 
 	if age >= 639 && age <= 1003 {
 		aod = 0
@@ -176,7 +148,7 @@ func WhatAod(thisAnimal Animal) (aod int) {
 	} else if age >= 1370 && age <= 1734 {
 		aod = 2
 	} else if age >= 1735 && age <= 3560 {
-		aod = 3
+		aod = matureAod
 	} else {
 		aod = 4
 	}
@@ -186,22 +158,31 @@ func WhatAod(thisAnimal Animal) (aod int) {
 }
 
 // Return the net AOD effect of even crossbreeds
-func SexAgeOfDamEffect(trait string, thisAnimal Animal) (effect float64) {
+func (st *State) SexAgeOfDamEffect(trait string, thisAnimal Animal) (effect float64) {
+	return st.sexAodEffect(trait, thisAnimal, st.WhatAod(thisAnimal))
+}
+
+// The sex and age of dam effect of an animal as if its dam were in aod
+func (st *State) sexAodEffect(trait string, thisAnimal Animal, aod int) (effect float64) {
 
-	for s, v := range thisAnimal.BreedComposition {
+	for _, s := range st.BreedsList {
+		v, ok := thisAnimal.BreedComposition[s]
+		if !ok {
+			continue
+		}
 		var b BTS_t
 		b.Breed = s
 		b.Trait = trait
 		b.Sex = thisAnimal.Sex
-		b.Aod = WhatAod(thisAnimal)
+		b.Aod = aod
 
-		effect += BreedTraitSexAod[b] * v
+		effect += st.BreedTraitSexAod[b] * v
 	}
 
 	return
 }
 
-func AgeEffect(trait string, thisAnimal Animal) (effect float64) {
+func (st *State) AgeEffect(trait string, thisAnimal Animal) (effect float64) {
 
 	// Calculate age at event
 
@@ -210,30 +191,30 @@ func AgeEffect(trait string, thisAnimal Animal) (effect float64) {
 		return effect
 	}
 	thisDeviation := float64(thisAnimal.BirthDate) -
-		Herds[thisAnimal.HerdName].SumBirthDates[thisAnimal.YearBorn]/Herds[thisAnimal.HerdName].NBorn[thisAnimal.YearBorn]
+		st.Herds[thisAnimal.HerdName].SumBirthDates[thisAnimal.YearBorn]/st.Herds[thisAnimal.HerdName].NBorn[thisAnimal.YearBorn]
 
 	/*fmt.Println("LOC AGE", thisAnimal.Id, thisAnimal.BirthDate, thisAnimal.YearBorn,
-	Herds[thisAnimal.HerdName].SumBirthDates[thisAnimal.YearBorn]/
-		Herds[thisAnimal.HerdName].NBorn[thisAnimal.YearBorn],
-	float64(thisAnimal.BirthDate)-Herds[thisAnimal.HerdName].SumBirthDates[thisAnimal.YearBorn]/
-		Herds[thisAnimal.HerdName].NBorn[thisAnimal.YearBorn])
+	st.Herds[thisAnimal.HerdName].SumBirthDates[thisAnimal.YearBorn]/
+		st.Herds[thisAnimal.HerdName].NBorn[thisAnimal.YearBorn],
+	float64(thisAnimal.BirthDate)-st.Herds[thisAnimal.HerdName].SumBirthDates[thisAnimal.YearBorn]/
+		st.Herds[thisAnimal.HerdName].NBorn[thisAnimal.YearBorn])
 	*/
-	effect = thisDeviation * TraitAgeEffects[trait].Slope
+	effect = thisDeviation * st.TraitAgeEffects[trait].Slope
 
 	return effect
 }
 
 // Calculate raw phenotype
-func Phenotype(thisAnimal Animal, thisTrait string) (pheno float64, l bool) {
+func (st *State) Phenotype(thisAnimal Animal, thisTrait string) (pheno float64, l bool) {
 
 	if thisAnimal.YearBorn < 1 {
 		l = false
 		return 0.0, l
 	}
 
-	resIdx := ResidualIndex(thisTrait)
+	resIdx := st.ResidualIndex(thisTrait)
 
-	matIdx := GeneticIndex(thisTrait, "M")
+	matIdx := st.GeneticIndex(thisTrait, "M")
 
 	if matIdx > -1 && thisAnimal.Dam == 0 { // Dam is unknown but trait has a maternal effect
 		l = false
@@ -242,38 +223,41 @@ func Phenotype(thisAnimal Animal, thisTrait string) (pheno float64, l bool) {
 
 	var geneticMaternalEffect float64
 	if matIdx >= 0 {
-		geneticMaternalEffect = GeneticEffect(matIdx, Records[thisAnimal.Dam-1]) * .5
+		geneticMaternalEffect = GeneticEffect(matIdx, *st.maternalDam(&thisAnimal)) * .5
 	}
 
-	geneticDirectEffect := GeneticEffect(GeneticIndex(thisTrait, "D"), thisAnimal)
+	geneticDirectEffect := GeneticEffect(st.GeneticIndex(thisTrait, "D"), thisAnimal)
 
 	// for now permEnvEffect := PermenentEnvEffect(PermEnvIndex(thisTrait))
 
-	breedEffects := BreedEffect(thisTrait, thisAnimal)
+	breedEffects := st.BreedEffect(thisTrait, thisAnimal)
 
 	var heterosisEffects float64
 	ok := true
-	if heterosisEffects, ok = HeterosisEffect(thisTrait, thisAnimal); !ok { // Sire of dam of dam of dam unknown and trait has a maternal het effect
+	if heterosisEffects, ok = st.HeterosisEffect(thisTrait, thisAnimal); !ok { // Sire of dam of dam of dam unknown and trait has a maternal het effect
 		l = false
 		return 0.0, l
 	}
 
-	sexAgeOfDamEffects := SexAgeOfDamEffect(thisTrait, thisAnimal) // Only sex effect if not AOD effect
+	sexAgeOfDamEffects := st.SexAgeOfDamEffect(thisTrait, thisAnimal) // Only sex effect if not AOD effect
+
+	inbreedingEffects := st.InbreedingEffect(thisTrait, thisAnimal)
 
-	ageEffect := AgeEffect(thisTrait, thisAnimal)
+	ageEffect := st.AgeEffect(thisTrait, thisAnimal)
 
-	pheno = TraitMean[thisTrait] +
+	pheno = st.TraitMean[thisTrait] +
 		breedEffects +
 		heterosisEffects +
 		sexAgeOfDamEffects +
+		inbreedingEffects +
 		ageEffect +
 		geneticDirectEffect +
 		geneticMaternalEffect +
 		//permEnvEffect +
 		thisAnimal.Residual.At(resIdx, 0)
 
-	if thisTrait == PhenotypeOutputTrait {
-		fmt.Fprintln(PhenotypeFilePointer, thisAnimal.Id, thisAnimal.YearBorn, TraitMean[thisTrait], breedEffects,
+	if thisTrait == st.PhenotypeOutputTrait {
+		fmt.Fprintln(st.PhenotypeFilePointer, thisAnimal.Id, thisAnimal.YearBorn, st.TraitMean[thisTrait], breedEffects,
 			heterosisEffects, sexAgeOfDamEffects, ageEffect, geneticDirectEffect, geneticMaternalEffect,
 			thisAnimal.Residual.At(resIdx, 0), pheno)
 	}
@@ -283,16 +267,16 @@ func Phenotype(thisAnimal Animal, thisTrait string) (pheno float64, l bool) {
 }
 
 // Calculate phenotype without adjusting for age
-func PhenotypeAtMeanAge(thisAnimal Animal, thisTrait string) (pheno float64, l bool) {
+func (st *State) PhenotypeAtMeanAge(thisAnimal Animal, thisTrait string) (pheno float64, l bool) {
 
 	if thisAnimal.YearBorn < 1 {
 		l = false
 		return 0.0, l
 	}
 
-	resIdx := ResidualIndex(thisTrait)
+	resIdx := st.ResidualIndex(thisTrait)
 
-	matIdx := GeneticIndex(thisTrait, "M")
+	matIdx := st.GeneticIndex(thisTrait, "M")
 
 	if matIdx > -1 && thisAnimal.Dam == 0 { // Dam is unknown but trait has a maternal effect
 		l = false
@@ -301,28 +285,31 @@ func PhenotypeAtMeanAge(thisAnimal Animal, thisTrait string) (pheno float64, l b
 
 	var geneticMaternalEffect float64
 	if matIdx >= 0 {
-		geneticMaternalEffect = GeneticEffect(matIdx, Records[thisAnimal.Dam-1]) * .5
+		geneticMaternalEffect = GeneticEffect(matIdx, *st.maternalDam(&thisAnimal)) * .5
 	}
 
-	geneticDirectEffect := GeneticEffect(GeneticIndex(thisTrait, "D"), thisAnimal)
+	geneticDirectEffect := GeneticEffect(st.GeneticIndex(thisTrait, "D"), thisAnimal)
 
 	// for now permEnvEffect := PermenentEnvEffect(PermEnvIndex(thisTrait))
 
-	breedEffects := BreedEffect(thisTrait, thisAnimal)
+	breedEffects := st.BreedEffect(thisTrait, thisAnimal)
 
 	var heterosisEffects float64
 	ok := true
-	if heterosisEffects, ok = HeterosisEffect(thisTrait, thisAnimal); !ok { // Sire of dam of dam of dam unknown and trait has a maternal het effect
+	if heterosisEffects, ok = st.HeterosisEffect(thisTrait, thisAnimal); !ok { // Sire of dam of dam of dam unknown and trait has a maternal het effect
 		l = false
 		return 0.0, l
 	}
 
-	sexAgeOfDamEffects := SexAgeOfDamEffect(thisTrait, thisAnimal) // Only sex effect if not AOD effect
+	sexAgeOfDamEffects := st.SexAgeOfDamEffect(thisTrait, thisAnimal) // Only sex effect if not AOD effect
 
-	pheno = TraitMean[thisTrait] +
+	inbreedingEffects := st.InbreedingEffect(thisTrait, thisAnimal)
+
+	pheno = st.TraitMean[thisTrait] +
 		breedEffects +
 		heterosisEffects +
 		sexAgeOfDamEffects +
+		inbreedingEffects +
 		geneticDirectEffect +
 		geneticMaternalEffect +
 		//permEnvEffect +
@@ -334,20 +321,20 @@ func PhenotypeAtMeanAge(thisAnimal Animal, thisTrait string) (pheno float64, l b
 }
 
 // phenotype of weaning wt
-func WeaningWtPhenotype(thisAnimal Animal) (float64, bool) {
+func (st *State) WeaningWtPhenotype(thisAnimal Animal) (float64, bool) {
 
-	pa, ok := PhenotypeAtMeanAge(thisAnimal, "WW")
+	pa, ok := st.PhenotypeAtMeanAge(thisAnimal, "WW")
 
 	if !ok {
 		return 0.0, false
 	}
 
-	pb, _ := Phenotype(thisAnimal, "BW")
+	pb, _ := st.Phenotype(thisAnimal, "BW")
 
 	thisDeviation := float64(thisAnimal.BirthDate) -
-		Herds[thisAnimal.HerdName].SumBirthDates[thisAnimal.YearBorn]/Herds[thisAnimal.HerdName].NBorn[thisAnimal.YearBorn]
+		st.Herds[thisAnimal.HerdName].SumBirthDates[thisAnimal.YearBorn]/st.Herds[thisAnimal.HerdName].NBorn[thisAnimal.YearBorn]
 
-	pheno := (pa-pb)/205*(thisDeviation+205) + pb
+	pheno := (pa-pb)/205*(thisDeviation+205) + pb - st.weaningPenalty(&thisAnimal)
 
 	return pheno, true
 }
@@ -399,17 +386,17 @@ func Stay2Concept21days(s float64) float64 {
 // Calculate the stayability phenotype at a particular day - e.g. at breeding
 // This is 6 yoa conception rate adjusted to a conception rate at a particular
 // age.
-func StayAtAgePhenotype(thisAnimal Animal, today Date) (pheno float64) {
+func (st *State) StayAtAgePhenotype(thisAnimal Animal, today Date) (pheno float64) {
 
-	geneticDirectEffect := GeneticEffect(GeneticIndex("STAY", "D"), thisAnimal)
+	geneticDirectEffect := GeneticEffect(st.GeneticIndex("STAY", "D"), thisAnimal)
 
 	// for now permEnvEffect := PermenentEnvEffect(PermEnvIndex(thisTrait))
 
-	breedEffects := BreedEffect("STAY", thisAnimal)
+	breedEffects := st.BreedEffect("STAY", thisAnimal)
 
 	var heterosisEffects float64
 	ok := true
-	heterosisEffects, ok = HeterosisEffect("STAY", thisAnimal)
+	heterosisEffects, ok = st.HeterosisEffect("STAY", thisAnimal)
 	if !ok {
 		heterosisEffects = 0.0
 	}
@@ -418,14 +405,17 @@ func StayAtAgePhenotype(thisAnimal Animal, today Date) (pheno float64) {
 
 	daysOfAge := today - thisAnimal.BirthDate
 
-	ageEffect := TraitAgeEffects["STAY"].Slope * (float64(daysOfAge) - TraitAgeEffects["STAY"].Age)
+	ageEffect := st.TraitAgeEffects["STAY"].Slope * (float64(daysOfAge) - st.TraitAgeEffects["STAY"].Age)
+
+	residual := st.Rng.Residual.NormFloat64() * st.ResidualStayStdDev // Simulated as uncorrelated to other residuals
 
-	residual := Rng.NormFloat64() * ResidualStayStdDev // Simulated as uncorrelated to other residuals
+	inbreedingEffects := st.InbreedingEffect("STAY", thisAnimal)
 
-	pheno = TraitMean["STAY"] +
+	pheno = st.TraitMean["STAY"] +
 		//breedEffects +
 		//heterosisEffects +
 		//sexAgeOfDamEffects +
+		inbreedingEffects +
 		ageEffect +
 		geneticDirectEffect +
 		//permEnvEffect +
@@ -433,14 +423,14 @@ func StayAtAgePhenotype(thisAnimal Animal, today Date) (pheno float64) {
 
 	pheno = pheno + pheno*(1.0*heterosisEffects) // This is a multiplicative effect because this is a probability
 
-	if StayPhenotypeFilePointer != nil {
-		fmt.Fprintln(StayPhenotypeFilePointer,
-			thisAnimal.Id,       // 1
-			thisAnimal.YearBorn, // 2
-			today,               // 3
-			TraitMean["STAY"],   // 4
-			breedEffects,        // 5
-			heterosisEffects,    // 6
+	if st.StayPhenotypeFilePointer != nil {
+		fmt.Fprintln(st.StayPhenotypeFilePointer,
+			thisAnimal.Id,        // 1
+			thisAnimal.YearBorn,  // 2
+			today,                // 3
+			st.TraitMean["STAY"], // 4
+			breedEffects,         // 5
+			heterosisEffects,     // 6
 			//sexAgeOfDamEffects,  // 7
 			ageEffect,           // 8
 			daysOfAge,           // 9
@@ -453,45 +443,48 @@ func StayAtAgePhenotype(thisAnimal Animal, today Date) (pheno float64) {
 }
 
 // Calculate the heifer pregnancy phenotype at a particular day - e.g. at breeding
-func HeiferPregnancyPhenotype(thisAnimal Animal, today Date) (pheno float64) {
+func (st *State) HeiferPregnancyPhenotype(thisAnimal Animal, today Date) (pheno float64) {
 
-	geneticDirectEffect := GeneticEffect(GeneticIndex("HP", "D"), thisAnimal)
+	geneticDirectEffect := GeneticEffect(st.GeneticIndex("HP", "D"), thisAnimal)
 
 	// for now permEnvEffect := PermenentEnvEffect(PermEnvIndex(thisTrait))
 
-	breedEffects := BreedEffect("HP", thisAnimal)
+	breedEffects := st.BreedEffect("HP", thisAnimal)
 
 	var heterosisEffects float64
 	ok := true
-	heterosisEffects, ok = HeterosisEffect("HP", thisAnimal)
+	heterosisEffects, ok = st.HeterosisEffect("HP", thisAnimal)
 	if !ok {
 		heterosisEffects = 0.0
 	}
 
-	sexAgeOfDamEffects := SexAgeOfDamEffect("HP", thisAnimal) // Only sex effect if not AOD effect
+	sexAgeOfDamEffects := st.SexAgeOfDamEffect("HP", thisAnimal) // Only sex effect if not AOD effect
 
 	daysOfAge := today - thisAnimal.BirthDate
 
-	ageEffect := TraitAgeEffects["HP"].Slope * (float64(daysOfAge) - TraitAgeEffects["HP"].Age)
+	ageEffect := st.TraitAgeEffects["HP"].Slope * (float64(daysOfAge) - st.TraitAgeEffects["HP"].Age)
 
 	//residual := thisAnimal.Residual.AtVec(ResidualIndex("HP")) // Simulated as uncorrelated to other residuals
-	residual := Rng.NormFloat64() * ResidualHpStdDev
+	residual := st.Rng.Residual.NormFloat64() * st.ResidualHpStdDev
+
+	inbreedingEffects := st.InbreedingEffect("HP", thisAnimal)
 
 	//pheno = TraitMean["HP"] +
 	pheno = breedEffects +
 		heterosisEffects +
 		sexAgeOfDamEffects +
+		inbreedingEffects +
 		ageEffect +
 		geneticDirectEffect +
 		//permEnvEffect +
 		residual
 
-	if HPPhenotypeFilePointer != nil {
-		fmt.Fprintln(HPPhenotypeFilePointer,
+	if st.HPPhenotypeFilePointer != nil {
+		fmt.Fprintln(st.HPPhenotypeFilePointer,
 			thisAnimal.Id,       // 1
 			thisAnimal.YearBorn, // 2
 			today,               // 3
-			TraitMean["HP"],     // 4
+			st.TraitMean["HP"],  // 4
 			breedEffects,        // 5
 			heterosisEffects,    // 6
 			sexAgeOfDamEffects,  // 7
@@ -508,45 +501,48 @@ func HeiferPregnancyPhenotype(thisAnimal Animal, today Date) (pheno float64) {
 
 // Calculate the calving difficulty phenotype for of the initial heifer population
 // This is used to parameterize the distribution
-func CalvingDifficultyPhenotype(thisAnimal Animal, thisBreeding BreedingRec) (pheno float64) {
+func (st *State) CalvingDifficultyPhenotype(thisAnimal Animal, thisBreeding BreedingRec) (pheno float64) {
 
-	geneticDirectEffect := GeneticEffect(GeneticIndex("CD", "D"), thisAnimal)
-	geneticMaternalEffect := GeneticEffect(GeneticIndex("CD", "M"), thisAnimal) * .5 // Use the animal's own maternal since dam is unknown
+	geneticDirectEffect := GeneticEffect(st.GeneticIndex("CD", "D"), thisAnimal)
+	geneticMaternalEffect := GeneticEffect(st.GeneticIndex("CD", "M"), thisAnimal) * .5 // Use the animal's own maternal since dam is unknown
 
-	breedEffects := BreedEffect("CD", thisAnimal)
+	breedEffects := st.BreedEffect("CD", thisAnimal)
 
 	var heterosisEffects float64
 	ok := true
-	heterosisEffects, ok = HeterosisEffect("CD", thisAnimal)
+	heterosisEffects, ok = st.HeterosisEffect("CD", thisAnimal)
 	if !ok {
 		heterosisEffects = 0.0
 	}
 
-	sexAgeOfDamEffects := SexAgeOfDamEffect("CD", thisAnimal) // Only sex effect if not AOD effect
+	sexAgeOfDamEffects := st.SexAgeOfDamEffect("CD", thisAnimal) // Only sex effect if not AOD effect
 
 	daysOfAge := thisBreeding.CalvingDate - thisAnimal.BirthDate - 730 // 730 is 2yoa
 
-	ageEffect := TraitAgeEffects["CD"].Slope * (float64(daysOfAge) - TraitAgeEffects["CD"].Age)
+	ageEffect := st.TraitAgeEffects["CD"].Slope * (float64(daysOfAge) - st.TraitAgeEffects["CD"].Age)
+
+	residual := thisAnimal.Residual.AtVec(st.ResidualIndex("CD"))
 
-	residual := thisAnimal.Residual.AtVec(ResidualIndex("CD"))
+	inbreedingEffects := st.InbreedingEffect("CD", thisAnimal)
 
-	pheno = TraitMean["CD"] +
+	pheno = st.TraitMean["CD"] +
 		breedEffects +
 		heterosisEffects +
 		sexAgeOfDamEffects +
+		inbreedingEffects +
 		ageEffect +
 		geneticDirectEffect +
 		geneticMaternalEffect +
 		residual
 
-	if CDPhenotypeFilePointer != nil {
+	if st.CDPhenotypeFilePointer != nil {
 		today := 0
-		bw, _ := Phenotype(thisAnimal, "BW")
-		fmt.Fprintln(CDPhenotypeFilePointer,
+		bw, _ := st.Phenotype(thisAnimal, "BW")
+		fmt.Fprintln(st.CDPhenotypeFilePointer,
 			thisAnimal.Id,       // 1
 			thisAnimal.YearBorn, // 2
 			today,               // 3
-			TraitMean["CD"],     // 4
+			st.TraitMean["CD"],  // 4
 			breedEffects,        // 5
 			heterosisEffects,    // 6
 			sexAgeOfDamEffects,  // 7
@@ -562,33 +558,33 @@ func CalvingDifficultyPhenotype(thisAnimal Animal, thisBreeding BreedingRec) (ph
 }
 
 // Calculate the mature weight phenotype at a particular day - e.g. at weaning
-func MatureWeightAtAgePhenotype(thisAnimal Animal, today Date) (pheno float64) {
+func (st *State) MatureWeightAtAgePhenotype(thisAnimal Animal, today Date) (pheno float64) {
 
-	geneticDirectEffect := GeneticEffect(GeneticIndex("MW", "D"), thisAnimal)
+	geneticDirectEffect := GeneticEffect(st.GeneticIndex("MW", "D"), thisAnimal)
 
 	// for now permEnvEffect := PermenentEnvEffect(PermEnvIndex(thisTrait))
 
-	breedEffects := BreedEffect("MW", thisAnimal)
+	breedEffects := st.BreedEffect("MW", thisAnimal)
 
 	var heterosisEffects float64
 	ok := true
-	heterosisEffects, ok = HeterosisEffect("MW", thisAnimal)
+	heterosisEffects, ok = st.HeterosisEffect("MW", thisAnimal)
 	if !ok {
 		heterosisEffects = 0.0
 	}
 
-	sexAgeOfDamEffects := SexAgeOfDamEffect("MW", thisAnimal) // Only sex effect if not AOD effect
+	sexAgeOfDamEffects := st.SexAgeOfDamEffect("MW", thisAnimal) // Only sex effect if not AOD effect
 
 	daysOfAge := today - thisAnimal.BirthDate
 	if daysOfAge > 1735 { // BIF Guidelines
 		daysOfAge = 1735
 	}
 
-	ageEffect := TraitAgeEffects["MW"].Slope * (float64(daysOfAge) - TraitAgeEffects["STAY"].Age)
+	ageEffect := st.TraitAgeEffects["MW"].Slope * (float64(daysOfAge) - st.TraitAgeEffects["STAY"].Age)
 
-	residual := thisAnimal.Residual.AtVec(ResidualIndex("MW")) // I know it should be different for each obs but...
+	residual := thisAnimal.Residual.AtVec(st.ResidualIndex("MW")) // I know it should be different for each obs but...
 
-	pheno = TraitMean["MW"] +
+	pheno = st.TraitMean["MW"] +
 		breedEffects +
 		heterosisEffects +
 		sexAgeOfDamEffects +
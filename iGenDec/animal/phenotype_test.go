@@ -0,0 +1,65 @@
+// phenotype_test.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"math"
+	"testing"
+)
+
+// Breed effects of a three breed cross are summed in the order of the
+// breeds, so every call gives the same bits and a seed repeats exactly
+func TestBreedEffectOrder(t *testing.T) {
+	st := NewState(1, "")
+	st.BreedsList = []string{"Angus", "Hereford", "Brahman"}
+	effects := map[string]float64{"Angus": .3, "Hereford": -3.1, "Brahman": 5.7}
+	st.Breeds = map[Component_t]BreedEffects_t{
+		{TraitName: "WW", Component: "D"}: {TraitName: "WW", Component: "D", Effects: effects},
+		{TraitName: "WW", Component: "M"}: {TraitName: "WW", Component: "M", Effects: effects},
+	}
+	dam := Animal{Id: 1, Sex: "C", BirthDate: 0, BreedComposition: map[string]float64{"Angus": .3, "Hereford": .3, "Brahman": .4}}
+	calf := Animal{Id: 2, Dam: 1, Sex: "S", BirthDate: 1500, YearBorn: 1, BreedComposition: map[string]float64{"Angus": .45, "Hereford": .35, "Brahman": .2}}
+	st.Records = []Animal{dam, calf}
+	aod := st.WhatAod(calf)
+	st.BreedTraitSexAod = make(map[BTS_t]float64)
+	for i, b := range st.BreedsList {
+		st.BreedTraitSexAod[BTS_t{Breed: b, Trait: "WW", Sex: "S", Aod: aod}] = []float64{-30.3, -20.7, -10.1}[i]
+	}
+
+	var want, wantAod float64
+	for _, b := range st.BreedsList {
+		want += effects[b] * dam.BreedComposition[b]
+	}
+	for _, b := range st.BreedsList {
+		want += effects[b] * calf.BreedComposition[b]
+		wantAod += st.BreedTraitSexAod[BTS_t{Breed: b, Trait: "WW", Sex: "S", Aod: aod}] * calf.BreedComposition[b]
+	}
+
+	for i := 0; i < 100; i++ {
+		if got := st.BreedEffect("WW", calf); math.Float64bits(got) != math.Float64bits(want) {
+			t.Fatalf("call %d: breed effect %.20g, want %.20g", i, got, want)
+		}
+		if got := st.SexAgeOfDamEffect("WW", calf); math.Float64bits(got) != math.Float64bits(wantAod) {
+			t.Fatalf("call %d: sex and age of dam effect %.20g, want %.20g", i, got, wantAod)
+		}
+	}
+}
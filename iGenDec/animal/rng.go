@@ -0,0 +1,147 @@
+// rng
+//
+// Named random number streams so every stochastic decision is reproducible
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+// Names of the random number streams.  Each is seeded from the run seed and its name
+const (
+	BreedingStream    = "breeding"    // breeding dates, bull assignment, gestation length
+	SexStream         = "sex"         // sex of the calves
+	CompositionStream = "composition" // breed composition of foundation cows and bulls
+	GeneticStream     = "genetic"     // foundation breeding values and Mendelian sampling
+	ResidualStream    = "residual"    // residual effects of the phenotypes
+	GridStream        = "grid"        // qualification of slaughter cattle for grid programs
+	BullsStream       = "bulls"       // bull injuries and the bulls bought from the bull battery
+	HeifersStream     = "heifers"     // random heifer selection and the error of estimated heifer indexes
+	AIStream          = "ai"          // AI sires and embryo parents, their choice, conception to timed AI and embryo transfer
+	CalvingStream     = "calving"     // calf and cow deaths of the calving ease categories
+	GenomeStream      = "genome"      // positions, founder allele frequencies and QTL effects of the genome
+)
+
+var StreamNames = []string{BreedingStream, SexStream, CompositionStream, GeneticStream, ResidualStream, GridStream, BullsStream, HeifersStream, AIStream, CalvingStream, GenomeStream}
+
+// Streams are the seeded random number generators of one simulation.  Because
+// they are separate, a change in how many draws one decision takes does not
+// shift the draws of the others.
+type Streams struct {
+	Breeding    *rand.Rand
+	Sex         *rand.Rand
+	Composition *rand.Rand
+	Genetic     *rand.Rand
+	Residual    *rand.Rand
+	Grid        *rand.Rand
+	Bulls       *rand.Rand
+	Heifers     *rand.Rand
+	AI          *rand.Rand
+	Calving     *rand.Rand
+	Genome      *rand.Rand
+
+	seed    int64 // The run seed the streams are derived from
+	sources map[string]*countingSource
+}
+
+// A rand.Source that counts the draws taken so its position can be saved and restored
+type countingSource struct {
+	src   rand.Source64
+	seed  int64
+	draws uint64
+}
+
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *countingSource) Uint64() uint64 {
+	s.draws++
+	return s.src.Uint64()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.src.Seed(seed)
+	s.seed = seed
+	s.draws = 0
+}
+
+// Seed all of the streams from the run seed
+func NewStreams(seed int64) *Streams {
+	s := new(Streams)
+	s.seed = seed
+	s.sources = make(map[string]*countingSource)
+
+	for _, name := range StreamNames {
+		src := &countingSource{src: rand.NewSource(0).(rand.Source64)}
+		src.Seed(StreamSeed(seed, name))
+		s.sources[name] = src
+	}
+
+	s.Breeding = rand.New(s.sources[BreedingStream])
+	s.Sex = rand.New(s.sources[SexStream])
+	s.Composition = rand.New(s.sources[CompositionStream])
+	s.Genetic = rand.New(s.sources[GeneticStream])
+	s.Residual = rand.New(s.sources[ResidualStream])
+	s.Grid = rand.New(s.sources[GridStream])
+	s.Bulls = rand.New(s.sources[BullsStream])
+	s.Heifers = rand.New(s.sources[HeifersStream])
+	s.AI = rand.New(s.sources[AIStream])
+	s.Calving = rand.New(s.sources[CalvingStream])
+	s.Genome = rand.New(s.sources[GenomeStream])
+
+	return s
+}
+
+// The seed of a named stream
+func StreamSeed(seed int64, name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return seed ^ int64(h.Sum64())
+}
+
+// The run seed the streams were derived from
+func (s *Streams) Seed() int64 {
+	return s.seed
+}
+
+// Number of draws taken from each stream
+func (s *Streams) Positions() map[string]uint64 {
+	p := make(map[string]uint64, len(s.sources))
+	for name, src := range s.sources {
+		p[name] = src.draws
+	}
+	return p
+}
+
+// Move each stream to a position returned by Positions
+func (s *Streams) SetPositions(p map[string]uint64) {
+	for name, src := range s.sources {
+		src.Seed(src.seed)
+		for src.draws < p[name] {
+			src.Int63()
+		}
+	}
+}
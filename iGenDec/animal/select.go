@@ -0,0 +1,177 @@
+// select.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Heifer selection policies from the heiferSelection: key
+const (
+	SelectFirst         = "first"         // The first heifers found in Records, the original behaviour
+	SelectRandom        = "random"        // A random sample of the heifers
+	SelectPhenotype     = "phenotype"     // Ranked on the phenotype of HeiferSelectionTrait
+	SelectBreedingValue = "breedingValue" // Ranked on the true breeding value index
+	SelectIndex         = "index"         // Ranked on the breeding value index estimated with HeiferIndexAccuracy, or GenomicTestAccuracy when tested
+	SelectEbv           = "ebv"           // Ranked on the index of the EBVs from the yearly evaluation
+)
+
+var SelectionPolicies = []string{SelectFirst, SelectRandom, SelectPhenotype, SelectBreedingValue, SelectIndex, SelectEbv}
+
+// Score a replacement heifer candidate under the herd's selection policy.  Higher is kept.
+// The accuracy is that of the index policy.
+func (st *State) heiferScore(a *Animal, accuracy float64) float64 {
+	switch st.HeiferSelection {
+	case SelectRandom:
+		return st.Rng.Heifers.Float64()
+	case SelectPhenotype:
+		p, ok := st.Phenotype(*a, st.HeiferSelectionTrait)
+		if !ok { // Foundation heifers have no record and rank as average
+			p = st.TraitMean[st.HeiferSelectionTrait]
+		}
+		if st.HeiferLowestFirst {
+			return -p
+		}
+		return p
+	case SelectBreedingValue, SelectIndex:
+		var v float64
+		for j, w := range st.HeiferIndexWeights {
+			v += w * a.BreedingValue.AtVec(j)
+		}
+		if st.HeiferSelection == SelectIndex && accuracy < 1 {
+			// Error that leaves the estimate correlated with the true index by the accuracy
+			r2 := accuracy * accuracy
+			v += st.Rng.Heifers.NormFloat64() * st.HeiferIndexStdDev * math.Sqrt((1-r2)/r2)
+		}
+		return v
+	case SelectEbv:
+		e, ok := st.EstimatedBreedingValue(a.Id)
+		if !ok {
+			return 0
+		}
+		var v float64
+		for j, w := range st.HeiferIndexWeights {
+			v += w * e.Value[j]
+		}
+		return v
+	}
+	return 0
+}
+
+// Rank the heifers that could calve as 2 year olds and make the best
+// replacements plus the surplus kept for development into cows
+func (st *State) selectHeifers(herd *Herd, year int, nReplacements int) (replace int) {
+
+	st.heiferScores = make(map[AnimalId]float64)
+	st.heifersNeeded[herd.HerdName] = nReplacements
+
+	// The burnin is the same with and without testing so runs can be paired
+	tested := st.GenomicTest && st.HeiferSelection == SelectIndex && year > st.Burnin
+	accuracy := st.HeiferIndexAccuracy
+	if tested {
+		accuracy = st.GenomicTestAccuracy
+	}
+
+	var candidates []*Animal
+	for i := herd.Cows[len(herd.Cows)-1].Id - 1; i < AnimalId(len(st.Records)-1); i++ {
+		if st.isReplacement(&st.Records[i], herd, year) {
+			st.heiferScores[st.Records[i].Id] = st.heiferScore(&st.Records[i], accuracy)
+			candidates = append(candidates, &st.Records[i])
+		}
+	}
+
+	if tested {
+		c := st.BreedingCosts[year]
+		c.Tested += len(candidates)
+		c.TestCost += float64(len(candidates)) * st.GenomicTestCost
+		st.BreedingCosts[year] = c
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return st.heiferScores[candidates[i].Id] > st.heiferScores[candidates[j].Id]
+	})
+
+	n := int(math.Ceil(float64(nReplacements) * (1 + st.HeiferSurplus)))
+	for _, a := range candidates {
+		if replace == n {
+			break
+		}
+		a.Active = true
+		a.Sex = Cow
+		a.DateCowEntered = int(herd.SumBirthDates[year-1]/herd.NBorn[year-1]) + 205 + 365
+		replace++
+	}
+
+	if st.OutputMode == "verbose" {
+		fmt.Printf("Selected %d of %d heifers by %s in the %v herd, year: %d\n", replace, len(candidates), st.HeiferSelection, herd.HerdName, year)
+		if tested {
+			fmt.Printf("Genomic tested %d heifers at accuracy %.2f for $%.2f\n", len(candidates), accuracy, float64(len(candidates))*st.GenomicTestCost)
+		}
+	}
+	return replace
+}
+
+// Once the open heifers are culled, sell the lowest ranked of the bred
+// heifers that are more than the replacements needed this year
+func (st *State) CullSurplusHeifers(herd *Herd, year int) {
+
+	if st.HeiferSurplus <= 0 {
+		return
+	}
+
+	herd.Cows = st.ActiveCows(herd)
+
+	var heifers []*Animal
+	for _, c := range herd.Cows {
+		if year-c.YearBorn == 2 {
+			heifers = append(heifers, c)
+		}
+	}
+	extra := len(heifers) - st.heifersNeeded[herd.HerdName]
+	delete(st.heifersNeeded, herd.HerdName)
+	if extra <= 0 {
+		return
+	}
+
+	sort.SliceStable(heifers, func(i, j int) bool { return st.heiferScores[heifers[i].Id] < st.heiferScores[heifers[j].Id] })
+
+	h := HerdYear_t{Herd: herd.HerdName, Year: year}
+	b := st.BreedingRecordsYearTable[h]
+
+	for i := 0; i < extra; i++ {
+		c := heifers[i]
+		c.Active = false
+		c.DateCowCulled = int(herd.SumBirthDates[year]/herd.NBorn[year]) + 205
+		st.CullAum(c, year, 5)
+		b.HeifersCulledSurplus++
+
+		w := st.MatureWeightAtAgePhenotype(*c, Date(c.DateCowCulled))
+		s := st.WtCullCows[year]
+		s.CumWt += w
+		s.NheadSurplus++
+		st.WtCullCows[year] = s
+	}
+
+	st.BreedingRecordsYearTable[h] = b
+	herd.Cows = st.ActiveCows(herd)
+}
@@ -0,0 +1,190 @@
+// snapshot
+//
+// Save and restore the state of a simulation after the burnin
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+)
+
+// Herd with its active animal lists stored as Ids rather than pointers into Records
+type herdSnapshot_t struct {
+	Herd   Herd
+	Cows   []AnimalId
+	Calves []AnimalId
+	Bulls  []AnimalId
+}
+
+// Everything the simulation changes while running the burnin
+type snapshot_t struct {
+	Seed      int64             // Run seed of the random number streams
+	Positions map[string]uint64 // Draws taken from each stream
+
+	Records    []Animal
+	RecordsCap int // See Restore
+	Herds      map[string]herdSnapshot_t
+
+	WtCullCows               map[int]Sales_t
+	NHeifersBred             map[int]int
+	CowsExposedPerYear       map[int]int
+	BreedingCosts            map[int]BreedingCost_t
+	CalvingCosts             map[int]CalvingCost_t
+	AISires                  []AISire_t
+	Embryos                  []Embryo_t
+	Recipients               map[AnimalId]AnimalId
+	Genome                   *Genome_t
+	Haplotypes               map[AnimalId]Haplotypes_t
+	BreedingRecordsYearTable map[HerdYear_t]BreedingRecordsTable_t
+	CowResetList             []Animal
+	MaxCowAge                int
+
+	Ebv             []Ebv_t
+	EvaluationYear  int
+	EvaluationTable []EvaluationSummary_t
+}
+
+// Serialise the animals, herds, summary tables and random number positions
+func (st *State) Snapshot() ([]byte, error) {
+
+	var s snapshot_t
+	s.Seed = st.Rng.Seed()
+	s.Positions = st.Rng.Positions()
+	s.Records = st.Records
+	s.RecordsCap = cap(st.Records)
+	s.Herds = make(map[string]herdSnapshot_t, len(st.Herds))
+	for name, h := range st.Herds {
+		var hs herdSnapshot_t
+		hs.Cows = animalIds(h.Cows)
+		hs.Calves = animalIds(h.Calves)
+		hs.Bulls = animalIds(h.Bulls)
+		h.Cows, h.Calves, h.Bulls = nil, nil, nil
+		hs.Herd = h
+		s.Herds[name] = hs
+	}
+	s.WtCullCows = st.WtCullCows
+	s.NHeifersBred = st.NHeifersBred
+	s.CowsExposedPerYear = st.CowsExposedPerYear
+	s.BreedingCosts = st.BreedingCosts
+	s.CalvingCosts = st.CalvingCosts
+	s.AISires = st.AISires
+	s.Embryos = st.Embryos
+	s.Recipients = st.recipients
+	s.Genome = st.Genome
+	s.Haplotypes = st.haplotypes
+	s.BreedingRecordsYearTable = st.BreedingRecordsYearTable
+	s.CowResetList = st.CowResetList
+	s.MaxCowAge = st.MaxCowAge
+	s.Ebv = st.Ebv
+	s.EvaluationYear = st.EvaluationYear
+	s.EvaluationTable = st.EvaluationTable
+
+	var b bytes.Buffer
+	if err := gob.NewEncoder(&b).Encode(&s); err != nil {
+		return nil, fmt.Errorf("snapshot: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// Replace the state with one saved by Snapshot.  The parameters must be those
+// the snapshot was made with.
+func (st *State) Restore(data []byte) error {
+
+	var s snapshot_t
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return fmt.Errorf("restore snapshot: %w", err)
+	}
+
+	st.Rng = NewStreams(s.Seed)
+	st.Rng.SetPositions(s.Positions)
+
+	// Herd lists point into Records and are only refreshed at points in the
+	// year, so when append moves Records matters.  Keep the same capacity.
+	st.Records = make([]Animal, len(s.Records), s.RecordsCap)
+	copy(st.Records, s.Records)
+	st.Herds = make(map[string]Herd, len(s.Herds))
+	for name, hs := range s.Herds {
+		h := hs.Herd
+		h.Cows = st.animalPointers(hs.Cows)
+		h.Calves = st.animalPointers(hs.Calves)
+		h.Bulls = st.animalPointers(hs.Bulls)
+		st.Herds[name] = h
+	}
+	st.SortHerdNames()
+	st.WtCullCows = s.WtCullCows
+	st.NHeifersBred = s.NHeifersBred
+	st.CowsExposedPerYear = s.CowsExposedPerYear
+	st.BreedingCosts = s.BreedingCosts
+	st.CalvingCosts = s.CalvingCosts
+	st.AISires = s.AISires
+	st.Embryos = s.Embryos
+	st.recipients = s.Recipients
+	st.Genome = s.Genome
+	st.haplotypes = s.Haplotypes
+	st.BreedingRecordsYearTable = s.BreedingRecordsYearTable
+	st.CowResetList = s.CowResetList
+	st.MaxCowAge = s.MaxCowAge
+	st.Ebv = s.Ebv
+	st.EvaluationYear = s.EvaluationYear
+	st.EvaluationTable = s.EvaluationTable
+	st.inbreeding, st.mendelian = nil, nil // Recalculated from Records
+
+	// gob leaves empty maps nil
+	if st.WtCullCows == nil {
+		st.WtCullCows = make(map[int]Sales_t)
+	}
+	if st.NHeifersBred == nil {
+		st.NHeifersBred = make(map[int]int)
+	}
+	if st.CowsExposedPerYear == nil {
+		st.CowsExposedPerYear = make(map[int]int)
+	}
+	if st.BreedingCosts == nil {
+		st.BreedingCosts = make(map[int]BreedingCost_t)
+	}
+	if st.CalvingCosts == nil {
+		st.CalvingCosts = make(map[int]CalvingCost_t)
+	}
+	if st.BreedingRecordsYearTable == nil {
+		st.BreedingRecordsYearTable = make(map[HerdYear_t]BreedingRecordsTable_t)
+	}
+
+	return nil
+}
+
+func animalIds(animals []*Animal) []AnimalId {
+	ids := make([]AnimalId, len(animals))
+	for i, a := range animals {
+		ids[i] = a.Id
+	}
+	return ids
+}
+
+func (st *State) animalPointers(ids []AnimalId) []*Animal {
+	animals := make([]*Animal, len(ids))
+	for i, id := range ids {
+		animals[i] = &st.Records[id-1]
+	}
+	return animals
+}
@@ -0,0 +1,161 @@
+// state
+//
+// The state of one herd simulation
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"os"
+)
+
+// State holds everything one simulation knows about its animals and herds.
+// Each simulation owns its own State so several can run side by side.
+type State struct {
+	OutputMode string // verbose, model, conception, etc.
+
+	Rng *Streams // Seeded random number streams for this simulation
+
+	err error // First error of the per-animal calculations
+
+	Records []Animal
+	Herds   map[string]Herd // can be more than 1 such as spring v fall
+	// Names of the Herds in sorted order.  Herds are always simulated in this
+	// order, map order is random and would change the draws and ids of a seed.
+	HerdNames []string
+
+	Traits        []string
+	Components    []string
+	ComponentList []Component_t
+
+	TraitMean map[string]float64 // Means of traits mapped to trait name.  Coms from the Traits: key in master.hjson
+
+	Breeds     map[Component_t]BreedEffects_t // See animal.go and technical documentation for this struct type
+	BreedsList []string                       // A list of the breeds in the master.hjson.  It is the 1st line of the BreedsEffects: key
+
+	HeterosisCodes        map[string]string                 // The breed to breed classification categories in the HeterosisCodes: key in master.hjson
+	HeterosisCrossClasses []string                          // The cross class designations from the HeterosisValues talbe 1st line in the master.hjson
+	HeterosisValues       map[Component_t]HeterosisValues_t // These are mapped according to their Component_t
+
+	BreedTraitSexAod map[BTS_t]float64 // mapped by Breed then trait
+	TraitAgeEffects  map[string]InterceptSlope_t
+
+	FoundationCowHerdBreedCompositionTable []BreedComposition_t
+	BullBatteryBreedCompositionTable       []BreedComposition_t
+	CurrentCalvesBreedCompositionTable     []BreedComposition_t
+
+	// Optional file to write out a phenotype components for debugging
+	PhenotypeFile            string // File name to write output
+	PhenotypeOutputTrait     string // A valid trait name in the master.hjson
+	PhenotypeFilePointer     *os.File
+	StayPhenotypeFilePointer *os.File // For debugging cow conception routines
+	HPPhenotypeFilePointer   *os.File
+	CDPhenotypeFilePointer   *os.File
+	CarcassPhenotypeFile     *os.File // For optional write of slaughter cattle phenotypes QG,YQ, etc.
+
+	CowAgeFile              *os.File // For cowagefilename: in master.hjson if exists
+	RecordsDumpFile         string   // name of file in recordsdump:
+	BreedingRecordsDumpFile string   // name of file in breedingrecordsdump:
+
+	CalfAumAt500 float64 // Amount of Aum at 500 lbs calf
+	CowAumAt1000 float64 // Amount of AUM at 1000 lbs animal
+
+	BumpComponent string // Name,Name of the component  to bump the bulls 1 unit after burnin
+
+	BullMerit []float64 // Genetic merit of the foundation bulls fro meritFoundationBulls key
+	BullTrend []float64 // Yearly change in merit of purchased bulls by genetic component from geneticTrendBulls key
+	BullBump  []float64 // Added to the bulls bought after the burnin when a component is bumped
+
+	AISires       []AISire_t             // Sires of the synchronization protocols from the aiSires key
+	Embryos       []Embryo_t             // Lines of purchased embryos from the embryos key
+	BreedingCosts map[int]BreedingCost_t // Cost of the synchronization, sexed semen and embryo transfer programs by year bred
+
+	CalvingCosts map[int]CalvingCost_t // Calvings by calving ease category, deaths and costs by year born
+
+	recipients map[AnimalId]AnimalId // Recipient cow of each embryo transfer calf
+
+	GenomeLayout []ChromosomeSet_t         // Chromosomes of the genomic mode from the genome key, none without it
+	Genome       *Genome_t                 // Made by MakeGenome, nil without a genome
+	haplotypes   map[AnimalId]Haplotypes_t // Of every animal when there is a genome
+
+	NFoundationBulls int       // Number of foundation bulls in each herd from nFoundationBulls key
+	AgeDist          []float64 // Proportion of foundation cows at each age from ageDist key
+
+	WtCullCows   map[int]Sales_t
+	NHeifersBred map[int]int
+
+	IndexType      string
+	IndexTerminal  bool
+	BackgroundDays float64
+	DaysOnFeed     float64
+
+	CDVar              float64 // phenotypic variance for CDF
+	ResidualStayStdDev float64
+	ResidualHpStdDev   float64
+
+	MaxCowAge int // For the culling policy it is the length of ageDist in master.hjson
+
+	// Replacement heifer selection from the heiferSelection keys
+	HeiferSelection      string    // One of SelectionPolicies, empty is SelectFirst
+	HeiferSelectionTrait string    // Trait of the phenotype policy
+	HeiferLowestFirst    bool      // The phenotype policy keeps the lowest heifers, e.g. for BW
+	HeiferIndexWeights   []float64 // Weight of each genetic component in the heifer index
+	HeiferIndexStdDev    float64   // Standard deviation of the true heifer index
+	HeiferIndexAccuracy  float64   // Correlation of the estimated and true heifer index
+	HeiferSurplus        float64   // Proportion more heifers kept than needed, culled once bred
+
+	// Genomic testing of the heifer candidates after the burnin, from the genomicTest keys
+	GenomicTest         bool    // The candidates are tested this run
+	GenomicTestAccuracy float64 // Accuracy of the index policy for tested heifers
+	GenomicTestCost     float64 // Per candidate tested
+
+	heiferScores  map[AnimalId]float64 // Scores of this year's ranked heifers.  Kept off Animal so Records grows as before.
+	heifersNeeded map[string]int       // Replacements each herd needed this year
+
+	InbreedingDepression map[Component_t]float64 // Change in the phenotype per 1% inbreeding of the animal, D, or its dam, M
+
+	inbreeding []float64 // Inbreeding coefficients by Records position, calculated as needed
+	mendelian  []float64 // Mendelian sampling variance by Records position
+
+	// Yearly animal model evaluation from the evaluationTraits key
+	Evaluation      []EvaluationTrait_t   // Empty when there is no evaluation
+	Ebv             []Ebv_t               // EBVs of the latest evaluation by Records position
+	EvaluationYear  int                   // Year of the latest evaluation
+	EvaluationTable []EvaluationSummary_t // Each evaluation's summary of last year's calves
+
+	CowsExposedPerYear   map[int]int // Counts of the number of cows exposed each year
+	Burnin               int         // Number of years to simulate before calculating the MEV
+	YearsPlanningHorizon int         // Total Number of years to run the simulation after the burnin for MEV calculation
+
+	BreedingRecordsYearTable map[HerdYear_t]BreedingRecordsTable_t
+
+	CowResetList []Animal // List of Records[] to reset to active cows when bumping index components
+}
+
+// NewState returns an empty State with its random number streams seeded
+func NewState(seed int64, outputMode string) *State {
+	st := new(State)
+	st.OutputMode = outputMode
+	st.Rng = NewStreams(seed)
+	st.TraitMean = make(map[string]float64)
+	st.heifersNeeded = make(map[string]int)
+	return st
+}
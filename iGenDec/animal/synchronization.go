@@ -0,0 +1,199 @@
+// synchronization.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package animal
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Classes of females a synchronization protocol is for
+const (
+	SyncHeifers = "heifers" // Yearling heifers
+	SyncCows    = "cows"    // Everything older
+)
+
+var SyncClasses = []string{SyncHeifers, SyncCows}
+
+// A fixed-time AI protocol for a class of a herd's females.  All of them are
+// synchronized and inseminated on AIDay, those that don't conceive are exposed
+// to the herd's bulls from CleanupDay to the end of the breeding season.
+type Synchronization_t struct {
+	Class          string  // heifers or cows
+	AIDay          int     // Days from the start of breeding to the timed insemination
+	ConceptionRate float64 // Proportion of the inseminated females that conceive
+	Cost           float64 // Drugs and labour per female synchronized
+	CleanupDay     int     // Days from the start of breeding the cleanup bulls go in
+	Sires          []int   // Positions in AISires, one is drawn for each female
+
+	// From sexedSemen: in the hjson.  A zero SexedFemales is conventional semen.
+	SexedFemales    float64 // Proportion of heifer calves
+	SexedConception float64 // Conception to sexed semen relative to ConceptionRate
+	SexedCost       float64 // Extra per unit
+}
+
+// A sire of the AI programs.  He is in Records but never in a herd.
+type AISire_t struct {
+	Name      string
+	Breed     string
+	SemenCost float64         // Per unit
+	Merit     map[int]float64 // Added to the foundation bulls' merit by genetic component
+	Id        AnimalId        // Set by MakeAISires
+}
+
+// Cost of a year's synchronization, sexed semen, embryo transfer and genomic testing programs
+type BreedingCost_t struct {
+	Synchronized int     // Females synchronized and inseminated
+	AIPregnant   int     // Females that conceived to AI
+	Cost         float64 // Protocols and conventional semen
+
+	Sexed     int     // Inseminations with sexed semen
+	SexedCost float64 // Its extra cost
+
+	Transfers  int     // Embryos transferred
+	ETPregnant int     // Recipients pregnant to them
+	ETCost     float64 // Transfers and embryos
+
+	Tested   int     // Heifer candidates genomic tested
+	TestCost float64 // Their tests
+}
+
+// Make the AI sires.  Their merit is the foundation bulls' plus their own
+// for the components given, the rest is drawn like a foundation bull's.
+func (st *State) MakeAISires(gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) {
+
+	for k := range st.AISires {
+		s := &st.AISires[k]
+
+		var a Animal
+		a.Id = AnimalId(len(st.Records)) + 1
+		a.Sex = Bull
+
+		st.genUnrelated(&a, gvCholesky, rvCholesky, st.Rng.AI, st.Rng.AI)
+		a.BreedComposition = map[string]float64{s.Breed: 1}
+
+		for j, m := range st.BullMerit {
+			a.BreedingValue.SetVec(j, a.BreedingValue.AtVec(j)+m)
+		}
+		for j, m := range s.Merit {
+			var base float64
+			if j < len(st.BullMerit) {
+				base = st.BullMerit[j]
+			}
+			a.BreedingValue.SetVec(j, base+m)
+		}
+
+		st.Records = append(st.Records, a)
+		s.Id = a.Id
+
+		if st.OutputMode == "verbose" {
+			fmt.Printf("AI sire %v is animal %d\n", s.Name, a.Id)
+		}
+	}
+}
+
+// The herd's protocol for a class, nil when it has none
+func (herd *Herd) protocol(class string) *Synchronization_t {
+	for i := range herd.Synchronization {
+		if herd.Synchronization[i].Class == class {
+			return &herd.Synchronization[i]
+		}
+	}
+	return nil
+}
+
+// Synchronize a female and inseminate her on the protocol's AI day.  The
+// sire follows the herd's mating plan when one of the protocol's sires is of
+// the breed wanted.
+func (st *State) inseminate(herd *Herd, cow *Animal, p *Synchronization_t, terminal bool, year int) (sire *Animal, pregnant bool) {
+
+	breed := st.planBreed(herd, cow, terminal)
+
+	sires := make([]*Animal, 0, len(p.Sires))
+	for _, k := range p.Sires {
+		s := &st.Records[st.AISires[k].Id-1]
+		if herd.MatingPlan == "" || bullBreed(herd, s) == breed {
+			sires = append(sires, s)
+		}
+	}
+	if len(sires) == 0 {
+		for _, k := range p.Sires {
+			sires = append(sires, &st.Records[st.AISires[k].Id-1])
+		}
+	}
+	k := randRange(st.Rng.AI, 0, len(sires))
+	sire = sires[k]
+	rate := p.ConceptionRate
+	if p.SexedFemales > 0 {
+		rate *= p.SexedConception
+	}
+	pregnant = st.Rng.AI.Float64() < rate
+
+	c := st.BreedingCosts[year]
+	c.Synchronized++
+	c.Cost += p.Cost + st.semenCost(sire.Id)
+	if p.SexedFemales > 0 {
+		c.Sexed++
+		c.SexedCost += p.SexedCost
+	}
+	if pregnant {
+		c.AIPregnant++
+	}
+	st.BreedingCosts[year] = c
+
+	return sire, pregnant
+}
+
+// Record a conception to AI or a transferred embryo on a day of the breeding season
+func (herd *Herd) conceived(r *BreedingRec, sire *Animal, day int, year int) {
+	r.DateBred = herd.StartBreeding + Date(day)
+	r.YearBred = year
+	r.Bred = true
+	r.Bull = sire.Id
+	r.CalvingDate = Date((year-1)*365) + r.DateBred + GestationLength()
+}
+
+func (st *State) semenCost(id AnimalId) float64 {
+	for _, s := range st.AISires {
+		if s.Id == id {
+			return s.SemenCost
+		}
+	}
+	return 0
+}
+
+// Print a herd's synchronization, sexed semen and embryo transfer totals for the year
+func (st *State) printSynchronization(herd *Herd, year int, before BreedingCost_t) {
+	c := st.BreedingCosts[year]
+	if c.Synchronized > before.Synchronized {
+		fmt.Printf("Synchronized %d females in the %v herd, year %d: %d conceived to AI, cost $%.2f\n", c.Synchronized-before.Synchronized,
+			herd.HerdName, year, c.AIPregnant-before.AIPregnant, c.Cost-before.Cost)
+	}
+	if c.Sexed > before.Sexed {
+		fmt.Printf("\t%d inseminated with sexed semen, extra cost $%.2f\n", c.Sexed-before.Sexed, c.SexedCost-before.SexedCost)
+	}
+	if c.Transfers > before.Transfers {
+		fmt.Printf("Transferred %d embryos in the %v herd, year %d: %d recipients pregnant, cost $%.2f\n", c.Transfers-before.Transfers,
+			herd.HerdName, year, c.ETPregnant-before.ETPregnant, c.ETCost-before.ETCost)
+	}
+}
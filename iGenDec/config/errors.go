@@ -0,0 +1,206 @@
+// errors
+//
+// Errors found reading the parameter hjson files
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blgolden/iGenDecModel/iGenDec/animal"
+)
+
+// A bad or missing value in a parameter file.  Row is 1 based and 0 when the
+// key is not a table.
+type ParamError struct {
+	Key string
+	Row int
+	Msg string
+}
+
+func (e *ParamError) Error() string {
+	if e.Row > 0 {
+		return fmt.Sprintf("'%s:' row %d: %s", e.Key, e.Row, e.Msg)
+	}
+	return fmt.Sprintf("'%s:' %s", e.Key, e.Msg)
+}
+
+// Every problem found in a parameter file
+type ParamErrors []*ParamError
+
+func (e ParamErrors) Error() string {
+	s := make([]string, len(e))
+	for i := range e {
+		s[i] = e[i].Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+// Accumulates errors while reading a map of hjson key:value pairs
+type reader struct {
+	param map[string]interface{}
+	errs  ParamErrors
+}
+
+func (r *reader) fail(key string, row int, format string, a ...interface{}) {
+	r.errs = append(r.errs, &ParamError{Key: key, Row: row, Msg: fmt.Sprintf(format, a...)})
+}
+
+// Return the error list or nil.  A nil ParamErrors must not be returned as a non-nil error
+func (r *reader) err() error {
+	if len(r.errs) == 0 {
+		return nil
+	}
+	return r.errs
+}
+
+// An array valued key
+func (r *reader) array(key string, required bool) []interface{} {
+	v, ok := r.param[key]
+	if !ok {
+		if required {
+			r.fail(key, 0, "key not found")
+		}
+		return nil
+	}
+	a, ok := v.([]interface{})
+	if !ok {
+		r.fail(key, 0, "must be an array")
+		return nil
+	}
+	return a
+}
+
+// A number valued key.  Numbers written as strings are accepted.
+func (r *reader) number(key string, required bool) float64 {
+	v, ok := r.param[key]
+	if !ok {
+		if required {
+			r.fail(key, 0, "key not found")
+		}
+		return 0
+	}
+	return r.value(key, 0, v)
+}
+
+// A whole number valued key
+func (r *reader) integer(key string, required bool) int {
+	f := r.number(key, required)
+	if f != float64(int(f)) {
+		r.fail(key, 0, "%v must be a whole number", f)
+	}
+	return int(f)
+}
+
+// A true/false valued key
+func (r *reader) boolean(key string, required bool) bool {
+	v, ok := r.param[key]
+	if !ok {
+		if required {
+			r.fail(key, 0, "key not found")
+		}
+		return false
+	}
+	b, ok := v.(bool)
+	if !ok {
+		r.fail(key, 0, "%v must be true or false", v)
+	}
+	return b
+}
+
+// A string valued key
+func (r *reader) str(key string) string {
+	v, ok := r.param[key]
+	if !ok {
+		return ""
+	}
+	s, ok := v.(string)
+	if !ok {
+		r.fail(key, 0, "must be a string")
+	}
+	return strings.TrimSpace(s)
+}
+
+// Pairs of value, "Trait,Comp" such as meritFoundationBulls
+func (r *reader) merit(key string, required bool) []Merit_t {
+	var merit []Merit_t
+	a := r.array(key, required)
+	for i := 0; i < len(a); i = i + 2 {
+		var m Merit_t
+		m.Value = r.value(key, i+1, a[i])
+		if i+1 >= len(a) {
+			r.fail(key, i+1, "value has no \"Trait,Comp\" after it")
+		} else if f, ok := r.fields(key, i+2, a[i+1], 2); ok {
+			m.Component = animal.Component_t{TraitName: f[0], Component: f[1]}
+		}
+		merit = append(merit, m)
+	}
+	return merit
+}
+
+// A number in a row of a table
+func (r *reader) value(key string, row int, v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		return r.parseFloat(key, row, n)
+	}
+	r.fail(key, row, "%v is not a number", v)
+	return 0
+}
+
+// Split a comma separated row and check it has at least n fields
+func (r *reader) fields(key string, row int, v interface{}, n int) ([]string, bool) {
+	s, ok := v.(string)
+	if !ok {
+		r.fail(key, row, "%v must be a quoted comma separated string", v)
+		return nil, false
+	}
+	f := strings.Split(s, ",")
+	for i := range f {
+		f[i] = strings.TrimSpace(f[i])
+	}
+	if len(f) < n {
+		r.fail(key, row, "\"%s\" has %d values, expected at least %d", s, len(f), n)
+		return nil, false
+	}
+	return f, true
+}
+
+func (r *reader) parseFloat(key string, row int, s string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		r.fail(key, row, "\"%s\" is not a number", s)
+	}
+	return f
+}
+
+func (r *reader) parseInt(key string, row int, s string) int {
+	i, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		r.fail(key, row, "\"%s\" is not a whole number", s)
+	}
+	return i
+}
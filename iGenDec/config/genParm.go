@@ -0,0 +1,635 @@
+// genParm
+//
+// The typed contents of the model (genParm) hjson file
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/blgolden/iGenDecModel/iGenDec/animal"
+
+	hjson "github.com/hjson/hjson-go"
+)
+
+// GenParm holds every key of the genParm hjson
+type GenParm struct {
+	Comment string
+
+	Traits     []Trait_t            // Traits: name and mean
+	Components []animal.Component_t // Components: in the order of the genetic covariance matrix
+	Genetic    []float64            // genetic: covariance matrix of the Components by row
+	Residual   []float64            // residual: covariance matrix of the Traits by row
+
+	Herds           []Herd_t
+	Burnin          int // Not needed by terminal indexes
+	PlanningHorizon int
+	CalfAum         float64 // Amount of Aum at 500 lbs calf
+	CowAum          float64 // Amount of AUM at 1000 lbs animal
+
+	NFoundationBulls     int
+	AgeDist              []float64 // Proportion of foundation cows at each age starting at 2
+	MeritFoundationBulls []Merit_t
+
+	BullManagement    []BullManagement_t // Optional, herds without a row keep their foundation bulls
+	GeneticTrendBulls []Merit_t          // Yearly change in the merit of purchased bulls
+	MatingPlans       []MatingPlan_t     // Optional, herds without a row breed cows to any bull
+
+	Synchronization []Synchronization_t // Optional fixed-time AI protocols by herd and class
+	AISires         []AISire_t          // Sires the protocols use
+	AISireMerit     []AISireMerit_t     // Merit of the AI sires over the foundation bulls
+	SexedSemen      []SexedSemen_t      // Optional sexed semen for the synchronization protocols
+	EmbryoTransfer  []EmbryoTransfer_t  // Optional embryo transfer by herd and class
+	Embryos         []Embryo_t          // Lines of purchased embryos
+	EmbryoMerit     []AISireMerit_t     // Merit of the embryo lines' parents over the foundation bulls
+
+	CalvingEase            []CalvingEase_t          // Optional calving ease categories by herd
+	CalvingEaseFrequencies []CalvingEaseFrequency_t // Their frequencies by herd and dam age
+	Dystocia               []Dystocia_t             // Optional carry-over effects of the categories
+
+	HeiferSelection      string    // first (default), random, phenotype, breedingValue or index
+	HeiferSelectionTrait string    // Trait ranked by the phenotype policy, a leading - keeps the lowest
+	HeiferIndexWeights   []Merit_t // Weights of the genetic components for breedingValue and index
+	HeiferIndexAccuracy  float64   // Accuracy of the index policy, 1 when not given
+	HeiferSurplus        float64   // Proportion more heifers bred than needed
+
+	GenomicTestAccuracy float64 // Accuracy of the index policy for genomic tested heifers, 0 when there is no test
+	GenomicTestCost     float64 // Per heifer candidate tested
+
+	EvaluationTraits []string // Traits of the yearly animal model evaluation, none turns it off
+
+	InbreedingDepression []Merit_t // Change in the phenotype per 1% inbreeding, D of the animal and M of its dam
+
+	Genome []Chromosomes_t // Optional genome of the genomic mode
+
+	TraitAgeEffects  []TraitAgeEffect_t
+	BreedTraitSexAod []BreedTraitSexAod_t
+
+	HeterosisCodes        []HeterosisCode_t
+	HeterosisCrossClasses []string // 1st row of HeterosisValues: after Trait,Comp
+	HeterosisValues       []HeterosisValue_t
+
+	Breeds       []string // 1st row of BreedEffects: after Trait,Effect,Type
+	BreedEffects []BreedEffect_t
+
+	CowHerdBreedComposition       []BreedComposition_t
+	BullBatteryBreedComposition   []BreedComposition_t
+	CurrentCalvesBreedComposition []BreedComposition_t
+
+	// Optional files for debugging
+	CowAgeFileName       string
+	RecordsDump          string
+	BreedingRecordsDump  string
+	PhenotypeFile        string
+	PhenotypeOutputTrait string
+	StayPhenotypeFile    string
+	HPPhenotypeFile      string
+	CDPhenotypeFile      string
+	CarcassPhenotypeFile string
+}
+
+type Trait_t struct {
+	Name string
+	Mean float64
+}
+
+// A row of herds: "Name, cows, start of breeding, season length, conception rate, calving death loss"
+type Herd_t struct {
+	Name                 string
+	NumberCows           int
+	StartBreeding        int     // Day of the year
+	BreedingSeasonLen    int     // Days
+	SeasonConceptionRate float64 // Proportion of cows bred in the season
+	CalvingDeathLossRate float64 // Initial calving difficulty death loss rate
+}
+
+// A pair of meritFoundationBulls: value, "Trait,Comp"
+type Merit_t struct {
+	Value     float64
+	Component animal.Component_t
+}
+
+// A row of bullManagement: "Herd, service life in years, cows per bull, injury rate"
+type BullManagement_t struct {
+	Herd        string
+	ServiceLife int     // Breeding seasons a bull is used
+	CowsPerBull float64 // Bulls are bought to keep this ratio
+	InjuryRate  float64 // Yearly proportion of bulls culled for injury
+}
+
+// A row of matingPlans: "Herd, rotation, Breed, Breed[, Breed]",
+// "Herd, terminal, Breed, proportion of cows" or
+// "Herd, rotaTerminal, Breed, proportion of cows, Breed, Breed[, Breed]"
+type MatingPlan_t struct {
+	Herd               string
+	Plan               string
+	RotationBreeds     []string
+	TerminalBreed      string
+	TerminalProportion float64
+}
+
+// A row of synchronization: "Herd, heifers or cows, AI day, AI conception rate,
+// cost per female, cleanup day, Sire[, Sire...]".  Days are from the start of breeding.
+type Synchronization_t struct {
+	Herd           string
+	Class          string
+	AIDay          int
+	ConceptionRate float64
+	Cost           float64
+	CleanupDay     int
+	Sires          []string // Names in aiSires
+}
+
+// A row of aiSires: "Name, Breed, semen cost per unit"
+type AISire_t struct {
+	Name      string
+	Breed     string
+	SemenCost float64
+}
+
+// A row of aiSireMerit or embryoMerit: "Name, Trait, Comp, value"
+type AISireMerit_t struct {
+	Name  string
+	Merit Merit_t
+}
+
+// A row of sexedSemen: "Herd, heifers or cows, proportion of heifer calves,
+// conception relative to conventional semen, extra cost per unit"
+type SexedSemen_t struct {
+	Herd               string
+	Class              string
+	Females            float64
+	RelativeConception float64
+	Cost               float64
+}
+
+// A row of embryoTransfer: "Herd, heifers or cows, proportion of recipients,
+// transfer day, pregnancy rate, cost per transfer, cleanup day, Embryo[, Embryo...]"
+type EmbryoTransfer_t struct {
+	Herd          string
+	Class         string
+	Proportion    float64
+	TransferDay   int
+	PregnancyRate float64
+	Cost          float64
+	CleanupDay    int
+	Embryos       []string // Names in embryos
+}
+
+// A row of embryos: "Name, Breed, cost per embryo"
+type Embryo_t struct {
+	Name  string
+	Breed string
+	Cost  float64
+}
+
+// A row of calvingEase: "Herd, Category, calf death rate, cow death rate,
+// labor cost, veterinary cost".  A herd's rows go from the easiest to the hardest.
+type CalvingEase_t struct {
+	Herd      string
+	Category  string
+	CalfDeath float64
+	CowDeath  float64
+	Labor     float64
+	Vet       float64
+}
+
+// A row of calvingEaseFrequencies: "Herd, dam age in years, Frequency[, Frequency...]"
+// with a frequency for each of the herd's calvingEase categories in their order
+type CalvingEaseFrequency_t struct {
+	Herd        string
+	Age         int
+	Frequencies []float64
+}
+
+// A row of dystocia: "Herd, Category, dam age in years, postpartum delay in days,
+// weaning weight penalty" for a calvingEase category of the herd
+type Dystocia_t struct {
+	Herd            string
+	Category        string
+	Age             int
+	PostpartumDelay int
+	WeaningPenalty  float64
+}
+
+// A row of genome: "chromosomes, length in Morgans, markers per chromosome, QTL per chromosome"
+type Chromosomes_t struct {
+	Number  int
+	Length  float64
+	Markers int
+	QTL     int
+}
+
+// A row of TraitAgeEffects: "Trait, slope, age"
+type TraitAgeEffect_t struct {
+	Trait string
+	Slope float64
+	Age   float64
+}
+
+// A row of BreedTraitSexAod: "Breed, Trait, Sex, AOD 0, AOD 1, ..."
+type BreedTraitSexAod_t struct {
+	Breed  string
+	Trait  string
+	Sex    string
+	Effect []float64 // by age of dam
+}
+
+// A row of HeterosisCodes: "Breed, Code"
+type HeterosisCode_t struct {
+	Breed string
+	Code  string
+}
+
+// A row of HeterosisValues: "Trait, Comp, value for each cross class"
+type HeterosisValue_t struct {
+	Component animal.Component_t
+	Values    []float64 // in the order of HeterosisCrossClasses
+}
+
+// A row of BreedEffects: "Trait, Effect, Cow or Calf, value for each breed"
+type BreedEffect_t struct {
+	Component animal.Component_t
+	CowOrCalf string
+	Effects   []float64 // in the order of Breeds
+}
+
+// A pair of a breed composition table: percent, "Breed, percent, Breed, percent..."
+type BreedComposition_t struct {
+	Percent float64 // Percent of the animals with this composition
+	Breeds  []BreedPercent_t
+}
+
+type BreedPercent_t struct {
+	Breed   string
+	Percent float64
+}
+
+// Read in a hjson file and setup the map of param[key] pairs
+func ReadHjson(fileName string) (map[string]interface{}, error) {
+
+	byteValue, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open parameter file %s: %w", fileName, err)
+	}
+
+	var param map[string]interface{}
+	if err = hjson.Unmarshal(byteValue, &param); err != nil {
+		return nil, fmt.Errorf("could not process the hjson %s: %w", fileName, err)
+	}
+
+	return param, nil
+}
+
+// Convert the hjson key:value pairs.  Every bad or missing value is reported
+// in the returned ParamErrors and the GenParm holds what could be read.
+func NewGenParm(param map[string]interface{}) (*GenParm, error) {
+
+	r := &reader{param: param}
+	g := new(GenParm)
+
+	g.Comment = r.str("Comment")
+
+	for i, v := range r.array("Traits", true) {
+		if f, ok := r.fields("Traits", i+1, v, 2); ok {
+			g.Traits = append(g.Traits, Trait_t{Name: f[0], Mean: r.parseFloat("Traits", i+1, f[1])})
+		}
+	}
+
+	for i, v := range r.array("Components", true) {
+		if f, ok := r.fields("Components", i+1, v, 2); ok {
+			g.Components = append(g.Components, animal.Component_t{TraitName: f[0], Component: f[1]})
+		}
+	}
+
+	for _, key := range []string{"genetic", "residual"} {
+		var m []float64
+		for i, v := range r.array(key, true) {
+			m = append(m, r.value(key, i+1, v))
+		}
+		if key == "genetic" {
+			g.Genetic = m
+		} else {
+			g.Residual = m
+		}
+	}
+
+	for i, v := range r.array("herds", true) {
+		if f, ok := r.fields("herds", i+1, v, 6); ok {
+			var h Herd_t
+			h.Name = f[0]
+			h.NumberCows = r.parseInt("herds", i+1, f[1])
+			h.StartBreeding = r.parseInt("herds", i+1, f[2])
+			h.BreedingSeasonLen = r.parseInt("herds", i+1, f[3])
+			h.SeasonConceptionRate = r.parseFloat("herds", i+1, f[4])
+			h.CalvingDeathLossRate = r.parseFloat("herds", i+1, f[5])
+			g.Herds = append(g.Herds, h)
+		}
+	}
+
+	g.Burnin = r.integer("burnin", false)
+	g.PlanningHorizon = r.integer("planningHorizon", false)
+	g.CalfAum = r.number("calfAum", false)
+	g.CowAum = r.number("cowAum", false)
+
+	g.NFoundationBulls = r.integer("nFoundationBulls", true)
+	for i, v := range r.array("ageDist", true) {
+		g.AgeDist = append(g.AgeDist, r.value("ageDist", i+1, v))
+	}
+
+	g.MeritFoundationBulls = r.merit("meritFoundationBulls", true)
+
+	for i, v := range r.array("bullManagement", false) {
+		if f, ok := r.fields("bullManagement", i+1, v, 4); ok {
+			g.BullManagement = append(g.BullManagement, BullManagement_t{
+				Herd:        f[0],
+				ServiceLife: r.parseInt("bullManagement", i+1, f[1]),
+				CowsPerBull: r.parseFloat("bullManagement", i+1, f[2]),
+				InjuryRate:  r.parseFloat("bullManagement", i+1, f[3])})
+		}
+	}
+	g.GeneticTrendBulls = r.merit("geneticTrendBulls", false)
+
+	for i, v := range r.array("matingPlans", false) {
+		f, ok := r.fields("matingPlans", i+1, v, 3)
+		if !ok {
+			continue
+		}
+		m := MatingPlan_t{Herd: f[0], Plan: f[1]}
+		switch m.Plan {
+		case animal.PlanRotation:
+			m.RotationBreeds = f[2:]
+		case animal.PlanTerminal, animal.PlanRotaTerminal:
+			if len(f) < 4 {
+				r.fail("matingPlans", i+1, "%s needs a terminal breed and the proportion of cows bred to it", m.Plan)
+				continue
+			}
+			m.TerminalBreed = f[2]
+			m.TerminalProportion = r.parseFloat("matingPlans", i+1, f[3])
+			if m.Plan == animal.PlanRotaTerminal {
+				m.RotationBreeds = f[4:]
+			}
+		}
+		g.MatingPlans = append(g.MatingPlans, m)
+	}
+
+	for i, v := range r.array("synchronization", false) {
+		if f, ok := r.fields("synchronization", i+1, v, 7); ok {
+			g.Synchronization = append(g.Synchronization, Synchronization_t{
+				Herd:           f[0],
+				Class:          f[1],
+				AIDay:          r.parseInt("synchronization", i+1, f[2]),
+				ConceptionRate: r.parseFloat("synchronization", i+1, f[3]),
+				Cost:           r.parseFloat("synchronization", i+1, f[4]),
+				CleanupDay:     r.parseInt("synchronization", i+1, f[5]),
+				Sires:          f[6:]})
+		}
+	}
+	for i, v := range r.array("aiSires", false) {
+		if f, ok := r.fields("aiSires", i+1, v, 3); ok {
+			g.AISires = append(g.AISires, AISire_t{Name: f[0], Breed: f[1], SemenCost: r.parseFloat("aiSires", i+1, f[2])})
+		}
+	}
+	for i, v := range r.array("aiSireMerit", false) {
+		if f, ok := r.fields("aiSireMerit", i+1, v, 4); ok {
+			g.AISireMerit = append(g.AISireMerit, AISireMerit_t{Name: f[0], Merit: Merit_t{
+				Value:     r.parseFloat("aiSireMerit", i+1, f[3]),
+				Component: animal.Component_t{TraitName: f[1], Component: f[2]}}})
+		}
+	}
+
+	for i, v := range r.array("sexedSemen", false) {
+		if f, ok := r.fields("sexedSemen", i+1, v, 5); ok {
+			g.SexedSemen = append(g.SexedSemen, SexedSemen_t{
+				Herd:               f[0],
+				Class:              f[1],
+				Females:            r.parseFloat("sexedSemen", i+1, f[2]),
+				RelativeConception: r.parseFloat("sexedSemen", i+1, f[3]),
+				Cost:               r.parseFloat("sexedSemen", i+1, f[4])})
+		}
+	}
+	for i, v := range r.array("embryoTransfer", false) {
+		if f, ok := r.fields("embryoTransfer", i+1, v, 8); ok {
+			g.EmbryoTransfer = append(g.EmbryoTransfer, EmbryoTransfer_t{
+				Herd:          f[0],
+				Class:         f[1],
+				Proportion:    r.parseFloat("embryoTransfer", i+1, f[2]),
+				TransferDay:   r.parseInt("embryoTransfer", i+1, f[3]),
+				PregnancyRate: r.parseFloat("embryoTransfer", i+1, f[4]),
+				Cost:          r.parseFloat("embryoTransfer", i+1, f[5]),
+				CleanupDay:    r.parseInt("embryoTransfer", i+1, f[6]),
+				Embryos:       f[7:]})
+		}
+	}
+	for i, v := range r.array("embryos", false) {
+		if f, ok := r.fields("embryos", i+1, v, 3); ok {
+			g.Embryos = append(g.Embryos, Embryo_t{Name: f[0], Breed: f[1], Cost: r.parseFloat("embryos", i+1, f[2])})
+		}
+	}
+	for i, v := range r.array("embryoMerit", false) {
+		if f, ok := r.fields("embryoMerit", i+1, v, 4); ok {
+			g.EmbryoMerit = append(g.EmbryoMerit, AISireMerit_t{Name: f[0], Merit: Merit_t{
+				Value:     r.parseFloat("embryoMerit", i+1, f[3]),
+				Component: animal.Component_t{TraitName: f[1], Component: f[2]}}})
+		}
+	}
+
+	for i, v := range r.array("calvingEase", false) {
+		if f, ok := r.fields("calvingEase", i+1, v, 6); ok {
+			g.CalvingEase = append(g.CalvingEase, CalvingEase_t{
+				Herd:      f[0],
+				Category:  f[1],
+				CalfDeath: r.parseFloat("calvingEase", i+1, f[2]),
+				CowDeath:  r.parseFloat("calvingEase", i+1, f[3]),
+				Labor:     r.parseFloat("calvingEase", i+1, f[4]),
+				Vet:       r.parseFloat("calvingEase", i+1, f[5])})
+		}
+	}
+	for i, v := range r.array("calvingEaseFrequencies", false) {
+		if f, ok := r.fields("calvingEaseFrequencies", i+1, v, 3); ok {
+			c := CalvingEaseFrequency_t{Herd: f[0], Age: r.parseInt("calvingEaseFrequencies", i+1, f[1])}
+			for _, x := range f[2:] {
+				c.Frequencies = append(c.Frequencies, r.parseFloat("calvingEaseFrequencies", i+1, x))
+			}
+			g.CalvingEaseFrequencies = append(g.CalvingEaseFrequencies, c)
+		}
+	}
+
+	for i, v := range r.array("dystocia", false) {
+		if f, ok := r.fields("dystocia", i+1, v, 5); ok {
+			g.Dystocia = append(g.Dystocia, Dystocia_t{
+				Herd:            f[0],
+				Category:        f[1],
+				Age:             r.parseInt("dystocia", i+1, f[2]),
+				PostpartumDelay: r.parseInt("dystocia", i+1, f[3]),
+				WeaningPenalty:  r.parseFloat("dystocia", i+1, f[4])})
+		}
+	}
+
+	g.HeiferSelection = r.str("heiferSelection")
+	g.HeiferSelectionTrait = r.str("heiferSelectionTrait")
+	g.HeiferIndexWeights = r.merit("heiferIndexWeights", false)
+	g.HeiferIndexAccuracy = 1
+	if _, ok := param["heiferIndexAccuracy"]; ok {
+		g.HeiferIndexAccuracy = r.number("heiferIndexAccuracy", false)
+	}
+	g.HeiferSurplus = r.number("heiferSurplus", false)
+
+	g.GenomicTestAccuracy = r.number("genomicTestAccuracy", false)
+	g.GenomicTestCost = r.number("genomicTestCost", false)
+
+	g.InbreedingDepression = r.merit("inbreedingDepression", false)
+
+	for i, v := range r.array("genome", false) {
+		if f, ok := r.fields("genome", i+1, v, 4); ok {
+			g.Genome = append(g.Genome, Chromosomes_t{
+				Number:  r.parseInt("genome", i+1, f[0]),
+				Length:  r.parseFloat("genome", i+1, f[1]),
+				Markers: r.parseInt("genome", i+1, f[2]),
+				QTL:     r.parseInt("genome", i+1, f[3])})
+		}
+	}
+
+	for i, v := range r.array("evaluationTraits", false) {
+		t, ok := v.(string)
+		if !ok {
+			r.fail("evaluationTraits", i+1, "%v must be a trait name", v)
+			continue
+		}
+		g.EvaluationTraits = append(g.EvaluationTraits, strings.TrimSpace(t))
+	}
+
+	for i, v := range r.array("TraitAgeEffects", true) {
+		if f, ok := r.fields("TraitAgeEffects", i+1, v, 3); ok {
+			g.TraitAgeEffects = append(g.TraitAgeEffects, TraitAgeEffect_t{
+				Trait: f[0],
+				Slope: r.parseFloat("TraitAgeEffects", i+1, f[1]),
+				Age:   r.parseFloat("TraitAgeEffects", i+1, f[2])})
+		}
+	}
+
+	for i, v := range r.array("BreedTraitSexAod", true) {
+		if f, ok := r.fields("BreedTraitSexAod", i+1, v, 4); ok {
+			b := BreedTraitSexAod_t{Breed: f[0], Trait: f[1], Sex: f[2]}
+			for k := 3; k < len(f); k++ {
+				b.Effect = append(b.Effect, r.parseFloat("BreedTraitSexAod", i+1, f[k]))
+			}
+			g.BreedTraitSexAod = append(g.BreedTraitSexAod, b)
+		}
+	}
+
+	for i, v := range r.array("HeterosisCodes", true) {
+		if f, ok := r.fields("HeterosisCodes", i+1, v, 2); ok {
+			g.HeterosisCodes = append(g.HeterosisCodes, HeterosisCode_t{Breed: f[0], Code: f[1]})
+		}
+	}
+
+	hv := r.array("HeterosisValues", true)
+	for i, v := range hv {
+		f, ok := r.fields("HeterosisValues", i+1, v, 3)
+		if !ok {
+			continue
+		}
+		if i == 0 { // 1st line is the header of cross classes
+			g.HeterosisCrossClasses = f[2:]
+			continue
+		}
+		if len(f)-2 != len(g.HeterosisCrossClasses) {
+			r.fail("HeterosisValues", i+1, "has %d values for %d cross classes", len(f)-2, len(g.HeterosisCrossClasses))
+		}
+		h := HeterosisValue_t{Component: animal.Component_t{TraitName: f[0], Component: f[1]}}
+		for k := 2; k < len(f) && k-2 < len(g.HeterosisCrossClasses); k++ {
+			h.Values = append(h.Values, r.parseFloat("HeterosisValues", i+1, f[k]))
+		}
+		g.HeterosisValues = append(g.HeterosisValues, h)
+	}
+
+	be := r.array("BreedEffects", true)
+	for i, v := range be {
+		f, ok := r.fields("BreedEffects", i+1, v, 4)
+		if !ok {
+			continue
+		}
+		if i == 0 { // 1st line is the header of breeds
+			g.Breeds = f[3:]
+			continue
+		}
+		if len(f)-3 != len(g.Breeds) {
+			r.fail("BreedEffects", i+1, "has %d values for %d breeds", len(f)-3, len(g.Breeds))
+		}
+		b := BreedEffect_t{Component: animal.Component_t{TraitName: f[0], Component: f[1]}, CowOrCalf: f[2]}
+		if b.CowOrCalf != "Cow" && b.CowOrCalf != "Calf" {
+			r.fail("BreedEffects", i+1, "type \"%s\" must be Cow or Calf", b.CowOrCalf)
+		}
+		for k := 3; k < len(f) && k-3 < len(g.Breeds); k++ {
+			b.Effects = append(b.Effects, r.parseFloat("BreedEffects", i+1, f[k]))
+		}
+		g.BreedEffects = append(g.BreedEffects, b)
+	}
+
+	g.CowHerdBreedComposition = r.breedComposition("CowHerdBreedComposition")
+	g.BullBatteryBreedComposition = r.breedComposition("BullBatteryBreedComposition")
+	g.CurrentCalvesBreedComposition = r.breedComposition("CurrentCalvesBreedComposition")
+
+	g.CowAgeFileName = r.str("cowagefilename")
+	g.RecordsDump = r.str("recordsdump")
+	g.BreedingRecordsDump = r.str("breedingrecordsdump")
+	if a := r.array("phenotypeFile", false); len(a) > 0 {
+		if f, ok := r.fields("phenotypeFile", 1, a[0], 2); ok {
+			g.PhenotypeFile = f[0]
+			g.PhenotypeOutputTrait = f[1]
+		}
+	}
+	g.StayPhenotypeFile = r.str("stayPhenotypeFile")
+	g.HPPhenotypeFile = r.str("HPPhenotypeFile")
+	g.CDPhenotypeFile = r.str("CDPhenotypeFile")
+	g.CarcassPhenotypeFile = r.str("CarcassPhenotypeFile")
+
+	return g, r.err()
+}
+
+// Read a table of percent, "Breed, percent, ..." pairs
+func (r *reader) breedComposition(key string) (table []BreedComposition_t) {
+	a := r.array(key, true)
+	for i := 0; i < len(a); i = i + 2 {
+		var b BreedComposition_t
+		b.Percent = r.value(key, i+1, a[i])
+		if i+1 >= len(a) {
+			r.fail(key, i+1, "percent has no \"Breed, percent\" list after it")
+			break
+		}
+		s, ok := r.fields(key, i+2, a[i+1], 2)
+		if !ok {
+			continue
+		}
+		if len(s)%2 != 0 {
+			r.fail(key, i+2, "breeds and percents must be in pairs")
+		}
+		for j := 0; j+1 < len(s); j = j + 2 {
+			b.Breeds = append(b.Breeds, BreedPercent_t{Breed: s[j], Percent: r.parseFloat(key, i+2, s[j+1])})
+		}
+		table = append(table, b)
+	}
+	return table
+}
@@ -0,0 +1,131 @@
+// indexParm
+//
+// The parts of the economic index (indexParm) hjson the model is checked against
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package config
+
+import (
+	"github.com/blgolden/iGenDecModel/iGenDec/animal"
+)
+
+// IndexParm holds the keys of an indexParm hjson that must agree with the genParm
+type IndexParm struct {
+	SaleEndpoint    string // weaning, background, fatcattle or slaughtercattle
+	IndexTerminal   bool
+	IndexComponents []animal.Component_t
+	PriceTable      []PriceBand_t // traitSexPricePerCwt
+
+	DiscountRate        float64         // Yearly, e.g. 0.05
+	AumCost             []float64       // $ per AUM by month of the year
+	BackgroundAumCost   []float64       // By month, for the endpoints after weaning
+	FeedlotFeedCost     float64         // $ per lb of feed, for fatcattle and slaughtercattle
+	GridPremiums        []GridPremium_t // Optional slaughtercattle grid
+	ProportionInProgram float64         // Of the calves that may qualify for a grid program - e.g., CHB, CAB
+}
+
+// A row of gridPremiums: "Quality grade, $/cwt for yield grades 1 to 5"
+type GridPremium_t struct {
+	QualityGrade string
+	PerCwt       [5]float64
+}
+
+// A row of traitSexPricePerCwt: "Trait, Sex, min weight, max weight, $/cwt"
+type PriceBand_t struct {
+	Trait       string
+	Sex         string
+	MinWt       float64 // If the weight of the animals is >=
+	MaxWt       float64 // and the weight of the animals is <
+	PricePerCwt float64
+}
+
+// The price table trait of the calves sold at each sale endpoint
+var EndpointPriceTrait = map[string]string{
+	"weaning":         "WW",
+	"background":      "BG",
+	"fatcattle":       "FC",
+	"slaughtercattle": "SC",
+}
+
+// Convert the index hjson key:value pairs.  Like NewGenParm every bad or
+// missing value is reported.
+func NewIndexParm(param map[string]interface{}) (*IndexParm, error) {
+
+	r := &reader{param: param}
+	x := new(IndexParm)
+
+	x.SaleEndpoint = r.str("saleEndpoint")
+	if _, ok := EndpointPriceTrait[x.SaleEndpoint]; !ok {
+		r.fail("saleEndpoint", 0, "\"%s\" must be weaning, background, fatcattle or slaughtercattle", x.SaleEndpoint)
+	}
+	x.IndexTerminal = r.boolean("indexTerminal", true)
+
+	for i, v := range r.array("indexComponents", true) {
+		if f, ok := r.fields("indexComponents", i+1, v, 2); ok {
+			x.IndexComponents = append(x.IndexComponents, animal.Component_t{TraitName: f[0], Component: f[1]})
+		}
+	}
+
+	for i, v := range r.array("traitSexPricePerCwt", true) {
+		if f, ok := r.fields("traitSexPricePerCwt", i+1, v, 5); ok {
+			x.PriceTable = append(x.PriceTable, PriceBand_t{
+				Trait:       f[0],
+				Sex:         f[1],
+				MinWt:       r.parseFloat("traitSexPricePerCwt", i+1, f[2]),
+				MaxWt:       r.parseFloat("traitSexPricePerCwt", i+1, f[3]),
+				PricePerCwt: r.parseFloat("traitSexPricePerCwt", i+1, f[4])})
+		}
+	}
+
+	x.DiscountRate = r.number("discountRate", true)
+	x.AumCost = r.months("aumCost", true)
+	x.BackgroundAumCost = r.months("backgroundAumCost", x.SaleEndpoint != "weaning")
+	x.FeedlotFeedCost = r.number("feedlotFeedCost", x.SaleEndpoint == "fatcattle" || x.SaleEndpoint == "slaughtercattle")
+
+	for i, v := range r.array("gridPremiums", false) {
+		if f, ok := r.fields("gridPremiums", i+1, v, 6); ok {
+			g := GridPremium_t{QualityGrade: f[0]}
+			for yg := range g.PerCwt {
+				g.PerCwt[yg] = r.parseFloat("gridPremiums", i+1, f[yg+1])
+			}
+			x.GridPremiums = append(x.GridPremiums, g)
+		}
+	}
+	x.ProportionInProgram = r.number("proportionInProgram", false)
+
+	return x, r.err()
+}
+
+// A number for each month of the year
+func (r *reader) months(key string, required bool) []float64 {
+	a := r.array(key, required)
+	if a == nil {
+		return nil
+	}
+	if len(a) != 12 {
+		r.fail(key, 0, "has %d values, expected one for each of the 12 months", len(a))
+	}
+	var m []float64
+	for i, v := range a {
+		m = append(m, r.value(key, i+1, v))
+	}
+	return m
+}
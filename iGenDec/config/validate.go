@@ -0,0 +1,850 @@
+// validate
+//
+// Checks that the parameter files agree with themselves and each other
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package config
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/blgolden/iGenDecModel/iGenDec/animal"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Sale weights are expected to have a price from 0 up to this
+const MaxPricedWeight = 9999.0
+
+// Cull cows are priced at this weight, their price per pound is all that is used
+const CullPricedWeight = 1000.0
+
+// Check a genParm and, if not nil, an indexParm for everything that would
+// otherwise fail part way through a run or quietly give a nonsense MEV.
+// Every problem is returned rather than the first.
+func Validate(g *GenParm, x *IndexParm) ParamErrors {
+
+	r := new(reader)
+
+	r.checkComponents(g)
+	r.checkCovariance("genetic", g.Genetic, len(g.Components))
+	r.checkCovariance("residual", g.Residual, len(g.Traits))
+	r.checkCompositions("CowHerdBreedComposition", g.CowHerdBreedComposition)
+	r.checkCompositions("BullBatteryBreedComposition", g.BullBatteryBreedComposition)
+	r.checkCompositions("CurrentCalvesBreedComposition", g.CurrentCalvesBreedComposition)
+	r.checkHeterosis(g)
+	r.checkAgeDist(g)
+	r.checkBullManagement(g)
+	r.checkMatingPlans(g)
+	r.checkSynchronization(g)
+	r.checkCalvingEase(g)
+	r.checkGenome(g)
+	r.checkHeiferSelection(g)
+	r.checkEvaluation(g)
+	r.checkInbreedingDepression(g)
+
+	if x != nil {
+		r.checkIndexComponents(g, x)
+		r.checkPriceTable(x)
+		r.checkIndexCosts(x)
+	}
+
+	return r.errs
+}
+
+// The foundation needs bulls, and bull management rows must name a herd and
+// keep at least one bull
+func (r *reader) checkBullManagement(g *GenParm) {
+	if g.NFoundationBulls < 1 {
+		r.fail("nFoundationBulls", 0, "%d must be at least 1", g.NFoundationBulls)
+	}
+	herds := make(map[string]bool)
+	for _, h := range g.Herds {
+		herds[h.Name] = true
+	}
+	for i, b := range g.BullManagement {
+		if !herds[b.Herd] {
+			r.fail("bullManagement", i+1, "herd %s is not in herds", b.Herd)
+		}
+		if b.ServiceLife < 1 {
+			r.fail("bullManagement", i+1, "service life %d must be at least 1 year", b.ServiceLife)
+		}
+		if b.CowsPerBull <= 0 {
+			r.fail("bullManagement", i+1, "cows per bull %v must be more than 0", b.CowsPerBull)
+		}
+		if b.InjuryRate < 0 || b.InjuryRate >= 1 {
+			r.fail("bullManagement", i+1, "injury rate %v must be from 0 up to 1", b.InjuryRate)
+		}
+	}
+
+	components := make(map[animal.Component_t]bool)
+	for _, c := range g.Components {
+		components[c] = true
+	}
+	for i, m := range g.GeneticTrendBulls {
+		if !components[m.Component] {
+			r.fail("geneticTrendBulls", 2*i+2, "%s,%s is not in Components", m.Component.TraitName, m.Component.Component)
+		}
+	}
+}
+
+// A mating plan names a herd, breeds in BreedEffects and enough foundation
+// bulls for one of each of its sire breeds
+func (r *reader) checkMatingPlans(g *GenParm) {
+	herds := make(map[string]bool)
+	for _, h := range g.Herds {
+		herds[h.Name] = true
+	}
+	breeds := make(map[string]bool)
+	for _, b := range g.Breeds {
+		breeds[b] = true
+	}
+
+	planned := make(map[string]bool)
+	for i, m := range g.MatingPlans {
+		known := false
+		for _, p := range animal.MatingPlans {
+			known = known || p == m.Plan
+		}
+		if !known {
+			r.fail("matingPlans", i+1, "%s must be one of %s", m.Plan, strings.Join(animal.MatingPlans, ", "))
+			continue
+		}
+		if !herds[m.Herd] {
+			r.fail("matingPlans", i+1, "herd %s is not in herds", m.Herd)
+		}
+		if planned[m.Herd] {
+			r.fail("matingPlans", i+1, "herd %s has more than one mating plan", m.Herd)
+		}
+		planned[m.Herd] = true
+
+		sires := 0
+		if m.Plan != animal.PlanTerminal {
+			if len(m.RotationBreeds) < 2 || len(m.RotationBreeds) > 3 {
+				r.fail("matingPlans", i+1, "a rotation must have 2 or 3 breeds, not %d", len(m.RotationBreeds))
+			}
+			sires = len(m.RotationBreeds)
+		}
+		if m.Plan != animal.PlanRotation {
+			if m.TerminalProportion <= 0 || m.TerminalProportion > 1 {
+				r.fail("matingPlans", i+1, "the proportion of cows bred to terminal sires %v must be more than 0 and no more than 1", m.TerminalProportion)
+			}
+			sires++
+			if m.Plan == animal.PlanTerminal {
+				sires++ // and the bull battery
+			}
+		}
+
+		seen := make(map[string]bool)
+		for _, b := range append([]string{m.TerminalBreed}, m.RotationBreeds...) {
+			if b == "" {
+				continue
+			}
+			if !breeds[b] {
+				r.fail("matingPlans", i+1, "breed %s is not in BreedEffects", b)
+			}
+			if seen[b] {
+				r.fail("matingPlans", i+1, "breed %s is used more than once", b)
+			}
+			seen[b] = true
+		}
+
+		if g.NFoundationBulls < sires {
+			r.fail("nFoundationBulls", 0, "%d is fewer than the %d sire breeds of the %s herd's mating plan", g.NFoundationBulls, sires, m.Herd)
+		}
+	}
+}
+
+// A protocol or embryo transfer names a herd and a class, is timed within the
+// breeding season and uses sires in aiSires or lines in embryos.  Sexed semen
+// needs a protocol to use it.
+func (r *reader) checkSynchronization(g *GenParm) {
+	seasons := make(map[string]int)
+	for _, h := range g.Herds {
+		seasons[h.Name] = h.BreedingSeasonLen
+	}
+	breeds := make(map[string]bool)
+	for _, b := range g.Breeds {
+		breeds[b] = true
+	}
+	components := make(map[animal.Component_t]bool)
+	for _, c := range g.Components {
+		components[c] = true
+	}
+
+	sires := make(map[string]bool)
+	for i, s := range g.AISires {
+		if sires[s.Name] {
+			r.fail("aiSires", i+1, "sire %s is listed more than once", s.Name)
+		}
+		sires[s.Name] = true
+		if !breeds[s.Breed] {
+			r.fail("aiSires", i+1, "breed %s is not in BreedEffects", s.Breed)
+		}
+		if s.SemenCost < 0 {
+			r.fail("aiSires", i+1, "semen cost %v must not be negative", s.SemenCost)
+		}
+	}
+	for i, m := range g.AISireMerit {
+		if !sires[m.Name] {
+			r.fail("aiSireMerit", i+1, "sire %s is not in aiSires", m.Name)
+		}
+		if !components[m.Merit.Component] {
+			r.fail("aiSireMerit", i+1, "%s,%s is not in Components", m.Merit.Component.TraitName, m.Merit.Component.Component)
+		}
+	}
+
+	protocols := make(map[string]bool)
+	for i, p := range g.Synchronization {
+		season, ok := seasons[p.Herd]
+		if !ok {
+			r.fail("synchronization", i+1, "herd %s is not in herds", p.Herd)
+		}
+		known := false
+		for _, c := range animal.SyncClasses {
+			known = known || c == p.Class
+		}
+		if !known {
+			r.fail("synchronization", i+1, "%s must be one of %s", p.Class, strings.Join(animal.SyncClasses, ", "))
+		}
+		if protocols[p.Herd+","+p.Class] {
+			r.fail("synchronization", i+1, "the %s of herd %s have more than one protocol", p.Class, p.Herd)
+		}
+		protocols[p.Herd+","+p.Class] = true
+		if ok && (p.AIDay < 0 || p.AIDay >= season) {
+			r.fail("synchronization", i+1, "AI day %d must be within the %d day breeding season", p.AIDay, season)
+		}
+		if p.ConceptionRate < 0 || p.ConceptionRate > 1 {
+			r.fail("synchronization", i+1, "AI conception rate %v must be from 0 to 1", p.ConceptionRate)
+		}
+		if p.Cost < 0 {
+			r.fail("synchronization", i+1, "cost %v must not be negative", p.Cost)
+		}
+		if p.CleanupDay <= p.AIDay {
+			r.fail("synchronization", i+1, "cleanup day %d must be after the AI day %d", p.CleanupDay, p.AIDay)
+		}
+		for _, s := range p.Sires {
+			if !sires[s] {
+				r.fail("synchronization", i+1, "sire %s is not in aiSires", s)
+			}
+		}
+	}
+
+	sexed := make(map[string]bool)
+	for i, x := range g.SexedSemen {
+		if !protocols[x.Herd+","+x.Class] {
+			r.fail("sexedSemen", i+1, "the %s of herd %s have no synchronization protocol", x.Class, x.Herd)
+		}
+		if sexed[x.Herd+","+x.Class] {
+			r.fail("sexedSemen", i+1, "the %s of herd %s have more than one row", x.Class, x.Herd)
+		}
+		sexed[x.Herd+","+x.Class] = true
+		if x.Females <= 0 || x.Females >= 1 {
+			r.fail("sexedSemen", i+1, "proportion of heifer calves %v must be between 0 and 1", x.Females)
+		}
+		if x.RelativeConception <= 0 || x.RelativeConception > 1 {
+			r.fail("sexedSemen", i+1, "relative conception rate %v must be more than 0 and no more than 1", x.RelativeConception)
+		}
+		if x.Cost < 0 {
+			r.fail("sexedSemen", i+1, "cost %v must not be negative", x.Cost)
+		}
+	}
+
+	embryos := make(map[string]bool)
+	for i, e := range g.Embryos {
+		if embryos[e.Name] {
+			r.fail("embryos", i+1, "embryo line %s is listed more than once", e.Name)
+		}
+		embryos[e.Name] = true
+		if !breeds[e.Breed] {
+			r.fail("embryos", i+1, "breed %s is not in BreedEffects", e.Breed)
+		}
+		if e.Cost < 0 {
+			r.fail("embryos", i+1, "cost %v must not be negative", e.Cost)
+		}
+	}
+	for i, m := range g.EmbryoMerit {
+		if !embryos[m.Name] {
+			r.fail("embryoMerit", i+1, "embryo line %s is not in embryos", m.Name)
+		}
+		if !components[m.Merit.Component] {
+			r.fail("embryoMerit", i+1, "%s,%s is not in Components", m.Merit.Component.TraitName, m.Merit.Component.Component)
+		}
+	}
+
+	transfers := make(map[string]bool)
+	for i, e := range g.EmbryoTransfer {
+		season, ok := seasons[e.Herd]
+		if !ok {
+			r.fail("embryoTransfer", i+1, "herd %s is not in herds", e.Herd)
+		}
+		known := false
+		for _, c := range animal.SyncClasses {
+			known = known || c == e.Class
+		}
+		if !known {
+			r.fail("embryoTransfer", i+1, "%s must be one of %s", e.Class, strings.Join(animal.SyncClasses, ", "))
+		}
+		if transfers[e.Herd+","+e.Class] {
+			r.fail("embryoTransfer", i+1, "the %s of herd %s have more than one row", e.Class, e.Herd)
+		}
+		transfers[e.Herd+","+e.Class] = true
+		if e.Proportion <= 0 || e.Proportion > 1 {
+			r.fail("embryoTransfer", i+1, "proportion of recipients %v must be more than 0 and no more than 1", e.Proportion)
+		}
+		if ok && (e.TransferDay < 0 || e.TransferDay >= season) {
+			r.fail("embryoTransfer", i+1, "transfer day %d must be within the %d day breeding season", e.TransferDay, season)
+		}
+		if e.PregnancyRate < 0 || e.PregnancyRate > 1 {
+			r.fail("embryoTransfer", i+1, "pregnancy rate %v must be from 0 to 1", e.PregnancyRate)
+		}
+		if e.Cost < 0 {
+			r.fail("embryoTransfer", i+1, "cost %v must not be negative", e.Cost)
+		}
+		if e.CleanupDay <= e.TransferDay {
+			r.fail("embryoTransfer", i+1, "cleanup day %d must be after the transfer day %d", e.CleanupDay, e.TransferDay)
+		}
+		for _, name := range e.Embryos {
+			if !embryos[name] {
+				r.fail("embryoTransfer", i+1, "embryo line %s is not in embryos", name)
+			}
+		}
+	}
+}
+
+// Calving ease categories name a herd and each of their herds has frequencies
+// for every category that sum to 1 at each dam age.  Dystocia effects are for
+// one of the categories.
+func (r *reader) checkCalvingEase(g *GenParm) {
+	herds := make(map[string]bool)
+	for _, h := range g.Herds {
+		herds[h.Name] = true
+	}
+
+	categories := make(map[string]int)
+	seen := make(map[string]bool)
+	for i, c := range g.CalvingEase {
+		if !herds[c.Herd] {
+			r.fail("calvingEase", i+1, "herd %s is not in herds", c.Herd)
+		}
+		if seen[c.Herd+","+c.Category] {
+			r.fail("calvingEase", i+1, "category %s of herd %s is listed more than once", c.Category, c.Herd)
+		}
+		seen[c.Herd+","+c.Category] = true
+		categories[c.Herd]++
+		if c.CalfDeath < 0 || c.CalfDeath > 1 {
+			r.fail("calvingEase", i+1, "calf death rate %v must be from 0 to 1", c.CalfDeath)
+		}
+		if c.CowDeath < 0 || c.CowDeath > 1 {
+			r.fail("calvingEase", i+1, "cow death rate %v must be from 0 to 1", c.CowDeath)
+		}
+		if c.Labor < 0 || c.Vet < 0 {
+			r.fail("calvingEase", i+1, "labor and veterinary costs must not be negative")
+		}
+	}
+
+	ages := make(map[string]bool)
+	given := make(map[string]bool)
+	for i, f := range g.CalvingEaseFrequencies {
+		n, ok := categories[f.Herd]
+		if !ok {
+			r.fail("calvingEaseFrequencies", i+1, "herd %s has no calvingEase categories", f.Herd)
+			continue
+		}
+		if ages[fmt.Sprint(f.Herd, ",", f.Age)] {
+			r.fail("calvingEaseFrequencies", i+1, "dam age %d of herd %s is listed more than once", f.Age, f.Herd)
+		}
+		ages[fmt.Sprint(f.Herd, ",", f.Age)] = true
+		given[f.Herd] = true
+		if f.Age < 2 {
+			r.fail("calvingEaseFrequencies", i+1, "dam age %d must be at least 2", f.Age)
+		}
+		if len(f.Frequencies) != n {
+			r.fail("calvingEaseFrequencies", i+1, "%d frequencies for the %d calvingEase categories of herd %s", len(f.Frequencies), n, f.Herd)
+		}
+		var sum float64
+		for _, p := range f.Frequencies {
+			if p < 0 {
+				r.fail("calvingEaseFrequencies", i+1, "frequency %v must not be negative", p)
+			}
+			sum += p
+		}
+		if math.Abs(sum-1) > .001 {
+			r.fail("calvingEaseFrequencies", i+1, "frequencies sum to %v, not 1", sum)
+		}
+	}
+	for i, c := range g.CalvingEase {
+		if herds[c.Herd] && !given[c.Herd] {
+			r.fail("calvingEase", i+1, "herd %s has no calvingEaseFrequencies", c.Herd)
+			given[c.Herd] = true // Once
+		}
+	}
+
+	effects := make(map[string]bool)
+	for i, d := range g.Dystocia {
+		if !seen[d.Herd+","+d.Category] {
+			r.fail("dystocia", i+1, "%s is not a calvingEase category of herd %s", d.Category, d.Herd)
+		}
+		if effects[fmt.Sprint(d.Herd, ",", d.Category, ",", d.Age)] {
+			r.fail("dystocia", i+1, "dam age %d of %s in herd %s is listed more than once", d.Age, d.Category, d.Herd)
+		}
+		effects[fmt.Sprint(d.Herd, ",", d.Category, ",", d.Age)] = true
+		if d.Age < 2 {
+			r.fail("dystocia", i+1, "dam age %d must be at least 2", d.Age)
+		}
+		if d.PostpartumDelay < 0 {
+			r.fail("dystocia", i+1, "postpartum delay %d must not be negative", d.PostpartumDelay)
+		}
+		if d.WeaningPenalty < 0 {
+			r.fail("dystocia", i+1, "weaning weight penalty %v must not be negative", d.WeaningPenalty)
+		}
+	}
+}
+
+// A genome has enough QTL for the QTL effects to make the genetic covariance matrix
+func (r *reader) checkGenome(g *GenParm) {
+	qtl := 0
+	for i, c := range g.Genome {
+		if c.Number < 1 {
+			r.fail("genome", i+1, "number of chromosomes %d must be at least 1", c.Number)
+		}
+		if c.Length <= 0 {
+			r.fail("genome", i+1, "length %v Morgans must be more than 0", c.Length)
+		}
+		if c.Markers < 0 || c.QTL < 0 {
+			r.fail("genome", i+1, "markers and QTL must not be negative")
+		}
+		qtl += c.Number * c.QTL
+	}
+	if len(g.Genome) > 0 && qtl < len(g.Components) {
+		r.fail("genome", 0, "%d QTL are fewer than the %d genetic Components", qtl, len(g.Components))
+	}
+}
+
+// The heifer selection policy needs its trait or index weights
+func (r *reader) checkHeiferSelection(g *GenParm) {
+	policy := g.HeiferSelection
+	if policy == "" {
+		policy = animal.SelectFirst
+	}
+	known := false
+	for _, p := range animal.SelectionPolicies {
+		known = known || p == policy
+	}
+	if !known {
+		r.fail("heiferSelection", 0, "%s must be one of %s", policy, strings.Join(animal.SelectionPolicies, ", "))
+	}
+
+	if policy == animal.SelectPhenotype {
+		trait := strings.TrimPrefix(g.HeiferSelectionTrait, "-")
+		found := false
+		for _, t := range g.Traits {
+			found = found || t.Name == trait
+		}
+		if !found {
+			r.fail("heiferSelectionTrait", 0, "trait \"%s\" is not in Traits", trait)
+		}
+	}
+
+	if policy == animal.SelectBreedingValue || policy == animal.SelectIndex || policy == animal.SelectEbv {
+		if len(g.HeiferIndexWeights) == 0 {
+			r.fail("heiferIndexWeights", 0, "key not found, the %s policy needs it", policy)
+		}
+		components := make(map[animal.Component_t]bool)
+		for _, c := range g.Components {
+			components[c] = true
+		}
+		for i, m := range g.HeiferIndexWeights {
+			if !components[m.Component] {
+				r.fail("heiferIndexWeights", 2*i+2, "%s,%s is not in Components", m.Component.TraitName, m.Component.Component)
+			}
+		}
+	}
+	if g.HeiferIndexAccuracy <= 0 || g.HeiferIndexAccuracy > 1 {
+		r.fail("heiferIndexAccuracy", 0, "%v must be more than 0 and no more than 1", g.HeiferIndexAccuracy)
+	}
+
+	if g.HeiferSurplus < 0 {
+		r.fail("heiferSurplus", 0, "%v must not be negative", g.HeiferSurplus)
+	}
+	if g.HeiferSurplus > 0 && policy == animal.SelectFirst {
+		r.fail("heiferSurplus", 0, "needs a heiferSelection policy to rank which heifers are culled")
+	}
+
+	if g.GenomicTestAccuracy != 0 {
+		if policy != animal.SelectIndex {
+			r.fail("genomicTestAccuracy", 0, "needs the %s heiferSelection policy", animal.SelectIndex)
+		}
+		if g.GenomicTestAccuracy < g.HeiferIndexAccuracy || g.GenomicTestAccuracy > 1 {
+			r.fail("genomicTestAccuracy", 0, "%v must be at least heiferIndexAccuracy and no more than 1", g.GenomicTestAccuracy)
+		}
+	} else if g.GenomicTestCost != 0 {
+		r.fail("genomicTestCost", 0, "needs genomicTestAccuracy")
+	}
+	if g.GenomicTestCost < 0 {
+		r.fail("genomicTestCost", 0, "%v must not be negative", g.GenomicTestCost)
+	}
+}
+
+// Evaluated traits must be recorded on calves and have a direct component.
+// The ebv policy can only weight the components that are evaluated.
+func (r *reader) checkEvaluation(g *GenParm) {
+	components := make(map[animal.Component_t]bool)
+	for _, c := range g.Components {
+		components[c] = true
+	}
+
+	evaluated := make(map[string]bool)
+	for i, t := range g.EvaluationTraits {
+		recorded := false
+		for _, e := range animal.EvaluationTraits {
+			recorded = recorded || e == t
+		}
+		switch {
+		case !recorded:
+			r.fail("evaluationTraits", i+1, "%s must be one of %s", t, strings.Join(animal.EvaluationTraits, ", "))
+		case evaluated[t]:
+			r.fail("evaluationTraits", i+1, "%s is listed more than once", t)
+		case !components[animal.Component_t{TraitName: t, Component: "D"}]:
+			r.fail("evaluationTraits", i+1, "%s,D is not in Components", t)
+		}
+		evaluated[t] = true
+	}
+
+	if g.HeiferSelection != animal.SelectEbv {
+		return
+	}
+	if len(g.EvaluationTraits) == 0 {
+		r.fail("evaluationTraits", 0, "key not found, the %s heifer selection policy needs it", animal.SelectEbv)
+	}
+	for i, m := range g.HeiferIndexWeights {
+		if components[m.Component] && !evaluated[m.Component.TraitName] {
+			r.fail("heiferIndexWeights", 2*i+2, "%s,%s is not evaluated, add %s to evaluationTraits", m.Component.TraitName, m.Component.Component, m.Component.TraitName)
+		}
+	}
+}
+
+// Inbreeding depression is of a trait in Traits through the animal, D, or its dam, M
+func (r *reader) checkInbreedingDepression(g *GenParm) {
+	traits := make(map[string]bool)
+	for _, t := range g.Traits {
+		traits[t.Name] = true
+	}
+	seen := make(map[animal.Component_t]bool)
+	for i, m := range g.InbreedingDepression {
+		c := m.Component
+		switch {
+		case !traits[c.TraitName]:
+			r.fail("inbreedingDepression", 2*i+2, "trait \"%s\" is not in Traits", c.TraitName)
+		case c.Component != "D" && c.Component != "M":
+			r.fail("inbreedingDepression", 2*i+2, "%s,%s must be D or M", c.TraitName, c.Component)
+		case seen[c]:
+			r.fail("inbreedingDepression", 2*i+2, "%s,%s is listed more than once", c.TraitName, c.Component)
+		}
+		seen[c] = true
+	}
+}
+
+// Every genetic component must be of a trait in Traits
+func (r *reader) checkComponents(g *GenParm) {
+	traits := make(map[string]bool)
+	for _, t := range g.Traits {
+		traits[t.Name] = true
+	}
+	for i, c := range g.Components {
+		if !traits[c.TraitName] {
+			r.fail("Components", i+1, "trait %s is not in Traits", c.TraitName)
+		}
+		if c.Component != "D" && c.Component != "M" {
+			r.fail("Components", i+1, "component %s must be D or M", c.Component)
+		}
+	}
+}
+
+// A covariance matrix must be n x n, symmetric and positive definite
+func (r *reader) checkCovariance(key string, v []float64, n int) {
+	if len(v) != n*n {
+		r.fail(key, 0, "has %d values, a %d x %d matrix needs %d", len(v), n, n, n*n)
+		return
+	}
+	if n == 0 {
+		return
+	}
+
+	symmetric := true
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			a, b := v[i*n+j], v[j*n+i]
+			if math.Abs(a-b) > 1e-9*math.Max(1, math.Max(math.Abs(a), math.Abs(b))) {
+				r.fail(key, i+1, "column %d is %v but row %d column %d is %v, the matrix is not symmetric", j+1, a, j+1, i+1, b)
+				symmetric = false
+			}
+		}
+	}
+	if !symmetric {
+		return
+	}
+
+	var chol mat.Cholesky
+	if !chol.Factorize(mat.NewSymDense(n, v)) {
+		r.fail(key, 0, "the matrix is not positive definite")
+	}
+}
+
+// Each composition must add to 100% and so must the percent of animals
+func (r *reader) checkCompositions(key string, table []BreedComposition_t) {
+	var total float64
+	for i, b := range table {
+		total += b.Percent
+		var sum float64
+		for _, bp := range b.Breeds {
+			sum += bp.Percent
+		}
+		if math.Abs(sum-100) > .01 {
+			r.fail(key, 2*i+2, "breed percents add to %g, not 100", sum)
+		}
+	}
+	if len(table) > 0 && math.Abs(total-100) > .01 {
+		r.fail(key, 0, "percent of animals adds to %g, not 100", total)
+	}
+}
+
+// Every breed needs a heterosis code and every cross of those codes a heterosis value
+func (r *reader) checkHeterosis(g *GenParm) {
+	codes := make(map[string]string)
+	for _, h := range g.HeterosisCodes {
+		codes[h.Breed] = h.Code
+	}
+
+	// Every breed named anywhere in the model
+	var breeds []string
+	seen := make(map[string]bool)
+	add := func(b string) {
+		if !seen[b] {
+			seen[b] = true
+			breeds = append(breeds, b)
+		}
+	}
+	for _, b := range g.Breeds {
+		add(b)
+	}
+	for _, table := range [][]BreedComposition_t{g.CowHerdBreedComposition, g.BullBatteryBreedComposition, g.CurrentCalvesBreedComposition} {
+		for _, b := range table {
+			for _, bp := range b.Breeds {
+				add(bp.Breed)
+			}
+		}
+	}
+
+	for _, b := range breeds {
+		if _, ok := codes[b]; !ok {
+			r.fail("HeterosisCodes", 0, "breed %s has no heterosis code", b)
+		}
+		if !contains(g.Breeds, b) {
+			r.fail("BreedEffects", 0, "breed %s has no breed effects column", b)
+		}
+	}
+
+	classes := make(map[string]bool)
+	for _, c := range g.HeterosisCrossClasses {
+		classes[c] = true
+	}
+	for i, a := range breeds {
+		for _, b := range breeds[i+1:] {
+			ca, cb := codes[a], codes[b]
+			if ca == "" || cb == "" {
+				continue
+			}
+			if !classes[ca+"x"+cb] && !classes[cb+"x"+ca] {
+				r.fail("HeterosisValues", 1, "no %sx%s cross class for %s x %s", ca, cb, a, b)
+				classes[ca+"x"+cb] = true // Only report it once
+			}
+		}
+	}
+
+	// Every trait is given a direct heterosis effect
+	direct := make(map[string]bool)
+	for _, h := range g.HeterosisValues {
+		if h.Component.Component == "D" {
+			direct[h.Component.TraitName] = true
+		}
+	}
+	for _, t := range g.Traits {
+		if !direct[t.Name] {
+			r.fail("HeterosisValues", 0, "trait %s has no \"%s,D\" row", t.Name, t.Name)
+		}
+	}
+}
+
+func (r *reader) checkAgeDist(g *GenParm) {
+	var total float64
+	for _, p := range g.AgeDist {
+		total += p
+	}
+	if total > 1.001 || total < .999 {
+		r.fail("ageDist", 0, "proportions add to %g, not 1", total)
+	}
+}
+
+// Every index component must have a genetic component to bump
+func (r *reader) checkIndexComponents(g *GenParm, x *IndexParm) {
+	for i, c := range x.IndexComponents {
+		found := false
+		for _, gc := range g.Components {
+			if gc == c {
+				found = true
+			}
+		}
+		if !found {
+			r.fail("indexComponents", i+1, "%s,%s is not in the genParm Components", c.TraitName, c.Component)
+		}
+	}
+}
+
+// The discount rate is a yearly proportion and costs are not negative
+func (r *reader) checkIndexCosts(x *IndexParm) {
+	if x.DiscountRate < 0 || x.DiscountRate >= 1 {
+		r.fail("discountRate", 0, "%v must be a proportion from 0 to less than 1, e.g. 0.05", x.DiscountRate)
+	}
+	for _, m := range []struct {
+		key   string
+		costs []float64
+	}{{"aumCost", x.AumCost}, {"backgroundAumCost", x.BackgroundAumCost}} {
+		for i, c := range m.costs {
+			if c < 0 {
+				r.fail(m.key, i+1, "%v must not be negative", c)
+			}
+		}
+	}
+	if x.FeedlotFeedCost < 0 {
+		r.fail("feedlotFeedCost", 0, "%v must not be negative", x.FeedlotFeedCost)
+	}
+	if x.ProportionInProgram < 0 || x.ProportionInProgram > 1 {
+		r.fail("proportionInProgram", 0, "%v must be from 0 to 1", x.ProportionInProgram)
+	}
+}
+
+// Every trait and sex that is sold needs a price.  A weight must not be in two
+// bands of traitSexPricePerCwt and cull cows need a price at CullPricedWeight.
+// Sale weights without a price are left to PriceGaps, since a run only fails
+// if a calf is that weight.
+func (r *reader) checkPriceTable(x *IndexParm) {
+	trait, ok := EndpointPriceTrait[x.SaleEndpoint]
+	if !ok {
+		return // already reported
+	}
+
+	for _, p := range [][2]string{{trait, "S"}, {trait, "F"}} {
+		rows := priceBands(x, p[0], p[1])
+		if len(rows) == 0 {
+			r.fail("traitSexPricePerCwt", 0, "no price for trait %s sex %s", p[0], p[1])
+			continue
+		}
+		var covered float64 // Weights below this have a price
+		for _, i := range rows {
+			b := x.PriceTable[i]
+			if b.MaxWt <= b.MinWt {
+				r.fail("traitSexPricePerCwt", i+1, "max weight %g is not above min weight %g", b.MaxWt, b.MinWt)
+				continue
+			}
+			if b.MinWt < covered {
+				r.fail("traitSexPricePerCwt", i+1, "%s %s weights %g to %g are priced twice", p[0], p[1], b.MinWt, math.Min(covered, b.MaxWt))
+			}
+			covered = math.Max(covered, b.MaxWt)
+		}
+	}
+
+	rows := priceBands(x, "MW", "C")
+	if len(rows) == 0 {
+		r.fail("traitSexPricePerCwt", 0, "no price for trait MW sex C")
+		return
+	}
+	n := 0
+	for _, i := range rows {
+		if b := x.PriceTable[i]; CullPricedWeight >= b.MinWt && CullPricedWeight < b.MaxWt {
+			n++
+		}
+	}
+	if n != 1 {
+		r.fail("traitSexPricePerCwt", 0, "MW C needs one price at %g lb, the weight cull cows are priced at, not %d", CullPricedWeight, n)
+	}
+}
+
+// Sale weights from 0 to MaxPricedWeight of the steers and heifers at the
+// sale endpoint that have no price in traitSexPricePerCwt.  A run only fails
+// if a calf is sold at one of them, so these are warnings outside of
+// iGenDec validate.
+func PriceGaps(x *IndexParm) ParamErrors {
+	if x == nil {
+		return nil
+	}
+	trait, ok := EndpointPriceTrait[x.SaleEndpoint]
+	if !ok {
+		return nil
+	}
+	r := new(reader)
+
+	for _, p := range [][2]string{{trait, "S"}, {trait, "F"}} {
+		rows := priceBands(x, p[0], p[1])
+		if len(rows) == 0 {
+			continue // An error of Validate
+		}
+		var covered float64
+		for _, i := range rows {
+			b := x.PriceTable[i]
+			if b.MaxWt <= b.MinWt {
+				continue
+			}
+			if b.MinWt > covered {
+				r.fail("traitSexPricePerCwt", i+1, "%s %s weights %g to %g have no price", p[0], p[1], covered, b.MinWt)
+			}
+			covered = math.Max(covered, b.MaxWt)
+		}
+		if covered < MaxPricedWeight {
+			r.fail("traitSexPricePerCwt", 0, "%s %s weights %g and up have no price", p[0], p[1], covered)
+		}
+	}
+	return r.errs
+}
+
+// Rows of traitSexPricePerCwt of a trait and sex by their min weight
+func priceBands(x *IndexParm, trait, sex string) []int {
+	var rows []int
+	for i, b := range x.PriceTable {
+		if b.Trait == trait && b.Sex == sex {
+			rows = append(rows, i)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return x.PriceTable[rows[i]].MinWt < x.PriceTable[rows[j]].MinWt })
+	return rows
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if strings.TrimSpace(l) == s {
+			return true
+		}
+	}
+	return false
+}
@@ -26,13 +26,8 @@ import (
 	"math"
 
 	"github.com/blgolden/iGenDecModel/iGenDec/animal"
-	"github.com/blgolden/iGenDecModel/iGenDec/logger"
-
-	"gonum.org/v1/gonum/mat"
 )
 
-var BackgroundDays float64 // length of the backgrounding program after weaning
-
 type backgroundingGrossRevenueByYear_t struct {
 	nSteers                 float64
 	SteerRevenue            float64
@@ -52,15 +47,15 @@ type backgroundingGrossCostsByYear_t struct {
 	DiscountedHeiferCosts float64
 }
 
-var backgroundingGrossRevenueByYear map[int]backgroundingGrossRevenueByYear_t
-var backgroundingGrossCostsByYear map[int]backgroundingGrossCostsByYear_t
-
 // Calculate backgrounded animals total sale revenue
-func backgroundingSaleRevenue(calf animal.Animal) (salePrice float64) {
+func (ix *Index) backgroundingSaleRevenue(calf animal.Animal) (salePrice float64) {
 
 	weight := animal.BackgroundingWtPhenotype(calf)
 
-	pricePerPound := getPricePerPound(weight, calf.Sex, "BG")
+	pricePerPound, err := ix.getPricePerPound(weight, calf.Sex, "BG")
+	if err != nil {
+		ix.fail(err)
+	}
 	salePrice = weight * pricePerPound
 
 	//fmt.Println("LOC 1", weight, pricePerPound[tsmm], min, max, tsmm)
@@ -68,11 +63,11 @@ func backgroundingSaleRevenue(calf animal.Animal) (salePrice float64) {
 }
 
 // Revenue from sale of backgrounded calves
-func calculateBackgroundingRevenueByYear() {
+func (ix *Index) calculateBackgroundingRevenueByYear() {
 
-	backgroundingGrossRevenueByYear = make(map[int]backgroundingGrossRevenueByYear_t)
+	ix.backgroundingGrossRevenueByYear = make(map[int]backgroundingGrossRevenueByYear_t)
 
-	for _, calf := range animal.Records {
+	for _, calf := range ix.Animals.Records {
 
 		// Doing this because the distribution for CD is not initialized until after year 1
 		// Should probably discard entire year 1 or more results
@@ -80,201 +75,204 @@ func calculateBackgroundingRevenueByYear() {
 			calf.Dead = 0
 		}*/
 
-		yearBackgrounded := int(float64(calf.BirthDate)+205.+BackgroundDays) / 365
+		yearBackgrounded := int(float64(calf.BirthDate)+205.+ix.BackgroundDays) / 365
 		if calf.YearBorn >= 1 && calf.Dead == 0 {
 			if calf.Sex == animal.Steer {
 
-				w := backgroundingGrossRevenueByYear[yearBackgrounded]
+				w := ix.backgroundingGrossRevenueByYear[yearBackgrounded]
 				w.nSteers++
-				w.SteerRevenue += backgroundingSaleRevenue(calf)
+				w.SteerRevenue += ix.backgroundingSaleRevenue(calf)
 				p := calf.AumWeanThruBackgrounding[len(calf.AumWeanThruBackgrounding)-1].Weight
 				w.wtSteers += p
-				backgroundingGrossRevenueByYear[yearBackgrounded] = w
+				ix.backgroundingGrossRevenueByYear[yearBackgrounded] = w
 
 			} else if calf.Sex == animal.Heifer {
 
-				w := backgroundingGrossRevenueByYear[yearBackgrounded]
+				w := ix.backgroundingGrossRevenueByYear[yearBackgrounded]
 				w.nHeifers++
-				w.HeiferRevenue += backgroundingSaleRevenue(calf)
+				w.HeiferRevenue += ix.backgroundingSaleRevenue(calf)
 				p := calf.AumWeanThruBackgrounding[len(calf.AumWeanThruBackgrounding)-1].Weight
 				w.wtHeifers += p
-				backgroundingGrossRevenueByYear[yearBackgrounded] = w
+				ix.backgroundingGrossRevenueByYear[yearBackgrounded] = w
 			}
 		} else {
-			w := backgroundingGrossRevenueByYear[yearBackgrounded]
+			w := ix.backgroundingGrossRevenueByYear[yearBackgrounded]
 			w.nDead++
 			//fmt.Println("LOC 1", calf.YearBorn, yearBackgrounded)
-			backgroundingGrossRevenueByYear[yearBackgrounded] = w
+			ix.backgroundingGrossRevenueByYear[yearBackgrounded] = w
 		}
 	}
 	return
 }
 
 // Determine the discounted value of the revenue
-func calculateDiscountedBackgroundingRevenueByYear(nYears int) {
-	for y := animal.Burnin + 1; y <= nYears; y++ {
+func (ix *Index) calculateDiscountedBackgroundingRevenueByYear(nYears int) {
+	for y := ix.Animals.Burnin + 1; y <= nYears; y++ {
 
-		period := float64(y - StartYearOfNetReturns)
+		period := float64(y - ix.StartYearOfNetReturns)
 
-		b := backgroundingGrossRevenueByYear[y]
+		b := ix.backgroundingGrossRevenueByYear[y]
 
-		df := math.Pow(1.+DiscountRate, period)
+		df := math.Pow(1.+ix.DiscountRate, period)
 
 		b.DiscountedSteerRevenue = b.SteerRevenue / df
 		b.DiscountedHeiferRevenue = b.HeiferRevenue / df
 
-		backgroundingGrossRevenueByYear[y] = b
+		ix.backgroundingGrossRevenueByYear[y] = b
 	}
 }
 
 // Average backgrounding costs per mating accross years of simulation
 // Not used by backgrounding index but used by fat cattle, and grade and yield
-func calculateBackgroundingCostsPerMating(nYears int) float64 {
+func (ix *Index) calculateBackgroundingCostsPerMating(nYears int) float64 {
 
 	var totalDiscountedBackgroundingCostPerMating float64
 
-	for y := StartYearOfNetReturns; y <= nYears; y++ {
-		totalDiscountedBackgroundingCostPerMating += (backgroundingGrossCostsByYear[y].DiscountedSteerCosts + backgroundingGrossCostsByYear[y].DiscountedHeiferCosts) /
-			float64(animal.CowsExposedPerYear[y])
+	for y := ix.StartYearOfNetReturns; y <= nYears; y++ {
+		totalDiscountedBackgroundingCostPerMating += (ix.backgroundingGrossCostsByYear[y].DiscountedSteerCosts + ix.backgroundingGrossCostsByYear[y].DiscountedHeiferCosts) /
+			float64(ix.Animals.CowsExposedPerYear[y])
 	}
-	return totalDiscountedBackgroundingCostPerMating / float64(nYears-StartYearOfNetReturns+1)
+	return totalDiscountedBackgroundingCostPerMating / float64(nYears-ix.StartYearOfNetReturns+1)
 }
 
-//  The total discounted net returns to fixed costs for backgrounding
+//	The total discounted net returns to fixed costs for backgrounding
+//
 // and optionally write a table to stdout
 // Returns average cost/mating
-func backgroundingNetReturnsToFixedCosts(nYears int) float64 {
+func (ix *Index) backgroundingNetReturnsToFixedCosts(nYears int) float64 {
 
 	var TotalDiscountedRevenue float64
 	var TotalDiscountedNetRevenuePerMating float64
 
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 		fmt.Println("\nDiscounted Returns and Costs for Backgrounded Calves:")
 		fmt.Println("       Returns_____________________  Costs of backgrounding______    Costs of weaning_________ ")
 		fmt.Println("Year    $ Actual     $ Discounted    $ Actual     $ Discounted       $ Actual     $ Discounted     $ Net/Exposure  N Cows Exposed")
 	}
 
-	for y := StartYearOfNetReturns; y <= nYears; y++ {
+	for y := ix.StartYearOfNetReturns; y <= nYears; y++ {
 
-		TotalDiscountedRevenue += backgroundingGrossRevenueByYear[y].DiscountedSteerRevenue + backgroundingGrossRevenueByYear[y].DiscountedHeiferRevenue
+		TotalDiscountedRevenue += ix.backgroundingGrossRevenueByYear[y].DiscountedSteerRevenue + ix.backgroundingGrossRevenueByYear[y].DiscountedHeiferRevenue
 
-		netPerExposure := (backgroundingGrossRevenueByYear[y].DiscountedSteerRevenue + backgroundingGrossRevenueByYear[y].DiscountedHeiferRevenue -
-			backgroundingGrossCostsByYear[y].DiscountedSteerCosts - backgroundingGrossCostsByYear[y].DiscountedHeiferCosts -
-			weaningGrossCostsByYear[y].DiscountedSteerCosts - weaningGrossCostsByYear[y].DiscountedHeiferCosts) /
-			float64(animal.CowsExposedPerYear[y])
+		netPerExposure := (ix.backgroundingGrossRevenueByYear[y].DiscountedSteerRevenue + ix.backgroundingGrossRevenueByYear[y].DiscountedHeiferRevenue -
+			ix.backgroundingGrossCostsByYear[y].DiscountedSteerCosts - ix.backgroundingGrossCostsByYear[y].DiscountedHeiferCosts -
+			ix.weaningGrossCostsByYear[y].DiscountedSteerCosts - ix.weaningGrossCostsByYear[y].DiscountedHeiferCosts) /
+			float64(ix.Animals.CowsExposedPerYear[y])
 
 		TotalDiscountedNetRevenuePerMating += netPerExposure
 
-		if *logger.OutputMode == "verbose" {
+		if ix.OutputMode == "verbose" {
 			fmt.Printf("%5d  %10.2f    %10.2f     %10.2f   %10.2f         %10.2f   %10.2f         %10.2f       %7d\n", y,
-				backgroundingGrossRevenueByYear[y].SteerRevenue+backgroundingGrossRevenueByYear[y].HeiferRevenue,
-				backgroundingGrossRevenueByYear[y].DiscountedSteerRevenue+backgroundingGrossRevenueByYear[y].DiscountedHeiferRevenue,
-				backgroundingGrossCostsByYear[y].SteerCosts+backgroundingGrossCostsByYear[y].HeiferCosts,
-				backgroundingGrossCostsByYear[y].DiscountedSteerCosts+backgroundingGrossCostsByYear[y].DiscountedHeiferCosts,
-				weaningGrossCostsByYear[y].SteerCosts+weaningGrossCostsByYear[y].HeiferCosts,
-				weaningGrossCostsByYear[y].DiscountedSteerCosts+weaningGrossCostsByYear[y].DiscountedHeiferCosts,
+				ix.backgroundingGrossRevenueByYear[y].SteerRevenue+ix.backgroundingGrossRevenueByYear[y].HeiferRevenue,
+				ix.backgroundingGrossRevenueByYear[y].DiscountedSteerRevenue+ix.backgroundingGrossRevenueByYear[y].DiscountedHeiferRevenue,
+				ix.backgroundingGrossCostsByYear[y].SteerCosts+ix.backgroundingGrossCostsByYear[y].HeiferCosts,
+				ix.backgroundingGrossCostsByYear[y].DiscountedSteerCosts+ix.backgroundingGrossCostsByYear[y].DiscountedHeiferCosts,
+				ix.weaningGrossCostsByYear[y].SteerCosts+ix.weaningGrossCostsByYear[y].HeiferCosts,
+				ix.weaningGrossCostsByYear[y].DiscountedSteerCosts+ix.weaningGrossCostsByYear[y].DiscountedHeiferCosts,
 				netPerExposure,
-				animal.CowsExposedPerYear[y])
+				ix.Animals.CowsExposedPerYear[y])
 		}
 
 	}
 
-	return TotalDiscountedNetRevenuePerMating / float64(nYears-StartYearOfNetReturns+1)
+	return TotalDiscountedNetRevenuePerMating / float64(nYears-ix.StartYearOfNetReturns+1)
 }
 
 // Value calves after backgrounding
-func backgroundingSale(nYears int) float64 {
+func (ix *Index) backgroundingSale(nYears int) float64 {
 
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 		fmt.Println("Processing backgrounding sale net returns...")
 	}
 
-	calculateWeaningCostsByYear()
-	calculateDiscountedWeaningCostsByYear(nYears)
+	ix.calculateWeaningCostsByYear()
+	ix.calculateDiscountedWeaningCostsByYear(nYears)
 
-	calculateBackgroundingRevenueByYear()
-	calculateDiscountedBackgroundingRevenueByYear(nYears)
+	ix.calculateBackgroundingRevenueByYear()
+	ix.calculateDiscountedBackgroundingRevenueByYear(nYears)
 
-	calculateBackgroundingCostsByYear()
-	calculateDiscountedBackgroundingCostsByYear(nYears)
+	ix.calculateBackgroundingCostsByYear()
+	ix.calculateDiscountedBackgroundingCostsByYear(nYears)
 
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 		fmt.Println("\nRevenue from backgrounded calf sales:")
 		fmt.Println("Sale Year    n Steers	Steer $     Wt Steers   n Heifers      Heifer $    Wt Heifers    n Dead")
 		for year := 1; year <= nYears; year++ {
-			fmt.Printf("  %5d      %5d %12.2f  %12.1f       %5d  %12.2f  %12.1f   %7d\n", year, int(backgroundingGrossRevenueByYear[year].nSteers),
-				backgroundingGrossRevenueByYear[year].SteerRevenue, backgroundingGrossRevenueByYear[year].wtSteers,
-				int(backgroundingGrossRevenueByYear[year].nHeifers), backgroundingGrossRevenueByYear[year].HeiferRevenue, backgroundingGrossRevenueByYear[year].wtHeifers,
-				backgroundingGrossRevenueByYear[year].nDead)
+			fmt.Printf("  %5d      %5d %12.2f  %12.1f       %5d  %12.2f  %12.1f   %7d\n", year, int(ix.backgroundingGrossRevenueByYear[year].nSteers),
+				ix.backgroundingGrossRevenueByYear[year].SteerRevenue, ix.backgroundingGrossRevenueByYear[year].wtSteers,
+				int(ix.backgroundingGrossRevenueByYear[year].nHeifers), ix.backgroundingGrossRevenueByYear[year].HeiferRevenue, ix.backgroundingGrossRevenueByYear[year].wtHeifers,
+				ix.backgroundingGrossRevenueByYear[year].nDead)
 		}
 	}
 
-	return backgroundingNetReturnsToFixedCosts(nYears) // This returns total accumulated net returns/mating
+	return ix.backgroundingNetReturnsToFixedCosts(nYears) // This returns total accumulated net returns/mating
 
 }
 
 // Process an index with sale at after backgrounding
-func EvaluateBackgroundingIndex(nYears int, burninMarker int, param map[string]interface{}, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) float64 {
+func (ix *Index) EvaluateBackgroundingIndex(nYears int) (float64, error) {
 
 	var base animal.Component_t
 	base.Component = "D"
 	base.TraitName = "base"
-	IndexNetReturns := backgroundingSale(nYears) // Discounted and per mating
-	IndexNetReturns += cullSale(nYears)          // Discounted and per mating
-	IndexNetReturns -= CowCosts(nYears)
-
-	if *logger.OutputMode == "verbose" {
-		fmt.Printf("\nPlanning Horizon (in years):                                            %12d\n", nYears-StartYearOfNetReturns+1)
+	IndexNetReturns := ix.backgroundingSale(nYears) // Discounted and per mating
+	IndexNetReturns += ix.cullSale(nYears)          // Discounted and per mating
+	IndexNetReturns -= ix.CowCosts(nYears)
+	IndexNetReturns -= ix.BreedingCosts(nYears)
+	IndexNetReturns -= ix.CalvingCosts(nYears)
+
+	if ix.OutputMode == "verbose" {
+		fmt.Printf("\nPlanning Horizon (in years):                                            %12d\n", nYears-ix.StartYearOfNetReturns+1)
 		//fmt.Printf("%d Year Discounted Net Returns to land, management and labor per exp accum: %12.2f\n", nYears-StartYearOfNetReturns+1, NetReturns)
-		fmt.Printf("%d year Discounted Net Returns to land, management and labor per exposure:  %12.2f\n", nYears-StartYearOfNetReturns+1,
+		fmt.Printf("%d year Discounted Net Returns to land, management and labor per exposure:  %12.2f\n", nYears-ix.StartYearOfNetReturns+1,
 			IndexNetReturns)
 		fmt.Println("NOTE: all net values are returns to land, management and labor")
 	}
 
-	return IndexNetReturns
+	return IndexNetReturns, ix.Err()
 }
 
 // Determine the discounted value of the costs
-func calculateDiscountedBackgroundingCostsByYear(nYears int) {
-	for y := animal.Burnin + 1; y <= nYears; y++ {
+func (ix *Index) calculateDiscountedBackgroundingCostsByYear(nYears int) {
+	for y := ix.Animals.Burnin + 1; y <= nYears; y++ {
 
-		period := float64(y - StartYearOfNetReturns)
+		period := float64(y - ix.StartYearOfNetReturns)
 
-		df := math.Pow(1.+DiscountRate, period)
+		df := math.Pow(1.+ix.DiscountRate, period)
 
-		c := backgroundingGrossCostsByYear[y]
+		c := ix.backgroundingGrossCostsByYear[y]
 
 		c.DiscountedSteerCosts = c.SteerCosts / df
 		c.DiscountedHeiferCosts = c.HeiferCosts / df
 
-		backgroundingGrossCostsByYear[y] = c
+		ix.backgroundingGrossCostsByYear[y] = c
 	}
 }
 
 // Calculate cost to background each year
-func calculateBackgroundingCostsByYear() {
-	backgroundingGrossCostsByYear = make(map[int]backgroundingGrossCostsByYear_t)
+func (ix *Index) calculateBackgroundingCostsByYear() {
+	ix.backgroundingGrossCostsByYear = make(map[int]backgroundingGrossCostsByYear_t)
 
-	for _, calf := range animal.Records {
+	for _, calf := range ix.Animals.Records {
 
-		yearBackgrounded := int(float64(calf.BirthDate)+205.+BackgroundDays) / 365
+		yearBackgrounded := int(float64(calf.BirthDate)+205.+ix.BackgroundDays) / 365
 
 		if calf.YearBorn >= 1 && calf.Dead == 0 {
 			if calf.Sex == animal.Steer {
 				for _, a := range calf.AumWeanThruBackgrounding {
 					//c := backgroundingGrossCostsByYear[a.Year]
-					c := backgroundingGrossCostsByYear[yearBackgrounded]
-					c.SteerCosts += a.Aum * BackgroundAumCost[a.MonthOfYear-1]
+					c := ix.backgroundingGrossCostsByYear[yearBackgrounded]
+					c.SteerCosts += a.Aum * ix.BackgroundAumCost[a.MonthOfYear-1]
 					//backgroundingGrossCostsByYear[a.Year] = c
-					backgroundingGrossCostsByYear[yearBackgrounded] = c
+					ix.backgroundingGrossCostsByYear[yearBackgrounded] = c
 				}
 			} else if calf.Sex == animal.Heifer {
 				for _, a := range calf.AumWeanThruBackgrounding {
 					//c := backgroundingGrossCostsByYear[a.Year]
-					c := backgroundingGrossCostsByYear[yearBackgrounded]
-					c.HeiferCosts += a.Aum * BackgroundAumCost[a.MonthOfYear-1]
+					c := ix.backgroundingGrossCostsByYear[yearBackgrounded]
+					c.HeiferCosts += a.Aum * ix.BackgroundAumCost[a.MonthOfYear-1]
 					//backgroundingGrossCostsByYear[a.Year] = c
-					backgroundingGrossCostsByYear[yearBackgrounded] = c
+					ix.backgroundingGrossCostsByYear[yearBackgrounded] = c
 				}
 			}
 		}
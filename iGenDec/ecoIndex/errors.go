@@ -0,0 +1,54 @@
+// errors
+//
+// Errors returned while valuing a simulation
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package ecoIndex
+
+import (
+	"fmt"
+)
+
+// No band of traitSexPricePerCwt holds the weight of an animal sold
+type PriceError struct {
+	Trait  string
+	Sex    string
+	Weight float64
+}
+
+func (e *PriceError) Error() string {
+	return fmt.Sprintf("no traitSexPricePerCwt price for trait %s sex %s at %.1f lbs", e.Trait, e.Sex, e.Weight)
+}
+
+// Record the first error of the per-animal calculations that cannot return one
+func (ix *Index) fail(err error) {
+	if ix.err == nil {
+		ix.err = err
+	}
+}
+
+// The first error found while valuing, including those of the simulated animals
+func (ix *Index) Err() error {
+	if ix.err != nil {
+		return ix.err
+	}
+	return ix.Animals.Err()
+}
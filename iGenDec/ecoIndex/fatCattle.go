@@ -25,11 +25,8 @@ import (
 	"fmt"
 
 	"github.com/blgolden/iGenDecModel/iGenDec/animal"
-	"github.com/blgolden/iGenDecModel/iGenDec/logger"
 
 	"math"
-
-	"gonum.org/v1/gonum/mat"
 )
 
 type fatcattleGrossRevenueByYear_t struct {
@@ -51,26 +48,26 @@ type fatcattleGrossCostsByYear_t struct {
 	DiscountedHeiferCosts float64
 }
 
-var fatcattleGrossRevenueByYear map[int]fatcattleGrossRevenueByYear_t
-var fatcattleGrossCostsByYear map[int]fatcattleGrossCostsByYear_t
-
 // Calculate backgrounded animals total sale revenue
-func fatcattleSaleRevenue(calf animal.Animal) (salePrice float64) {
+func (ix *Index) fatcattleSaleRevenue(calf animal.Animal) (salePrice float64) {
 
 	weight := calf.HarvestWeight
 
-	pricePerPound := getPricePerPound(weight, calf.Sex, "FC")
+	pricePerPound, err := ix.getPricePerPound(weight, calf.Sex, "FC")
+	if err != nil {
+		ix.fail(err)
+	}
 	salePrice = weight * pricePerPound
 
 	return salePrice
 }
 
 // Revenue from sale of fed cattle
-func calculateFatcattleRevenueByYear() {
+func (ix *Index) calculateFatcattleRevenueByYear() {
 
-	fatcattleGrossRevenueByYear = make(map[int]fatcattleGrossRevenueByYear_t)
+	ix.fatcattleGrossRevenueByYear = make(map[int]fatcattleGrossRevenueByYear_t)
 
-	for _, calf := range animal.Records {
+	for _, calf := range ix.Animals.Records {
 
 		// Doing this because the distribution for CD is not initialized until after year 1
 		// Should probably discard entire year 1 or more results
@@ -78,139 +75,140 @@ func calculateFatcattleRevenueByYear() {
 			calf.Dead = 0
 		}
 
-		yearHarvested := int(float64(calf.BirthDate)+205.+BackgroundDays+animal.DaysOnFeed) / 365
+		yearHarvested := int(float64(calf.BirthDate)+205.+ix.BackgroundDays+ix.Animals.DaysOnFeed) / 365
 		if calf.YearBorn >= 1 && calf.Dead == 0 {
 			if calf.Sex == animal.Steer {
 
-				w := fatcattleGrossRevenueByYear[yearHarvested]
+				w := ix.fatcattleGrossRevenueByYear[yearHarvested]
 				w.nSteers++
-				w.SteerRevenue += fatcattleSaleRevenue(calf)
+				w.SteerRevenue += ix.fatcattleSaleRevenue(calf)
 				p := calf.HarvestWeight
 				w.wtSteers += p
-				fatcattleGrossRevenueByYear[yearHarvested] = w
+				ix.fatcattleGrossRevenueByYear[yearHarvested] = w
 
 			} else if calf.Sex == animal.Heifer {
 
-				w := fatcattleGrossRevenueByYear[yearHarvested]
+				w := ix.fatcattleGrossRevenueByYear[yearHarvested]
 				w.nHeifers++
-				w.HeiferRevenue += fatcattleSaleRevenue(calf)
+				w.HeiferRevenue += ix.fatcattleSaleRevenue(calf)
 				p := calf.HarvestWeight
 				w.wtHeifers += p
-				fatcattleGrossRevenueByYear[yearHarvested] = w
+				ix.fatcattleGrossRevenueByYear[yearHarvested] = w
 			}
 		} else {
-			w := fatcattleGrossRevenueByYear[yearHarvested]
+			w := ix.fatcattleGrossRevenueByYear[yearHarvested]
 			w.nDead++
-			fatcattleGrossRevenueByYear[yearHarvested] = w
+			ix.fatcattleGrossRevenueByYear[yearHarvested] = w
 		}
 	}
 	return
 }
 
 // Determine the discounted value of the revenue
-func calculateDiscountedFatcattleRevenueByYear(nYears int) {
-	for y := animal.Burnin + 1; y <= nYears; y++ {
+func (ix *Index) calculateDiscountedFatcattleRevenueByYear(nYears int) {
+	for y := ix.Animals.Burnin + 1; y <= nYears; y++ {
 
-		period := float64(y - StartYearOfNetReturns)
+		period := float64(y - ix.StartYearOfNetReturns)
 
-		b := fatcattleGrossRevenueByYear[y]
+		b := ix.fatcattleGrossRevenueByYear[y]
 
-		df := math.Pow(1.+DiscountRate, period)
+		df := math.Pow(1.+ix.DiscountRate, period)
 
 		b.DiscountedSteerRevenue = b.SteerRevenue / df
 		b.DiscountedHeiferRevenue = b.HeiferRevenue / df
 
-		fatcattleGrossRevenueByYear[y] = b
+		ix.fatcattleGrossRevenueByYear[y] = b
 	}
 }
 
 // Value calves as fed cattle
-func fatcattleSale(nYears int) float64 {
+func (ix *Index) fatcattleSale(nYears int) float64 {
 
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 		fmt.Println("Processing fatcattle sale net returns...")
 	}
 
-	calculateWeaningCostsByYear()
-	calculateDiscountedWeaningCostsByYear(nYears)
+	ix.calculateWeaningCostsByYear()
+	ix.calculateDiscountedWeaningCostsByYear(nYears)
 
-	calculateBackgroundingCostsByYear()
-	calculateDiscountedBackgroundingCostsByYear(nYears)
+	ix.calculateBackgroundingCostsByYear()
+	ix.calculateDiscountedBackgroundingCostsByYear(nYears)
 
-	calculateFatcattleRevenueByYear()
-	calculateDiscountedFatcattleRevenueByYear(nYears)
+	ix.calculateFatcattleRevenueByYear()
+	ix.calculateDiscountedFatcattleRevenueByYear(nYears)
 
-	calculateFatcattleCostsByYear()
-	calculateDiscountedFatcattleCostsByYear(nYears)
+	ix.calculateFatcattleCostsByYear()
+	ix.calculateDiscountedFatcattleCostsByYear(nYears)
 
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 		fmt.Println("\nRevenue from fatcattle calf sales:")
 		fmt.Println("Sale Year    n Steers	Steer $     Wt Steers   n Heifers      Heifer $    Wt Heifers    n Dead")
 		for year := 1; year <= nYears; year++ {
-			fmt.Printf("  %5d      %5d %12.2f  %12.1f       %5d  %12.2f  %12.1f   %7d\n", year, int(fatcattleGrossRevenueByYear[year].nSteers),
-				fatcattleGrossRevenueByYear[year].SteerRevenue, fatcattleGrossRevenueByYear[year].wtSteers,
-				int(fatcattleGrossRevenueByYear[year].nHeifers), fatcattleGrossRevenueByYear[year].HeiferRevenue, fatcattleGrossRevenueByYear[year].wtHeifers,
-				fatcattleGrossRevenueByYear[year].nDead)
+			fmt.Printf("  %5d      %5d %12.2f  %12.1f       %5d  %12.2f  %12.1f   %7d\n", year, int(ix.fatcattleGrossRevenueByYear[year].nSteers),
+				ix.fatcattleGrossRevenueByYear[year].SteerRevenue, ix.fatcattleGrossRevenueByYear[year].wtSteers,
+				int(ix.fatcattleGrossRevenueByYear[year].nHeifers), ix.fatcattleGrossRevenueByYear[year].HeiferRevenue, ix.fatcattleGrossRevenueByYear[year].wtHeifers,
+				ix.fatcattleGrossRevenueByYear[year].nDead)
 		}
 	}
 
-	return fatcattleNetReturnsToFixedCosts(nYears) // This returns total accumulated net returns/mating
-	return 0.0
+	return ix.fatcattleNetReturnsToFixedCosts(nYears) // This returns total accumulated net returns/mating
 }
 
 // Process an index with sale as finished cattle
-func EvaluateFatCattleIndex(nYears int, burninMarker int, param map[string]interface{}, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) float64 {
+func (ix *Index) EvaluateFatCattleIndex(nYears int) (float64, error) {
 
 	var base animal.Component_t
 	base.Component = "D"
 	base.TraitName = "base"
-	IndexNetReturns := fatcattleSale(nYears) // Discounted and per mating
-	IndexNetReturns += cullSale(nYears)      // Discounted and per mating
-	IndexNetReturns -= CowCosts(nYears)
-
-	if *logger.OutputMode == "verbose" {
-		fmt.Printf("\nPlanning Horizon (in years):                                            %12d\n", nYears-StartYearOfNetReturns+1)
+	IndexNetReturns := ix.fatcattleSale(nYears) // Discounted and per mating
+	IndexNetReturns += ix.cullSale(nYears)      // Discounted and per mating
+	IndexNetReturns -= ix.CowCosts(nYears)
+	IndexNetReturns -= ix.BreedingCosts(nYears)
+	IndexNetReturns -= ix.CalvingCosts(nYears)
+
+	if ix.OutputMode == "verbose" {
+		fmt.Printf("\nPlanning Horizon (in years):                                            %12d\n", nYears-ix.StartYearOfNetReturns+1)
 		//fmt.Printf("%d Year Discounted Net Returns to land, management and labor per exp accum: %12.2f\n", nYears-StartYearOfNetReturns+1, NetReturns)
-		fmt.Printf("%d year Discounted Net Returns to land, management and labor per exposure:  %12.2f\n", nYears-StartYearOfNetReturns+1,
+		fmt.Printf("%d year Discounted Net Returns to land, management and labor per exposure:  %12.2f\n", nYears-ix.StartYearOfNetReturns+1,
 			IndexNetReturns)
 		fmt.Println("NOTE: all net values are returns to land, management and labor")
 	}
 
-	return IndexNetReturns
+	return IndexNetReturns, ix.Err()
 }
 
 // Determine the discounted value of the costs
-func calculateDiscountedFatcattleCostsByYear(nYears int) {
-	for y := animal.Burnin + 1; y <= nYears; y++ {
+func (ix *Index) calculateDiscountedFatcattleCostsByYear(nYears int) {
+	for y := ix.Animals.Burnin + 1; y <= nYears; y++ {
 
-		period := float64(y - StartYearOfNetReturns)
+		period := float64(y - ix.StartYearOfNetReturns)
 
-		df := math.Pow(1.+DiscountRate, period)
+		df := math.Pow(1.+ix.DiscountRate, period)
 
-		c := fatcattleGrossCostsByYear[y]
+		c := ix.fatcattleGrossCostsByYear[y]
 
 		c.DiscountedSteerCosts = c.SteerCosts / df
 		c.DiscountedHeiferCosts = c.HeiferCosts / df
 
-		fatcattleGrossCostsByYear[y] = c
+		ix.fatcattleGrossCostsByYear[y] = c
 	}
 }
 
 // Calculate cost to feed each year
-func calculateFatcattleCostsByYear() {
+func (ix *Index) calculateFatcattleCostsByYear() {
 
-	fatcattleGrossCostsByYear = make(map[int]fatcattleGrossCostsByYear_t)
+	ix.fatcattleGrossCostsByYear = make(map[int]fatcattleGrossCostsByYear_t)
 
-	for _, calf := range animal.Records {
+	for _, calf := range ix.Animals.Records {
 		if calf.YearBorn >= 1 && calf.Dead == 0 {
 			if calf.Sex == animal.Steer { // Otherwise its a cow and was not fed
-				c := fatcattleGrossCostsByYear[calf.YearBorn+1]
-				c.SteerCosts += calf.FeedlotTotalFeedIntake * FeedlotFeedCost
-				fatcattleGrossCostsByYear[calf.YearBorn+1] = c
+				c := ix.fatcattleGrossCostsByYear[calf.YearBorn+1]
+				c.SteerCosts += calf.FeedlotTotalFeedIntake * ix.FeedlotFeedCost
+				ix.fatcattleGrossCostsByYear[calf.YearBorn+1] = c
 			} else if calf.Sex == animal.Heifer {
-				c := fatcattleGrossCostsByYear[calf.YearBorn+1]
-				c.HeiferCosts += calf.FeedlotTotalFeedIntake * FeedlotFeedCost
-				fatcattleGrossCostsByYear[calf.YearBorn+1] = c
+				c := ix.fatcattleGrossCostsByYear[calf.YearBorn+1]
+				c.HeiferCosts += calf.FeedlotTotalFeedIntake * ix.FeedlotFeedCost
+				ix.fatcattleGrossCostsByYear[calf.YearBorn+1] = c
 
 			}
 		}
@@ -218,47 +216,48 @@ func calculateFatcattleCostsByYear() {
 	return
 }
 
-//  The total discounted net returns to fixed costs for feeding cattle
+//	The total discounted net returns to fixed costs for feeding cattle
+//
 // and optionally write a table to stdout
 // Returns average net returns to LML/mating
-func fatcattleNetReturnsToFixedCosts(nYears int) float64 {
+func (ix *Index) fatcattleNetReturnsToFixedCosts(nYears int) float64 {
 
 	var TotalDiscountedRevenue float64
 	var TotalDiscountedNetRevenuePerMating float64
 
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 		fmt.Println("\nDiscounted Returns and Costs for Finished Cattle:")
 		fmt.Println("       Returns_____________________  Costs of Finishing_______      Costs of backgrounding______    Costs of weaning_________")
 		fmt.Println("Year    $ Actual     $ Discounted    $ Actual     $ Discounted      $ Actual     $ Discounted       $ Actual     $ Discounted     $ Net/Exposure  N Cows Exposed")
 	}
 
-	for y := StartYearOfNetReturns; y <= nYears; y++ {
+	for y := ix.StartYearOfNetReturns; y <= nYears; y++ {
 
-		TotalDiscountedRevenue += fatcattleGrossRevenueByYear[y].DiscountedSteerRevenue + fatcattleGrossRevenueByYear[y].DiscountedHeiferRevenue
+		TotalDiscountedRevenue += ix.fatcattleGrossRevenueByYear[y].DiscountedSteerRevenue + ix.fatcattleGrossRevenueByYear[y].DiscountedHeiferRevenue
 
-		netPerExposure := (fatcattleGrossRevenueByYear[y].DiscountedSteerRevenue + fatcattleGrossRevenueByYear[y].DiscountedHeiferRevenue -
-			fatcattleGrossCostsByYear[y].DiscountedSteerCosts - fatcattleGrossCostsByYear[y].DiscountedHeiferCosts -
-			backgroundingGrossCostsByYear[y].DiscountedSteerCosts - backgroundingGrossCostsByYear[y].DiscountedHeiferCosts -
-			weaningGrossCostsByYear[y].DiscountedSteerCosts - weaningGrossCostsByYear[y].DiscountedHeiferCosts) /
-			float64(animal.CowsExposedPerYear[y])
+		netPerExposure := (ix.fatcattleGrossRevenueByYear[y].DiscountedSteerRevenue + ix.fatcattleGrossRevenueByYear[y].DiscountedHeiferRevenue -
+			ix.fatcattleGrossCostsByYear[y].DiscountedSteerCosts - ix.fatcattleGrossCostsByYear[y].DiscountedHeiferCosts -
+			ix.backgroundingGrossCostsByYear[y].DiscountedSteerCosts - ix.backgroundingGrossCostsByYear[y].DiscountedHeiferCosts -
+			ix.weaningGrossCostsByYear[y].DiscountedSteerCosts - ix.weaningGrossCostsByYear[y].DiscountedHeiferCosts) /
+			float64(ix.Animals.CowsExposedPerYear[y])
 
 		TotalDiscountedNetRevenuePerMating += netPerExposure
 
-		if *logger.OutputMode == "verbose" {
+		if ix.OutputMode == "verbose" {
 			fmt.Printf("%5d  %10.2f    %10.2f     %10.2f    %10.2f     %10.2f   %10.2f         %10.2f   %10.2f         %10.2f       %7d\n", y,
-				fatcattleGrossRevenueByYear[y].SteerRevenue+fatcattleGrossRevenueByYear[y].HeiferRevenue,
-				fatcattleGrossRevenueByYear[y].DiscountedSteerRevenue+fatcattleGrossRevenueByYear[y].DiscountedHeiferRevenue,
-				fatcattleGrossCostsByYear[y].SteerCosts+fatcattleGrossCostsByYear[y].HeiferCosts,
-				fatcattleGrossCostsByYear[y].DiscountedSteerCosts+fatcattleGrossCostsByYear[y].DiscountedHeiferCosts,
-				backgroundingGrossCostsByYear[y].SteerCosts+backgroundingGrossCostsByYear[y].HeiferCosts,
-				backgroundingGrossCostsByYear[y].DiscountedSteerCosts+backgroundingGrossCostsByYear[y].DiscountedHeiferCosts,
-				weaningGrossCostsByYear[y].SteerCosts+weaningGrossCostsByYear[y].HeiferCosts,
-				weaningGrossCostsByYear[y].DiscountedSteerCosts+weaningGrossCostsByYear[y].DiscountedHeiferCosts,
+				ix.fatcattleGrossRevenueByYear[y].SteerRevenue+ix.fatcattleGrossRevenueByYear[y].HeiferRevenue,
+				ix.fatcattleGrossRevenueByYear[y].DiscountedSteerRevenue+ix.fatcattleGrossRevenueByYear[y].DiscountedHeiferRevenue,
+				ix.fatcattleGrossCostsByYear[y].SteerCosts+ix.fatcattleGrossCostsByYear[y].HeiferCosts,
+				ix.fatcattleGrossCostsByYear[y].DiscountedSteerCosts+ix.fatcattleGrossCostsByYear[y].DiscountedHeiferCosts,
+				ix.backgroundingGrossCostsByYear[y].SteerCosts+ix.backgroundingGrossCostsByYear[y].HeiferCosts,
+				ix.backgroundingGrossCostsByYear[y].DiscountedSteerCosts+ix.backgroundingGrossCostsByYear[y].DiscountedHeiferCosts,
+				ix.weaningGrossCostsByYear[y].SteerCosts+ix.weaningGrossCostsByYear[y].HeiferCosts,
+				ix.weaningGrossCostsByYear[y].DiscountedSteerCosts+ix.weaningGrossCostsByYear[y].DiscountedHeiferCosts,
 				netPerExposure,
-				animal.CowsExposedPerYear[y])
+				ix.Animals.CowsExposedPerYear[y])
 		}
 
 	}
 
-	return TotalDiscountedNetRevenuePerMating / float64(nYears-StartYearOfNetReturns+1)
+	return TotalDiscountedNetRevenuePerMating / float64(nYears-ix.StartYearOfNetReturns+1)
 }
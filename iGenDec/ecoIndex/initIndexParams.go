@@ -23,34 +23,53 @@ package ecoIndex
 
 import (
 	"fmt"
-	"io/ioutil"
-	"os"
-	"strconv"
 	"strings"
 
-	hjson "github.com/hjson/hjson-go"
-
 	"github.com/blgolden/iGenDecModel/iGenDec/animal"
-	"github.com/blgolden/iGenDecModel/iGenDec/logger"
-
-	"gonum.org/v1/gonum/mat"
+	"github.com/blgolden/iGenDecModel/iGenDec/config"
 )
 
-var IndexType string
-var IndexTerminal bool
+// Index holds the parameters of one economic index and the revenue and cost
+// tables it accumulates while evaluating a simulation's net returns.
+type Index struct {
+	OutputMode string // verbose, model, etc.
+
+	Animals *animal.State // The simulation being valued
+
+	Param map[string]interface{} // setup the map of the array of json name:value pairs - notice "interface{}"
+	parm  *config.IndexParm      // The checked and typed Param
+
+	IndexType             string
+	IndexTerminal         bool
+	gridPrice             map[GridValue_t]float64 // Premiums for grid prices
+	StartYearOfNetReturns int
+	DiscountRate          float64
+	IndexComponents       []animal.Component_t
+	AumCost               []float64
+	BackgroundAumCost     []float64
+	FeedlotFeedCost       float64
+	InProgramProportion   float64 // proportion of calves that initially may qualify for a grid program - e.g., CHB, CAB
+	NetReturns            float64
+	BackgroundDays        float64 // length of the backgrounding program after weaning
+
+	PriceTable []TraitSexMinWtMaxWt_t
+
+	variableCostsByYearCows   map[int]float64
+	weaningGrossRevenueByYear map[int]weaningGrossRevenueByYear_t
+	weaningGrossCostsByYear   map[int]weaningGrossCostsByYear_t
+	cullCowGrosRevenueByYear  map[int]cullCowRevenueByYear_t
 
-// setup the map of the array of json name:value pairs - notice "interface{}"
-var ParamIndex map[string]interface{}
+	backgroundingGrossRevenueByYear map[int]backgroundingGrossRevenueByYear_t
+	backgroundingGrossCostsByYear   map[int]backgroundingGrossCostsByYear_t
 
-var gridPrice map[GridValue_t]float64 // Premiums for grid prices
-var StartYearOfNetReturns int
-var DiscountRate float64
-var IndexComponents []animal.Component_t
-var AumCost []float64
-var BackgroundAumCost []float64
-var FeedlotFeedCost float64
-var InProgramProportion float64 // proportion of calves that initially may qualify for a grid program - e.g., CHB, CAB
-var NetReturns float64
+	fatcattleGrossRevenueByYear map[int]fatcattleGrossRevenueByYear_t
+	fatcattleGrossCostsByYear   map[int]fatcattleGrossCostsByYear_t
+
+	slaughtercattleGrossRevenueByYear map[int]slaughtercattleGrossRevenueByYear_t
+	slaughtercattleGrossCostsByYear   map[int]slaughtercattleGrossCostsByYear_t
+
+	err error // First error found while valuing
+}
 
 type TraitSexMinWtMaxWt_t struct {
 	Trait         string  // Same as traits in master hjson - e.g., WW is weaning weight
@@ -60,263 +79,190 @@ type TraitSexMinWtMaxWt_t struct {
 	PricePerPound float64 // read as per cwt but converted to per lb
 }
 
-var PriceTable []TraitSexMinWtMaxWt_t
-
 type GridValue_t struct {
 	QualityGrade string // Prime, Program, Choice, Select,Standard
 	YieldGrade   int    // 1, 2, 3, 4, 5
 }
 
 // Read the table of price per cwt.  Convert it to price per pound
-func readPricePerPound() {
-
-	carray, ok := ParamIndex["traitSexPricePerCwt"].([]interface{})
-	if !ok {
-		logger.LogWriterFatal("'pricePerPound:' key not found in echonomic index hjson.")
-	}
-
-	for i := range carray {
-		c := strings.Split(carray[i].(string), ",")
-		trait := strings.TrimSpace(c[0])
-		sex := strings.TrimSpace(c[1])
-		min, _ := strconv.ParseFloat(strings.TrimSpace(c[2]), 64)
-		max, _ := strconv.ParseFloat(strings.TrimSpace(c[3]), 64)
+func (ix *Index) readPricePerPound(table []config.PriceBand_t) {
 
+	for _, b := range table {
 		var tsmm TraitSexMinWtMaxWt_t
-		tsmm.Trait = trait
-		tsmm.Sex = sex
-		tsmm.MinWt = min
-		tsmm.MaxWt = max
-		f, _ := strconv.ParseFloat(strings.TrimSpace(c[4]), 64)
-		tsmm.PricePerPound = f / 100.0 // Convert from $/cwt to $/lb
-
-		PriceTable = append(PriceTable, tsmm)
+		tsmm.Trait = b.Trait
+		tsmm.Sex = b.Sex
+		tsmm.MinWt = b.MinWt
+		tsmm.MaxWt = b.MaxWt
+		tsmm.PricePerPound = b.PricePerCwt / 100.0 // Convert from $/cwt to $/lb
+
+		ix.PriceTable = append(ix.PriceTable, tsmm)
 	}
 
 }
 
 // If slaughtercattle IndexType then initialize grid pricing
-func InitGrid() {
-
-	gridPrice = make(map[GridValue_t]float64)
+func (ix *Index) InitGrid() {
 
-	carray, ok := ParamIndex["gridPremiums"].([]interface{})
+	ix.gridPrice = make(map[GridValue_t]float64)
 
-	for i := range carray {
-		c := strings.Split(carray[i].(string), ",")
-		qg := strings.TrimSpace(c[0])
+	for _, g := range ix.parm.GridPremiums {
 		for yieldGrade := 1; yieldGrade <= 5; yieldGrade++ {
 
 			var gridValue GridValue_t
-			gridValue.QualityGrade = qg
+			gridValue.QualityGrade = g.QualityGrade
 			gridValue.YieldGrade = yieldGrade
 
-			f, _ := strconv.ParseFloat(strings.TrimSpace(c[yieldGrade]), 64)
-			gridPrice[gridValue] = f / 100.0 // comes in as $/cwt and converts to $/lb
-		}
-	}
-	ip, ok := ParamIndex["proportionInProgram"].(interface{})
-	if ok {
-		InProgramProportion, _ = strconv.ParseFloat(strings.TrimSpace(ip.(string)), 64)
-	}
-}
-
-// Read the index hjson file
-func InitIndexParams(indexParam *string) {
-
-	hjsonFile, err := os.Open(*indexParam)
-	if err != nil {
-		if *logger.OutputMode == "verbose" {
-			fmt.Println("Failed to open " + *indexParam)
-			fmt.Println(err)
-		}
-		logger.LogWriterFatal("Failed to open index parameter file " + *indexParam)
-	}
-
-	defer hjsonFile.Close()
-
-	byteValue, _ := ioutil.ReadAll(hjsonFile)
-
-	if er := hjson.Unmarshal(byteValue, &ParamIndex); er != nil {
-		if *logger.OutputMode == "verbose" {
-			fmt.Println("Could not process the hjson", *indexParam)
-			fmt.Println("Panic message:", er)
+			ix.gridPrice[gridValue] = g.PerCwt[yieldGrade-1] / 100.0 // comes in as $/cwt and converts to $/lb
 		}
-		logger.LogWriterFatal("failed to unmarshal " + *indexParam)
 	}
-
-	readPricePerPound()
-	loadAumCostPerMonth()
-
-	return
+	ix.InProgramProportion = ix.parm.ProportionInProgram
 }
 
-// Read in the AUM cost per month
-func loadAumCostPerMonth() {
-
-	carray, ok := ParamIndex["aumCost"].([]interface{})
-	if !ok {
-		logger.LogWriterFatal("'aumCost:' key not found in economic index hjson.")
-	}
-
-	for i := range carray {
-		c := carray[i].(float64)
-		AumCost = append(AumCost, c)
-	}
-
-	if string(ParamIndex["saleEndpoint"].(string)) != "weaning" {
-
-		barray, ok := ParamIndex["backgroundAumCost"].([]interface{})
-		if !ok {
-			logger.LogWriterFatal("'backgroundAumCost not found in economic index hjson")
-		}
-		for i := range barray {
-			b := barray[i].(float64)
-			BackgroundAumCost = append(BackgroundAumCost, b)
-		}
+// Set up the index from the index hjson parameters already read into param
+func NewIndex(param map[string]interface{}, animals *animal.State, outputMode string) (*Index, error) {
 
-		if string(ParamIndex["saleEndpoint"].(string)) != "background" { // Then it must be fatcattle or slaughter
-			dr, ok := ParamIndex["feedlotFeedCost"].(interface{})
-			if !ok {
-				logger.LogWriterFatal("'feedlotFeedCost' key not found in economic index hjson")
-			}
-			r, _ := strconv.ParseFloat(strings.TrimSpace(dr.(string)), 64)
-			FeedlotFeedCost = r
+	ix := new(Index)
+	ix.Param = param
+	ix.Animals = animals
+	ix.OutputMode = outputMode
 
-		}
+	// Checks and converts the keys the index reads
+	x, err := config.NewIndexParm(param)
+	if err != nil {
+		return nil, err
 	}
+	ix.parm = x
 
-	return
-}
+	ix.readPricePerPound(x.PriceTable)
+	ix.DiscountRate = x.DiscountRate
+	ix.AumCost = x.AumCost
+	ix.BackgroundAumCost = x.BackgroundAumCost
+	ix.FeedlotFeedCost = x.FeedlotFeedCost
 
-// Set the discount rate
-func whatDiscountRate() float64 {
-	dr := ParamIndex["discountRate"].(interface{})
-	r, _ := strconv.ParseFloat(strings.TrimSpace(dr.(string)), 64)
-
-	return r
+	return ix, nil
 }
 
 /*
 // Reset Records back to heifers
-func HeiferReset() {
-	for i := range animal.HeiferResetList {
-		animal.Records[i].Sex = animal.Heifer
-		animal.Records[i].BreedingRecords = nil
-		animal.Records[i].Dead = 0
-		animal.Records[i].YearCowCulled = 0
-		animal.Records[i].Active = false
 
+	func (ix *Index) HeiferReset() {
+		for i := range animal.HeiferResetList {
+			ix.Animals.Records[i].Sex = animal.Heifer
+			ix.Animals.Records[i].BreedingRecords = nil
+			ix.Animals.Records[i].Dead = 0
+			ix.Animals.Records[i].YearCowCulled = 0
+			ix.Animals.Records[i].Active = false
+
+		}
 	}
-}
 
 // Reset Records back to active cows
-func CowReset() {
-	for l := range animal.CowResetList {
-		i := animal.CowResetList[l].Id - 1
-		animal.Records[i].Sex = animal.Cow
-		animal.Records[i].BreedingRecords = nil
-		animal.Records[i].Dead = 0
-		animal.Records[i].YearCowCulled = 0
-		animal.Records[i].Active = true
-		animal.Records[i].BreedingRecords = animal.CowResetList[l].BreedingRecords
+
+	func (ix *Index) CowReset() {
+		for l := range ix.Animals.CowResetList {
+			i := ix.Animals.CowResetList[l].Id - 1
+			ix.Animals.Records[i].Sex = animal.Cow
+			ix.Animals.Records[i].BreedingRecords = nil
+			ix.Animals.Records[i].Dead = 0
+			ix.Animals.Records[i].YearCowCulled = 0
+			ix.Animals.Records[i].Active = true
+			ix.Animals.Records[i].BreedingRecords = ix.Animals.CowResetList[l].BreedingRecords
+		}
 	}
-}
 */
-func SetActiveCowList() {
-	for _, h := range animal.Herds {
-		activeCows := animal.ActiveCows(&h)
+func (ix *Index) SetActiveCowList() {
+	for _, n := range ix.Animals.HerdNames {
+		h := ix.Animals.Herds[n]
+		activeCows := ix.Animals.ActiveCows(&h)
 		for i := range activeCows {
-			animal.CowResetList = append(animal.CowResetList, *activeCows[i])
+			ix.Animals.CowResetList = append(ix.Animals.CowResetList, *activeCows[i])
 		}
 	}
 }
 
 // So they are sold when a terminal index
-func CowsToHeifers() {
-	for r := range animal.Records {
-		if animal.Records[r].Sex == animal.Cow {
-			animal.Records[r].Sex = animal.Heifer
+func (ix *Index) CowsToHeifers() {
+	for r := range ix.Animals.Records {
+		if ix.Animals.Records[r].Sex == animal.Cow {
+			ix.Animals.Records[r].Sex = animal.Heifer
 		}
 	}
 }
 
 // main call
-func ProcessNetReturns(indexParam *string, nYears int, burninMarker int,
-	param map[string]interface{}, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) {
-	//InitIndexParams(indexParam)
+func (ix *Index) ProcessNetReturns(nYears int) (float64, error) {
 
-	IndexType = WhatSaleEndpoint()
-	IndexTerminal = IsIndexTerminal()
-	StartYearOfNetReturns = animal.Burnin + 1
-	DiscountRate = whatDiscountRate()
+	ix.IndexType = ix.WhatSaleEndpoint()
+	ix.IndexTerminal = ix.IsIndexTerminal()
+	ix.StartYearOfNetReturns = ix.Animals.Burnin + 1
 
-	if *logger.OutputMode == "verbose" {
-		fmt.Println("Type of economic index:", IndexType, " Terminal:", IndexTerminal)
+	if ix.OutputMode == "verbose" {
+		fmt.Println("Type of economic index:", ix.IndexType, " Terminal:", ix.IndexTerminal)
 	}
 
-	if IndexTerminal {
-		CowsToHeifers()
-		StartYearOfNetReturns = nYears
+	if ix.IndexTerminal {
+		ix.CowsToHeifers()
+		ix.StartYearOfNetReturns = nYears
 	}
 
-	switch IndexType {
+	var err error
+	switch ix.IndexType {
 	case "weaning":
-		NetReturns = EvaluateWeaningIndex(nYears, burninMarker, param, gvCholesky, rvCholesky)
+		ix.NetReturns, err = ix.EvaluateWeaningIndex(nYears)
 
 	case "background":
-		NetReturns = EvaluateBackgroundingIndex(nYears, burninMarker, param, gvCholesky, rvCholesky)
+		ix.NetReturns, err = ix.EvaluateBackgroundingIndex(nYears)
 
 	case "fatcattle":
-		NetReturns = EvaluateFatCattleIndex(nYears, burninMarker, param, gvCholesky, rvCholesky)
+		ix.NetReturns, err = ix.EvaluateFatCattleIndex(nYears)
 
 	case "slaughtercattle":
-		NetReturns = EvaluateSlaughterCattleIndex(nYears, burninMarker, param, gvCholesky, rvCholesky)
+		ix.NetReturns, err = ix.EvaluateSlaughterCattleIndex(nYears)
 	}
 
-	if *logger.OutputMode != "verbose" {
-		fmt.Printf("%f", NetReturns)
-	}
+	return ix.NetReturns, err
 }
 
 // Return the type of index the hjson builds
-func WhatSaleEndpoint() (indexType string) {
+func (ix *Index) WhatSaleEndpoint() (indexType string) {
 
-	indexType = string(ParamIndex["saleEndpoint"].(string))
-	if indexType == "" {
-		logger.LogWriterFatal("'IndexType:' key not found in ")
-	}
+	indexType, _ = ix.Param["saleEndpoint"].(string) // Checked by NewIndex
 
 	return indexType
 }
-func IsIndexTerminal() bool {
-	indexTerminal := ParamIndex["indexTerminal"].(bool)
+func (ix *Index) IsIndexTerminal() bool {
+	indexTerminal, _ := ix.Param["indexTerminal"].(bool)
 	return indexTerminal
 }
 
 // Setup the index traits
-func LoadIndexComponents() {
+func (ix *Index) LoadIndexComponents() error {
 
-	carray, ok := ParamIndex["indexComponents"].([]interface{})
+	carray, ok := ix.Param["indexComponents"].([]interface{})
 	if !ok {
-		logger.LogWriterFatal("'indexComponents:' key not found")
+		return &config.ParamError{Key: "indexComponents", Msg: "key not found"}
 	}
 	for i := range carray {
 
-		c := strings.Split(carray[i].(string), ",")
+		s, _ := carray[i].(string)
+		c := strings.Split(s, ",")
+		if len(c) < 2 {
+			return &config.ParamError{Key: "indexComponents", Row: i + 1, Msg: "\"" + s + "\" must be Trait,Comp"}
+		}
 		var e animal.Component_t
 
 		e.TraitName = strings.TrimSpace(c[0])
 		e.Component = strings.TrimSpace(c[1])
 
-		IndexComponents = append(IndexComponents, e)
+		ix.IndexComponents = append(ix.IndexComponents, e)
 
 	}
+	return nil
 }
 
 // Is this in the index
-func IsInIndex(b animal.Component_t) bool {
-	for _, c := range IndexComponents {
+func (ix *Index) IsInIndex(b animal.Component_t) bool {
+	for _, c := range ix.IndexComponents {
 		if b.TraitName == c.TraitName && b.Component == c.Component {
 			return true
 		}
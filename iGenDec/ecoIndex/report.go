@@ -0,0 +1,141 @@
+// report
+//
+// The revenue and cost tables of a valued simulation as one structure
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package ecoIndex
+
+import (
+	"math"
+
+	"github.com/blgolden/iGenDecModel/iGenDec/animal"
+)
+
+// Report is what ProcessNetReturns found, year by year
+type Report struct {
+	SaleEndpoint         string   `json:"saleEndpoint"`
+	Terminal             bool     `json:"terminal"`
+	DiscountRate         float64  `json:"discountRate"`
+	FirstYear            int      `json:"firstYear"` // First year of the net returns
+	LastYear             int      `json:"lastYear"`
+	Lines                []Line_t `json:"lines"`
+	NetReturnPerExposure float64  `json:"netReturnPerExposure"` // Discounted, averaged over the years
+}
+
+// One revenue or cost of a year
+type Line_t struct {
+	Year       int     `json:"year"`
+	Endpoint   string  `json:"endpoint"`      // weaning, background, fatcattle, slaughtercattle, cull, cow, breeding, sexedSemen, embryoTransfer, genomicTest or calvingEase
+	Sex        string  `json:"sex,omitempty"` // S=steer, F=heifer, C=cow
+	Type       string  `json:"type"`          // revenue or cost
+	Head       float64 `json:"head,omitempty"`
+	Weight     float64 `json:"weight,omitempty"` // Total weight sold
+	Amount     float64 `json:"amount"`
+	Discounted float64 `json:"discounted"`
+}
+
+// A sex's share of one of the ...ByYear tables
+type sexLine_t struct {
+	head, weight, amount, discounted float64
+}
+
+// Collect the tables left by ProcessNetReturns
+func (ix *Index) Report(nYears int) *Report {
+
+	r := new(Report)
+	r.SaleEndpoint = ix.IndexType
+	r.Terminal = ix.IndexTerminal
+	r.DiscountRate = ix.DiscountRate
+	r.FirstYear = ix.StartYearOfNetReturns
+	r.LastYear = nYears
+	r.NetReturnPerExposure = ix.NetReturns
+
+	add := func(y int, endpoint string, sex string, kind string, l sexLine_t) {
+		if l.amount == 0 && l.head == 0 {
+			return
+		}
+		r.Lines = append(r.Lines, Line_t{Year: y, Endpoint: endpoint, Sex: sex, Type: kind,
+			Head: l.head, Weight: l.weight, Amount: l.amount, Discounted: l.discounted})
+	}
+
+	for y := r.FirstYear; y <= r.LastYear; y++ {
+
+		if w, ok := ix.weaningGrossRevenueByYear[y]; ok {
+			add(y, "weaning", animal.Steer, "revenue", sexLine_t{w.nSteers, w.wtSteers, w.SteerRevenue, w.DiscountedSteerRevenue})
+			add(y, "weaning", animal.Heifer, "revenue", sexLine_t{w.nHeifers, w.wtHeifers, w.HeiferRevenue, w.DiscountedHeiferRevenue})
+		}
+		if c, ok := ix.weaningGrossCostsByYear[y]; ok {
+			add(y, "weaning", animal.Steer, "cost", sexLine_t{amount: c.SteerCosts, discounted: c.DiscountedSteerCosts})
+			add(y, "weaning", animal.Heifer, "cost", sexLine_t{amount: c.HeiferCosts, discounted: c.DiscountedHeiferCosts})
+		}
+
+		if w, ok := ix.backgroundingGrossRevenueByYear[y]; ok {
+			add(y, "background", animal.Steer, "revenue", sexLine_t{w.nSteers, w.wtSteers, w.SteerRevenue, w.DiscountedSteerRevenue})
+			add(y, "background", animal.Heifer, "revenue", sexLine_t{w.nHeifers, w.wtHeifers, w.HeiferRevenue, w.DiscountedHeiferRevenue})
+		}
+		if c, ok := ix.backgroundingGrossCostsByYear[y]; ok {
+			add(y, "background", animal.Steer, "cost", sexLine_t{amount: c.SteerCosts, discounted: c.DiscountedSteerCosts})
+			add(y, "background", animal.Heifer, "cost", sexLine_t{amount: c.HeiferCosts, discounted: c.DiscountedHeiferCosts})
+		}
+
+		if w, ok := ix.fatcattleGrossRevenueByYear[y]; ok {
+			add(y, "fatcattle", animal.Steer, "revenue", sexLine_t{w.nSteers, w.wtSteers, w.SteerRevenue, w.DiscountedSteerRevenue})
+			add(y, "fatcattle", animal.Heifer, "revenue", sexLine_t{w.nHeifers, w.wtHeifers, w.HeiferRevenue, w.DiscountedHeiferRevenue})
+		}
+		if c, ok := ix.fatcattleGrossCostsByYear[y]; ok {
+			add(y, "fatcattle", animal.Steer, "cost", sexLine_t{amount: c.SteerCosts, discounted: c.DiscountedSteerCosts})
+			add(y, "fatcattle", animal.Heifer, "cost", sexLine_t{amount: c.HeiferCosts, discounted: c.DiscountedHeiferCosts})
+		}
+
+		if w, ok := ix.slaughtercattleGrossRevenueByYear[y]; ok {
+			add(y, "slaughtercattle", animal.Steer, "revenue", sexLine_t{w.nSteers, w.wtSteers, w.SteerRevenue, w.DiscountedSteerRevenue})
+			add(y, "slaughtercattle", animal.Heifer, "revenue", sexLine_t{w.nHeifers, w.wtHeifers, w.HeiferRevenue, w.DiscountedHeiferRevenue})
+		}
+		if c, ok := ix.slaughtercattleGrossCostsByYear[y]; ok {
+			add(y, "slaughtercattle", animal.Steer, "cost", sexLine_t{amount: c.SteerCosts, discounted: c.DiscountedSteerCosts})
+			add(y, "slaughtercattle", animal.Heifer, "cost", sexLine_t{amount: c.HeiferCosts, discounted: c.DiscountedHeiferCosts})
+		}
+
+		if c, ok := ix.cullCowGrosRevenueByYear[y]; ok {
+			add(y, "cull", animal.Cow, "revenue", sexLine_t{c.nCowsOpen + c.nCowsOld + c.nHeifersSurplus, ix.Animals.WtCullCows[y].CumWt, c.CowRevenue, c.DiscountedCowRevenue})
+		}
+
+		if c, ok := ix.variableCostsByYearCows[y]; ok {
+			df := math.Pow(1.+ix.DiscountRate, float64(y-ix.StartYearOfNetReturns))
+			add(y, "cow", animal.Cow, "cost", sexLine_t{amount: c, discounted: c / df})
+		}
+
+		if c, ok := ix.Animals.BreedingCosts[y]; ok {
+			df := math.Pow(1.+ix.DiscountRate, float64(y-ix.StartYearOfNetReturns))
+			add(y, "breeding", animal.Cow, "cost", sexLine_t{head: float64(c.Synchronized), amount: c.Cost, discounted: c.Cost / df})
+			add(y, "sexedSemen", animal.Cow, "cost", sexLine_t{head: float64(c.Sexed), amount: c.SexedCost, discounted: c.SexedCost / df})
+			add(y, "embryoTransfer", animal.Cow, "cost", sexLine_t{head: float64(c.Transfers), amount: c.ETCost, discounted: c.ETCost / df})
+			add(y, "genomicTest", animal.Heifer, "cost", sexLine_t{head: float64(c.Tested), amount: c.TestCost, discounted: c.TestCost / df})
+		}
+
+		if c, ok := ix.Animals.CalvingCosts[y]; ok {
+			df := math.Pow(1.+ix.DiscountRate, float64(y-ix.StartYearOfNetReturns))
+			add(y, "calvingEase", animal.Cow, "cost", sexLine_t{head: float64(c.Calvings), amount: c.Labor + c.Vet, discounted: (c.Labor + c.Vet) / df})
+		}
+	}
+
+	return r
+}
@@ -26,12 +26,8 @@ import (
 	"fmt"
 
 	"github.com/blgolden/iGenDecModel/iGenDec/animal"
-	"github.com/blgolden/iGenDecModel/iGenDec/logger"
 
 	"math"
-	"math/rand"
-
-	"gonum.org/v1/gonum/mat"
 )
 
 type slaughtercattleGrossRevenueByYear_t struct {
@@ -53,28 +49,26 @@ type slaughtercattleGrossCostsByYear_t struct {
 	DiscountedHeiferCosts float64
 }
 
-var slaughtercattleGrossRevenueByYear map[int]slaughtercattleGrossRevenueByYear_t
-var slaughtercattleGrossCostsByYear map[int]slaughtercattleGrossCostsByYear_t
-
 // Return the price per lb type for a given calf
-func getPricePerPound(wt float64, sex string, trait string) float64 {
+func (ix *Index) getPricePerPound(wt float64, sex string, trait string) (float64, error) {
 
-	for _, c := range PriceTable {
+	for _, c := range ix.PriceTable {
 		//fmt.Println("LOC_1", c, wt, sex, trait)
 		if sex == c.Sex && trait == c.Trait && wt >= c.MinWt && wt < c.MaxWt {
-			return c.PricePerPound
+			return c.PricePerPound, nil
 		}
 	}
 
-	logger.LogWriterFatal("No price per pound found for " + sex + " " + trait)
-
-	return 0.0
+	return 0.0, &PriceError{Trait: trait, Sex: sex, Weight: wt}
 }
 
 // Calculate backgrounded animals total sale revenue
-func slaughtercattleSaleRevenue(calf animal.Animal) (salePrice float64) {
+func (ix *Index) slaughtercattleSaleRevenue(calf animal.Animal) (salePrice float64) {
 
-	pricePerPound := getPricePerPound(calf.CarcassWeight, calf.Sex, "SC")
+	pricePerPound, err := ix.getPricePerPound(calf.CarcassWeight, calf.Sex, "SC")
+	if err != nil {
+		ix.fail(err)
+	}
 
 	//fmt.Println("LOC 1", salePrice, weight, tsmm)
 
@@ -86,10 +80,10 @@ func slaughtercattleSaleRevenue(calf animal.Animal) (salePrice float64) {
 		(.32 * calf.RibEyArea)
 	//fmt.Println("LOC 2", calf.Id, calf.BackFatThickness, calf.CarcassWeight, calf.RibEyArea, calf.MarblingScore)
 
-	inp := rand.Float64()
+	inp := ix.Animals.Rng.Grid.Float64()
 
 	isInProgram := false // Is this calf in special program - e.g., CHB
-	if inp <= InProgramProportion {
+	if inp <= ix.InProgramProportion {
 		isInProgram = true
 	}
 
@@ -109,7 +103,7 @@ func slaughtercattleSaleRevenue(calf animal.Animal) (salePrice float64) {
 		var pValue GridValue_t
 		pValue.QualityGrade = "Program"
 		pValue.YieldGrade = yg
-		progPremium = gridPrice[pValue]
+		progPremium = ix.gridPrice[pValue]
 	}
 
 	if yg > 5 {
@@ -122,18 +116,18 @@ func slaughtercattleSaleRevenue(calf animal.Animal) (salePrice float64) {
 	gridValue.QualityGrade = qg
 	gridValue.YieldGrade = yg
 
-	if animal.CarcassPhenotypeFile != nil {
-		fmt.Fprintln(animal.CarcassPhenotypeFile, calf.Id, calf.YearBorn, calf.CarcassWeight, qg, yg, pricePerPound, gridPrice[gridValue], progPremium, calf.BackFatThickness, calf.RibEyArea, calf.MarblingScore)
+	if ix.Animals.CarcassPhenotypeFile != nil {
+		fmt.Fprintln(ix.Animals.CarcassPhenotypeFile, calf.Id, calf.YearBorn, calf.CarcassWeight, qg, yg, pricePerPound, ix.gridPrice[gridValue], progPremium, calf.BackFatThickness, calf.RibEyArea, calf.MarblingScore)
 	}
-	return calf.CarcassWeight * (pricePerPound + gridPrice[gridValue] + progPremium)
+	return calf.CarcassWeight * (pricePerPound + ix.gridPrice[gridValue] + progPremium)
 }
 
 // Revenue from sale of fed cattle
-func calculateSlaughtercattleRevenueByYear() {
+func (ix *Index) calculateSlaughtercattleRevenueByYear() {
 
-	slaughtercattleGrossRevenueByYear = make(map[int]slaughtercattleGrossRevenueByYear_t)
+	ix.slaughtercattleGrossRevenueByYear = make(map[int]slaughtercattleGrossRevenueByYear_t)
 
-	for _, calf := range animal.Records {
+	for _, calf := range ix.Animals.Records {
 
 		// Doing this because the distribution for CD is not initialized until after year 1
 		// Should probably discard entire year 1 or more results
@@ -141,156 +135,167 @@ func calculateSlaughtercattleRevenueByYear() {
 			calf.Dead = 0
 		}
 
-		yearHarvested := int(float64(calf.BirthDate)+205.+BackgroundDays+animal.DaysOnFeed) / 365
+		yearHarvested := int(float64(calf.BirthDate)+205.+ix.BackgroundDays+ix.Animals.DaysOnFeed) / 365
 		if calf.YearBorn >= 1 && calf.Dead == 0 {
 			if calf.Sex == animal.Steer {
 
-				w := slaughtercattleGrossRevenueByYear[yearHarvested]
+				w := ix.slaughtercattleGrossRevenueByYear[yearHarvested]
 				w.nSteers++
-				w.SteerRevenue += slaughtercattleSaleRevenue(calf)
+				w.SteerRevenue += ix.slaughtercattleSaleRevenue(calf)
 				p := calf.HarvestWeight
 				w.wtSteers += p
-				slaughtercattleGrossRevenueByYear[yearHarvested] = w
+				ix.slaughtercattleGrossRevenueByYear[yearHarvested] = w
 
 			} else if calf.Sex == animal.Heifer {
 
-				w := slaughtercattleGrossRevenueByYear[yearHarvested]
+				w := ix.slaughtercattleGrossRevenueByYear[yearHarvested]
 				w.nHeifers++
-				w.HeiferRevenue += slaughtercattleSaleRevenue(calf)
+				w.HeiferRevenue += ix.slaughtercattleSaleRevenue(calf)
 				p := calf.HarvestWeight
 				w.wtHeifers += p
-				slaughtercattleGrossRevenueByYear[yearHarvested] = w
+				ix.slaughtercattleGrossRevenueByYear[yearHarvested] = w
 			}
 		} else {
-			w := slaughtercattleGrossRevenueByYear[yearHarvested]
+			w := ix.slaughtercattleGrossRevenueByYear[yearHarvested]
 			w.nDead++
-			slaughtercattleGrossRevenueByYear[yearHarvested] = w
+			ix.slaughtercattleGrossRevenueByYear[yearHarvested] = w
 		}
 	}
 	return
 }
 
 // Determine the discounted value of the revenue
-func calculateDiscountedSlaughtercattleRevenueByYear(nYears int) {
-	for y := animal.Burnin + 1; y <= nYears; y++ {
+func (ix *Index) calculateDiscountedSlaughtercattleRevenueByYear(nYears int) {
+	for y := ix.Animals.Burnin + 1; y <= nYears; y++ {
 
-		period := float64(y - StartYearOfNetReturns)
+		period := float64(y - ix.StartYearOfNetReturns)
 
-		b := slaughtercattleGrossRevenueByYear[y]
+		b := ix.slaughtercattleGrossRevenueByYear[y]
 
-		df := math.Pow(1.+DiscountRate, period)
+		df := math.Pow(1.+ix.DiscountRate, period)
 
 		b.DiscountedSteerRevenue = b.SteerRevenue / df
 		b.DiscountedHeiferRevenue = b.HeiferRevenue / df
 
-		slaughtercattleGrossRevenueByYear[y] = b
+		ix.slaughtercattleGrossRevenueByYear[y] = b
 	}
 }
 
 // Value calves as fed cattle
-func slaughtercattleSale(nYears int) float64 {
+func (ix *Index) slaughtercattleSale(nYears int) float64 {
 
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 		fmt.Println("Processing slaughtercattle sale net returns...")
 	}
 
-	calculateWeaningCostsByYear()
-	calculateDiscountedWeaningCostsByYear(nYears)
+	ix.calculateWeaningCostsByYear()
+	ix.calculateDiscountedWeaningCostsByYear(nYears)
 
-	calculateBackgroundingCostsByYear()
-	calculateDiscountedBackgroundingCostsByYear(nYears)
+	ix.calculateBackgroundingCostsByYear()
+	ix.calculateDiscountedBackgroundingCostsByYear(nYears)
 
-	calculateSlaughtercattleRevenueByYear()
-	calculateDiscountedSlaughtercattleRevenueByYear(nYears)
+	ix.calculateSlaughtercattleRevenueByYear()
+	ix.calculateDiscountedSlaughtercattleRevenueByYear(nYears)
 
-	calculateSlaughtercattleCostsByYear()
-	calculateDiscountedSlaughtercattleCostsByYear(nYears)
+	ix.calculateSlaughtercattleCostsByYear()
+	ix.calculateDiscountedSlaughtercattleCostsByYear(nYears)
 
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 		fmt.Println("\nRevenue from slaughtercattle calf sales:")
 		fmt.Println("Sale Year    n Steers	Steer $     Wt Steers   n Heifers      Heifer $    Wt Heifers    n Dead")
 		for year := 1; year <= nYears; year++ {
-			fmt.Printf("  %5d      %5d %12.2f  %12.1f       %5d  %12.2f  %12.1f   %7d\n", year, int(slaughtercattleGrossRevenueByYear[year].nSteers),
-				slaughtercattleGrossRevenueByYear[year].SteerRevenue, slaughtercattleGrossRevenueByYear[year].wtSteers,
-				int(slaughtercattleGrossRevenueByYear[year].nHeifers), slaughtercattleGrossRevenueByYear[year].HeiferRevenue, slaughtercattleGrossRevenueByYear[year].wtHeifers,
-				slaughtercattleGrossRevenueByYear[year].nDead)
+			fmt.Printf("  %5d      %5d %12.2f  %12.1f       %5d  %12.2f  %12.1f   %7d\n", year, int(ix.slaughtercattleGrossRevenueByYear[year].nSteers),
+				ix.slaughtercattleGrossRevenueByYear[year].SteerRevenue, ix.slaughtercattleGrossRevenueByYear[year].wtSteers,
+				int(ix.slaughtercattleGrossRevenueByYear[year].nHeifers), ix.slaughtercattleGrossRevenueByYear[year].HeiferRevenue, ix.slaughtercattleGrossRevenueByYear[year].wtHeifers,
+				ix.slaughtercattleGrossRevenueByYear[year].nDead)
 		}
 	}
 
-	return slaughtercattleNetReturnsToFixedCosts(nYears) // This returns total accumulated net returns/mating
-	return 0.0
+	return ix.slaughtercattleNetReturnsToFixedCosts(nYears) // This returns total accumulated net returns/mating
 }
 
 // Process an index with sale as finished cattle
-func EvaluateSlaughterCattleIndex(nYears int, burninMarker int, param map[string]interface{}, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) float64 {
+func (ix *Index) EvaluateSlaughterCattleIndex(nYears int) (float64, error) {
 
 	var base animal.Component_t
 	base.Component = "D"
 	base.TraitName = "base"
 
-	IndexNetReturns := slaughtercattleSale(nYears) // Discounted and per mating
+	IndexNetReturns := ix.slaughtercattleSale(nYears) // Discounted and per mating
 	n := IndexNetReturns
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 		fmt.Printf("Total Slaughter Sale Revenue: %f\n\n", IndexNetReturns)
 	}
 
-	IndexNetReturns += cullSale(nYears) // Discounted and per mating
-	if *logger.OutputMode == "verbose" {
+	IndexNetReturns += ix.cullSale(nYears) // Discounted and per mating
+	if ix.OutputMode == "verbose" {
 		fmt.Printf("Cull gross revenue: %f\n\n", IndexNetReturns-n)
 
 	}
 
-	if !IsIndexTerminal() {
+	if !ix.IsIndexTerminal() {
 		n = IndexNetReturns
-		IndexNetReturns -= CowCosts(nYears)
-		if *logger.OutputMode == "verbose" {
+		IndexNetReturns -= ix.CowCosts(nYears)
+		if ix.OutputMode == "verbose" {
 			fmt.Printf("Cow costs: (%f)\n\n", n-IndexNetReturns)
 		}
 	}
 
-	if *logger.OutputMode == "verbose" {
-		fmt.Printf("\nPlanning Horizon (in years):                                            %12d\n", nYears-StartYearOfNetReturns+1)
+	n = IndexNetReturns
+	IndexNetReturns -= ix.BreedingCosts(nYears)
+	if ix.OutputMode == "verbose" && n != IndexNetReturns {
+		fmt.Printf("Synchronization, AI, sexed semen and embryo transfer costs: (%f)\n\n", n-IndexNetReturns)
+	}
+
+	n = IndexNetReturns
+	IndexNetReturns -= ix.CalvingCosts(nYears)
+	if ix.OutputMode == "verbose" && n != IndexNetReturns {
+		fmt.Printf("Calving ease costs: (%f)\n\n", n-IndexNetReturns)
+	}
+
+	if ix.OutputMode == "verbose" {
+		fmt.Printf("\nPlanning Horizon (in years):                                            %12d\n", nYears-ix.StartYearOfNetReturns+1)
 		//fmt.Printf("%d Year Discounted Net Returns to land, management and labor per exp accum: %12.2f\n", nYears-StartYearOfNetReturns+1, NetReturns)
-		fmt.Printf("%d year Discounted Net Returns to land, management and labor per exposure:  %12.2f\n", nYears-StartYearOfNetReturns+1,
+		fmt.Printf("%d year Discounted Net Returns to land, management and labor per exposure:  %12.2f\n", nYears-ix.StartYearOfNetReturns+1,
 			IndexNetReturns)
 		fmt.Println("NOTE: all net values are returns to land, management and labor")
 	}
 
-	return IndexNetReturns
+	return IndexNetReturns, ix.Err()
 }
 
 // Determine the discounted value of the costs
-func calculateDiscountedSlaughtercattleCostsByYear(nYears int) {
-	for y := animal.Burnin + 1; y <= nYears; y++ {
+func (ix *Index) calculateDiscountedSlaughtercattleCostsByYear(nYears int) {
+	for y := ix.Animals.Burnin + 1; y <= nYears; y++ {
 
-		period := float64(y - StartYearOfNetReturns)
+		period := float64(y - ix.StartYearOfNetReturns)
 
-		df := math.Pow(1.+DiscountRate, period)
+		df := math.Pow(1.+ix.DiscountRate, period)
 
-		c := slaughtercattleGrossCostsByYear[y]
+		c := ix.slaughtercattleGrossCostsByYear[y]
 
 		c.DiscountedSteerCosts = c.SteerCosts / df
 		c.DiscountedHeiferCosts = c.HeiferCosts / df
 
-		slaughtercattleGrossCostsByYear[y] = c
+		ix.slaughtercattleGrossCostsByYear[y] = c
 	}
 }
 
 // Calculate cost to feed each year
-func calculateSlaughtercattleCostsByYear() {
+func (ix *Index) calculateSlaughtercattleCostsByYear() {
 
-	slaughtercattleGrossCostsByYear = make(map[int]slaughtercattleGrossCostsByYear_t)
+	ix.slaughtercattleGrossCostsByYear = make(map[int]slaughtercattleGrossCostsByYear_t)
 
-	for _, calf := range animal.Records {
+	for _, calf := range ix.Animals.Records {
 		if calf.YearBorn >= 1 && calf.Dead == 0 {
 			if calf.Sex == animal.Steer { // Otherwise its a cow and was not fed
-				c := slaughtercattleGrossCostsByYear[calf.YearBorn+1]
-				c.SteerCosts += calf.FeedlotTotalFeedIntake * FeedlotFeedCost
-				slaughtercattleGrossCostsByYear[calf.YearBorn+1] = c
+				c := ix.slaughtercattleGrossCostsByYear[calf.YearBorn+1]
+				c.SteerCosts += calf.FeedlotTotalFeedIntake * ix.FeedlotFeedCost
+				ix.slaughtercattleGrossCostsByYear[calf.YearBorn+1] = c
 			} else if calf.Sex == animal.Heifer {
-				c := slaughtercattleGrossCostsByYear[calf.YearBorn+1]
-				c.HeiferCosts += calf.FeedlotTotalFeedIntake * FeedlotFeedCost
-				slaughtercattleGrossCostsByYear[calf.YearBorn+1] = c
+				c := ix.slaughtercattleGrossCostsByYear[calf.YearBorn+1]
+				c.HeiferCosts += calf.FeedlotTotalFeedIntake * ix.FeedlotFeedCost
+				ix.slaughtercattleGrossCostsByYear[calf.YearBorn+1] = c
 
 			}
 		}
@@ -298,47 +303,48 @@ func calculateSlaughtercattleCostsByYear() {
 	return
 }
 
-//  The total discounted net returns to fixed costs for feeding cattle
+//	The total discounted net returns to fixed costs for feeding cattle
+//
 // and optionally write a table to stdout
 // Returns average net returns to LML/mating
-func slaughtercattleNetReturnsToFixedCosts(nYears int) float64 {
+func (ix *Index) slaughtercattleNetReturnsToFixedCosts(nYears int) float64 {
 
 	var TotalDiscountedRevenue float64
 	var TotalDiscountedNetRevenuePerMating float64
 
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 		fmt.Println("\nDiscounted Returns and Costs for Finished Cattle:")
 		fmt.Println("       Returns_____________________  Costs of Finishing_______      Costs of backgrounding______    Costs of weaning_________")
 		fmt.Println("Year    $ Actual     $ Discounted    $ Actual     $ Discounted      $ Actual     $ Discounted       $ Actual     $ Discounted     $ Net/Exposure  N Cows Exposed")
 	}
 
-	for y := StartYearOfNetReturns; y <= nYears; y++ {
+	for y := ix.StartYearOfNetReturns; y <= nYears; y++ {
 
-		TotalDiscountedRevenue += slaughtercattleGrossRevenueByYear[y].DiscountedSteerRevenue + slaughtercattleGrossRevenueByYear[y].DiscountedHeiferRevenue
+		TotalDiscountedRevenue += ix.slaughtercattleGrossRevenueByYear[y].DiscountedSteerRevenue + ix.slaughtercattleGrossRevenueByYear[y].DiscountedHeiferRevenue
 
-		netPerExposure := (slaughtercattleGrossRevenueByYear[y].DiscountedSteerRevenue + slaughtercattleGrossRevenueByYear[y].DiscountedHeiferRevenue -
-			slaughtercattleGrossCostsByYear[y].DiscountedSteerCosts - slaughtercattleGrossCostsByYear[y].DiscountedHeiferCosts -
-			backgroundingGrossCostsByYear[y].DiscountedSteerCosts - backgroundingGrossCostsByYear[y].DiscountedHeiferCosts -
-			weaningGrossCostsByYear[y].DiscountedSteerCosts - weaningGrossCostsByYear[y].DiscountedHeiferCosts) /
-			float64(animal.CowsExposedPerYear[y])
+		netPerExposure := (ix.slaughtercattleGrossRevenueByYear[y].DiscountedSteerRevenue + ix.slaughtercattleGrossRevenueByYear[y].DiscountedHeiferRevenue -
+			ix.slaughtercattleGrossCostsByYear[y].DiscountedSteerCosts - ix.slaughtercattleGrossCostsByYear[y].DiscountedHeiferCosts -
+			ix.backgroundingGrossCostsByYear[y].DiscountedSteerCosts - ix.backgroundingGrossCostsByYear[y].DiscountedHeiferCosts -
+			ix.weaningGrossCostsByYear[y].DiscountedSteerCosts - ix.weaningGrossCostsByYear[y].DiscountedHeiferCosts) /
+			float64(ix.Animals.CowsExposedPerYear[y])
 
 		TotalDiscountedNetRevenuePerMating += netPerExposure
 
-		if *logger.OutputMode == "verbose" {
+		if ix.OutputMode == "verbose" {
 			fmt.Printf("%5d  %10.2f    %10.2f     %10.2f    %10.2f     %10.2f   %10.2f         %10.2f   %10.2f         %10.2f       %7d\n", y,
-				slaughtercattleGrossRevenueByYear[y].SteerRevenue+slaughtercattleGrossRevenueByYear[y].HeiferRevenue,
-				slaughtercattleGrossRevenueByYear[y].DiscountedSteerRevenue+slaughtercattleGrossRevenueByYear[y].DiscountedHeiferRevenue,
-				slaughtercattleGrossCostsByYear[y].SteerCosts+slaughtercattleGrossCostsByYear[y].HeiferCosts,
-				slaughtercattleGrossCostsByYear[y].DiscountedSteerCosts+slaughtercattleGrossCostsByYear[y].DiscountedHeiferCosts,
-				backgroundingGrossCostsByYear[y].SteerCosts+backgroundingGrossCostsByYear[y].HeiferCosts,
-				backgroundingGrossCostsByYear[y].DiscountedSteerCosts+backgroundingGrossCostsByYear[y].DiscountedHeiferCosts,
-				weaningGrossCostsByYear[y].SteerCosts+weaningGrossCostsByYear[y].HeiferCosts,
-				weaningGrossCostsByYear[y].DiscountedSteerCosts+weaningGrossCostsByYear[y].DiscountedHeiferCosts,
+				ix.slaughtercattleGrossRevenueByYear[y].SteerRevenue+ix.slaughtercattleGrossRevenueByYear[y].HeiferRevenue,
+				ix.slaughtercattleGrossRevenueByYear[y].DiscountedSteerRevenue+ix.slaughtercattleGrossRevenueByYear[y].DiscountedHeiferRevenue,
+				ix.slaughtercattleGrossCostsByYear[y].SteerCosts+ix.slaughtercattleGrossCostsByYear[y].HeiferCosts,
+				ix.slaughtercattleGrossCostsByYear[y].DiscountedSteerCosts+ix.slaughtercattleGrossCostsByYear[y].DiscountedHeiferCosts,
+				ix.backgroundingGrossCostsByYear[y].SteerCosts+ix.backgroundingGrossCostsByYear[y].HeiferCosts,
+				ix.backgroundingGrossCostsByYear[y].DiscountedSteerCosts+ix.backgroundingGrossCostsByYear[y].DiscountedHeiferCosts,
+				ix.weaningGrossCostsByYear[y].SteerCosts+ix.weaningGrossCostsByYear[y].HeiferCosts,
+				ix.weaningGrossCostsByYear[y].DiscountedSteerCosts+ix.weaningGrossCostsByYear[y].DiscountedHeiferCosts,
 				netPerExposure,
-				animal.CowsExposedPerYear[y])
+				ix.Animals.CowsExposedPerYear[y])
 		}
 
 	}
 
-	return TotalDiscountedNetRevenuePerMating / float64(nYears-StartYearOfNetReturns+1)
+	return TotalDiscountedNetRevenuePerMating / float64(nYears-ix.StartYearOfNetReturns+1)
 }
@@ -26,16 +26,9 @@ import (
 	"math"
 
 	"github.com/blgolden/iGenDecModel/iGenDec/animal"
-	"github.com/blgolden/iGenDecModel/iGenDec/logger"
-
-	"gonum.org/v1/gonum/mat"
+	"github.com/blgolden/iGenDecModel/iGenDec/config"
 )
 
-//var grossRevenueByYearWeanedCalves []float64 // Returns
-var variableCostsByYearWeanedCalves []float64
-
-var variableCostsByYearCows map[int]float64
-
 type weaningGrossRevenueByYear_t struct {
 	nSteers                 float64
 	SteerRevenue            float64
@@ -55,41 +48,40 @@ type weaningGrossCostsByYear_t struct {
 	DiscountedHeiferCosts float64
 }
 
-var weaningGrossRevenueByYear map[int]weaningGrossRevenueByYear_t
-var weaningGrossCostsByYear map[int]weaningGrossCostsByYear_t
-
 type cullCowRevenueByYear_t struct {
 	nCowsOpen            float64
 	nCowsOld             float64
+	nHeifersSurplus      float64
 	CowRevenue           float64
 	DiscountedCowRevenue float64
 }
 
-var cullCowGrosRevenueByYear map[int]cullCowRevenueByYear_t
-
 // calculate the cull cow sales revenue
 
 // Calculate animals total weaning sale revenue
-func weaningSaleRevenue(calf animal.Animal) (salePrice float64) {
+func (ix *Index) weaningSaleRevenue(calf animal.Animal) (salePrice float64) {
 
-	weight, ok := animal.WeaningWtPhenotype(calf)
+	weight, ok := ix.Animals.WeaningWtPhenotype(calf)
 
 	if !ok {
 		fmt.Println("Calf ", calf.Id, "can't get weaning weight. YearBorn:", calf.YearBorn)
 	}
 
-	pricePerPound := getPricePerPound(weight, calf.Sex, "WW")
+	pricePerPound, err := ix.getPricePerPound(weight, calf.Sex, "WW")
+	if err != nil {
+		ix.fail(err)
+	}
 	salePrice = weight * pricePerPound
 
 	return salePrice
 }
 
 // Cost to raise a weanling calf by year
-func calculateWeaningCostsByYear() {
+func (ix *Index) calculateWeaningCostsByYear() {
 
-	weaningGrossCostsByYear = make(map[int]weaningGrossCostsByYear_t)
+	ix.weaningGrossCostsByYear = make(map[int]weaningGrossCostsByYear_t)
 
-	for _, calf := range animal.Records {
+	for _, calf := range ix.Animals.Records {
 
 		/*if calf.YearBorn == 1 {
 			calf.Dead = 0
@@ -101,15 +93,15 @@ func calculateWeaningCostsByYear() {
 
 				for _, a := range calf.AumToWeaning {
 					//c := weaningGrossCostsByYear[a.Year]
-					c := weaningGrossCostsByYear[yearWeaned]
-					c.SteerCosts += a.Aum * AumCost[a.MonthOfYear-1]
-					weaningGrossCostsByYear[yearWeaned] = c
+					c := ix.weaningGrossCostsByYear[yearWeaned]
+					c.SteerCosts += a.Aum * ix.AumCost[a.MonthOfYear-1]
+					ix.weaningGrossCostsByYear[yearWeaned] = c
 				}
 			} else if calf.Sex == animal.Heifer {
 				for _, a := range calf.AumToWeaning {
-					c := weaningGrossCostsByYear[yearWeaned]
-					c.HeiferCosts += a.Aum * AumCost[a.MonthOfYear-1]
-					weaningGrossCostsByYear[yearWeaned] = c
+					c := ix.weaningGrossCostsByYear[yearWeaned]
+					c.HeiferCosts += a.Aum * ix.AumCost[a.MonthOfYear-1]
+					ix.weaningGrossCostsByYear[yearWeaned] = c
 				}
 			}
 		}
@@ -118,188 +110,189 @@ func calculateWeaningCostsByYear() {
 }
 
 // Revenue from sale of weaning calves
-func calculateWeaningRevenueByYear() {
+func (ix *Index) calculateWeaningRevenueByYear() {
 
-	weaningGrossRevenueByYear = make(map[int]weaningGrossRevenueByYear_t)
+	ix.weaningGrossRevenueByYear = make(map[int]weaningGrossRevenueByYear_t)
 
-	for _, calf := range animal.Records {
+	for _, calf := range ix.Animals.Records {
 
 		yearWeaned := int(calf.BirthDate+205) / 365
 		if calf.YearBorn >= 1 && calf.Dead == 0 {
 			if calf.Sex == animal.Steer {
 
-				w := weaningGrossRevenueByYear[yearWeaned]
+				w := ix.weaningGrossRevenueByYear[yearWeaned]
 				w.nSteers++
-				w.SteerRevenue += weaningSaleRevenue(calf)
-				p, _ := animal.Phenotype(calf, "WW")
+				w.SteerRevenue += ix.weaningSaleRevenue(calf)
+				p, _ := ix.Animals.Phenotype(calf, "WW")
 				w.wtSteers += p
-				weaningGrossRevenueByYear[yearWeaned] = w
+				ix.weaningGrossRevenueByYear[yearWeaned] = w
 
 			} else if calf.Sex == animal.Heifer { // This works because sex has been set to Cow if she became a replacement
 
-				w := weaningGrossRevenueByYear[yearWeaned]
+				w := ix.weaningGrossRevenueByYear[yearWeaned]
 				w.nHeifers++
-				w.HeiferRevenue += weaningSaleRevenue(calf)
-				p, _ := animal.Phenotype(calf, "WW")
+				w.HeiferRevenue += ix.weaningSaleRevenue(calf)
+				p, _ := ix.Animals.Phenotype(calf, "WW")
 				w.wtHeifers += p
-				weaningGrossRevenueByYear[yearWeaned] = w
+				ix.weaningGrossRevenueByYear[yearWeaned] = w
 			}
 		} else {
-			w := weaningGrossRevenueByYear[yearWeaned]
+			w := ix.weaningGrossRevenueByYear[yearWeaned]
 			w.nDead++
-			weaningGrossRevenueByYear[yearWeaned] = w
+			ix.weaningGrossRevenueByYear[yearWeaned] = w
 		}
 	}
 	return
 }
 
 // Determine the discounted value of the revenue
-func calculateDiscountedWeaningRevenueByYear(nYears int) {
-	for y := animal.Burnin + 1; y <= nYears; y++ {
+func (ix *Index) calculateDiscountedWeaningRevenueByYear(nYears int) {
+	for y := ix.Animals.Burnin + 1; y <= nYears; y++ {
 
-		period := float64(y - StartYearOfNetReturns)
+		period := float64(y - ix.StartYearOfNetReturns)
 
-		w := weaningGrossRevenueByYear[y]
+		w := ix.weaningGrossRevenueByYear[y]
 
-		df := math.Pow(1.+DiscountRate, period)
+		df := math.Pow(1.+ix.DiscountRate, period)
 
 		w.DiscountedSteerRevenue = w.SteerRevenue / df
 		w.DiscountedHeiferRevenue = w.HeiferRevenue / df
 
-		weaningGrossRevenueByYear[y] = w
+		ix.weaningGrossRevenueByYear[y] = w
 	}
 }
 
 // Determine the discounted value of the costs
-func calculateDiscountedWeaningCostsByYear(nYears int) {
-	for y := animal.Burnin + 1; y <= nYears; y++ {
+func (ix *Index) calculateDiscountedWeaningCostsByYear(nYears int) {
+	for y := ix.Animals.Burnin + 1; y <= nYears; y++ {
 
-		period := float64(y - StartYearOfNetReturns)
+		period := float64(y - ix.StartYearOfNetReturns)
 
-		df := math.Pow(1.+DiscountRate, period)
+		df := math.Pow(1.+ix.DiscountRate, period)
 
-		c := weaningGrossCostsByYear[y]
+		c := ix.weaningGrossCostsByYear[y]
 
 		c.DiscountedSteerCosts = c.SteerCosts / df
 		c.DiscountedHeiferCosts = c.HeiferCosts / df
 
-		weaningGrossCostsByYear[y] = c
+		ix.weaningGrossCostsByYear[y] = c
 	}
 }
 
 // This is used to caalculate the weaning costs per year for
 // all indexes except weaning, because weaning does it below in the
 // net returns calculation.
-func WeaningVariableCostsPerMating(nYears int) float64 {
+func (ix *Index) WeaningVariableCostsPerMating(nYears int) float64 {
 
 	var TotalDiscountedCosts float64
 	var TotalDiscountedCostsPerMating float64
 
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 		fmt.Println("\nDiscounted Costs of Weanling Calf:")
 		fmt.Println("       Costs_______________________ ")
 		fmt.Println("Year    $ Actual     $ Discounted  $ cum/Exposure  N Cows Exposed")
 	}
 
-	for y := StartYearOfNetReturns; y <= nYears; y++ {
+	for y := ix.StartYearOfNetReturns; y <= nYears; y++ {
 
-		TotalDiscountedCosts += weaningGrossCostsByYear[y].DiscountedSteerCosts + weaningGrossCostsByYear[y].DiscountedHeiferCosts
+		TotalDiscountedCosts += ix.weaningGrossCostsByYear[y].DiscountedSteerCosts + ix.weaningGrossCostsByYear[y].DiscountedHeiferCosts
 
-		TotalDiscountedCostsPerMating += (weaningGrossCostsByYear[y].DiscountedSteerCosts + weaningGrossCostsByYear[y].DiscountedHeiferCosts) /
-			float64(animal.CowsExposedPerYear[y])
+		TotalDiscountedCostsPerMating += (ix.weaningGrossCostsByYear[y].DiscountedSteerCosts + ix.weaningGrossCostsByYear[y].DiscountedHeiferCosts) /
+			float64(ix.Animals.CowsExposedPerYear[y])
 
-		if *logger.OutputMode == "verbose" {
+		if ix.OutputMode == "verbose" {
 			fmt.Printf("%5d  %10.2f    %10.2f     %10.2f       %7d\n", y,
-				weaningGrossCostsByYear[y].SteerCosts+weaningGrossCostsByYear[y].HeiferCosts,
-				weaningGrossCostsByYear[y].DiscountedSteerCosts+weaningGrossCostsByYear[y].DiscountedHeiferCosts,
+				ix.weaningGrossCostsByYear[y].SteerCosts+ix.weaningGrossCostsByYear[y].HeiferCosts,
+				ix.weaningGrossCostsByYear[y].DiscountedSteerCosts+ix.weaningGrossCostsByYear[y].DiscountedHeiferCosts,
 				TotalDiscountedCostsPerMating,
-				animal.CowsExposedPerYear[y])
+				ix.Animals.CowsExposedPerYear[y])
 		}
 
 	}
 
-	return TotalDiscountedCostsPerMating / float64(nYears-StartYearOfNetReturns+1)
+	return TotalDiscountedCostsPerMating / float64(nYears-ix.StartYearOfNetReturns+1)
 }
 
-//  The total discounted net returns to fixed costs for weaning
+//	The total discounted net returns to fixed costs for weaning
+//
 // and optionally write a table to stdout
 // Returns average cost/mating
-func WeaningNetReturnsToFixedCosts(nYears int) float64 {
+func (ix *Index) WeaningNetReturnsToFixedCosts(nYears int) float64 {
 
 	var TotalDiscountedRevenue float64
 	var TotalDiscountedNetRevenuePerMating float64
 
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 		fmt.Println("\nReturns and Costs from Weanling Calf Sales:")
 		fmt.Println("       Returns_____________________  Costs_______________________ ")
 		fmt.Println("Year    $ Actual     $ Discounted    $ Actual     $ Discounted  $ Net/Exposure  N Cows Exposed")
 	}
 
-	for y := StartYearOfNetReturns; y <= nYears; y++ {
+	for y := ix.StartYearOfNetReturns; y <= nYears; y++ {
 
-		TotalDiscountedRevenue += weaningGrossRevenueByYear[y].DiscountedSteerRevenue + weaningGrossRevenueByYear[y].DiscountedHeiferRevenue
+		TotalDiscountedRevenue += ix.weaningGrossRevenueByYear[y].DiscountedSteerRevenue + ix.weaningGrossRevenueByYear[y].DiscountedHeiferRevenue
 
-		netPerExposure := (weaningGrossRevenueByYear[y].DiscountedSteerRevenue + weaningGrossRevenueByYear[y].DiscountedHeiferRevenue -
-			weaningGrossCostsByYear[y].DiscountedSteerCosts - weaningGrossCostsByYear[y].DiscountedHeiferCosts) /
-			float64(animal.CowsExposedPerYear[y])
+		netPerExposure := (ix.weaningGrossRevenueByYear[y].DiscountedSteerRevenue + ix.weaningGrossRevenueByYear[y].DiscountedHeiferRevenue -
+			ix.weaningGrossCostsByYear[y].DiscountedSteerCosts - ix.weaningGrossCostsByYear[y].DiscountedHeiferCosts) /
+			float64(ix.Animals.CowsExposedPerYear[y])
 
 		TotalDiscountedNetRevenuePerMating += netPerExposure
 
-		if *logger.OutputMode == "verbose" {
+		if ix.OutputMode == "verbose" {
 			fmt.Printf("%5d  %10.2f    %10.2f     %10.2f   %10.2f         %10.2f       %7d\n", y,
-				weaningGrossRevenueByYear[y].SteerRevenue+weaningGrossRevenueByYear[y].HeiferRevenue,
-				weaningGrossRevenueByYear[y].DiscountedSteerRevenue+weaningGrossRevenueByYear[y].DiscountedHeiferRevenue,
-				weaningGrossCostsByYear[y].SteerCosts+weaningGrossCostsByYear[y].HeiferCosts,
-				weaningGrossCostsByYear[y].DiscountedSteerCosts+weaningGrossCostsByYear[y].DiscountedHeiferCosts,
+				ix.weaningGrossRevenueByYear[y].SteerRevenue+ix.weaningGrossRevenueByYear[y].HeiferRevenue,
+				ix.weaningGrossRevenueByYear[y].DiscountedSteerRevenue+ix.weaningGrossRevenueByYear[y].DiscountedHeiferRevenue,
+				ix.weaningGrossCostsByYear[y].SteerCosts+ix.weaningGrossCostsByYear[y].HeiferCosts,
+				ix.weaningGrossCostsByYear[y].DiscountedSteerCosts+ix.weaningGrossCostsByYear[y].DiscountedHeiferCosts,
 				netPerExposure,
-				animal.CowsExposedPerYear[y])
+				ix.Animals.CowsExposedPerYear[y])
 		}
 
 	}
 
-	return TotalDiscountedNetRevenuePerMating / float64(nYears-StartYearOfNetReturns+1)
+	return TotalDiscountedNetRevenuePerMating / float64(nYears-ix.StartYearOfNetReturns+1)
 }
 
 // Value calves at weaning
-func weaningSale(nYears int) float64 {
+func (ix *Index) weaningSale(nYears int) float64 {
 
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 		fmt.Println("Processing weaning sale net returns...")
 	}
 
-	calculateWeaningRevenueByYear()
-	calculateDiscountedWeaningRevenueByYear(nYears)
-	calculateWeaningCostsByYear()
-	calculateDiscountedWeaningCostsByYear(nYears)
+	ix.calculateWeaningRevenueByYear()
+	ix.calculateDiscountedWeaningRevenueByYear(nYears)
+	ix.calculateWeaningCostsByYear()
+	ix.calculateDiscountedWeaningCostsByYear(nYears)
 
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 		fmt.Println("\nRevenue from weaning calf sales:")
 		fmt.Println("Sale Year    n Steers	Steer $     Wt Steers   n Heifers      Heifer $    Wt Heifers    n Dead")
 		for year := 1; year <= nYears; year++ {
-			fmt.Printf("  %5d      %5d %12.2f  %12.1f       %5d  %12.2f  %12.1f   %7d\n", year, int(weaningGrossRevenueByYear[year].nSteers),
-				weaningGrossRevenueByYear[year].SteerRevenue, weaningGrossRevenueByYear[year].wtSteers,
-				int(weaningGrossRevenueByYear[year].nHeifers), weaningGrossRevenueByYear[year].HeiferRevenue, weaningGrossRevenueByYear[year].wtHeifers,
-				weaningGrossRevenueByYear[year].nDead)
+			fmt.Printf("  %5d      %5d %12.2f  %12.1f       %5d  %12.2f  %12.1f   %7d\n", year, int(ix.weaningGrossRevenueByYear[year].nSteers),
+				ix.weaningGrossRevenueByYear[year].SteerRevenue, ix.weaningGrossRevenueByYear[year].wtSteers,
+				int(ix.weaningGrossRevenueByYear[year].nHeifers), ix.weaningGrossRevenueByYear[year].HeiferRevenue, ix.weaningGrossRevenueByYear[year].wtHeifers,
+				ix.weaningGrossRevenueByYear[year].nDead)
 		}
 	}
 
-	return WeaningNetReturnsToFixedCosts(nYears) // This returns total accumulated net returns/mating
+	return ix.WeaningNetReturnsToFixedCosts(nYears) // This returns total accumulated net returns/mating
 
 }
 
 // Determine the net revenue from cull cow sale
-func cullSale(nYears int) float64 {
+func (ix *Index) cullSale(nYears int) float64 {
 
-	cullCowGrosRevenueByYear = make(map[int]cullCowRevenueByYear_t)
+	ix.cullCowGrosRevenueByYear = make(map[int]cullCowRevenueByYear_t)
 
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 		fmt.Println("\nRevenue from cull cow sales:")
 		fmt.Println("Year   Tot Weight  $ Revenue $ DiscountedRev  N CullsOpen N CullsOld")
 	}
 
 	var TotalDiscountedNetRevenuePerMating float64
 
-	for y := StartYearOfNetReturns; y <= nYears; y++ {
+	for y := ix.StartYearOfNetReturns; y <= nYears; y++ {
 
 		/*
 			var tsmm TraitSexMinWtMaxWt_t
@@ -308,80 +301,146 @@ func cullSale(nYears int) float64 {
 			tsmm.Sex = animal.Cow
 			tsmm.Trait = "MW"
 		*/
-		pricePerPound := getPricePerPound(1000.0, animal.Cow, "MW") // 1000 just to get the number.  Don't need actual
-		c := cullCowGrosRevenueByYear[y]
+		pricePerPound, err := ix.getPricePerPound(config.CullPricedWeight, animal.Cow, "MW") // Just to get the number.  Don't need actual
+		if err != nil {
+			ix.fail(err)
+		}
+		c := ix.cullCowGrosRevenueByYear[y]
 
-		c.CowRevenue = animal.WtCullCows[y].CumWt * pricePerPound
-		c.nCowsOpen = animal.WtCullCows[y].NheadOpen
-		c.nCowsOld = animal.WtCullCows[y].NheadOld
-		period := float64(y - StartYearOfNetReturns)
-		c.DiscountedCowRevenue = c.CowRevenue / math.Pow(1.+DiscountRate, period)
+		c.CowRevenue = ix.Animals.WtCullCows[y].CumWt * pricePerPound
+		c.nCowsOpen = ix.Animals.WtCullCows[y].NheadOpen
+		c.nCowsOld = ix.Animals.WtCullCows[y].NheadOld
+		c.nHeifersSurplus = ix.Animals.WtCullCows[y].NheadSurplus
+		period := float64(y - ix.StartYearOfNetReturns)
+		c.DiscountedCowRevenue = c.CowRevenue / math.Pow(1.+ix.DiscountRate, period)
 
-		cullCowGrosRevenueByYear[y] = c
+		ix.cullCowGrosRevenueByYear[y] = c
 
-		TotalDiscountedNetRevenuePerMating += c.DiscountedCowRevenue / float64(animal.CowsExposedPerYear[y])
+		TotalDiscountedNetRevenuePerMating += c.DiscountedCowRevenue / float64(ix.Animals.CowsExposedPerYear[y])
 
-		if *logger.OutputMode == "verbose" {
-			fmt.Printf("%5d  %10.0f %10.2f      %10.2f   %10.0f %10.0f\n", y, animal.WtCullCows[y].CumWt, c.CowRevenue, c.DiscountedCowRevenue, c.nCowsOpen, c.nCowsOld)
+		if ix.OutputMode == "verbose" {
+			fmt.Printf("%5d  %10.0f %10.2f      %10.2f   %10.0f %10.0f\n", y, ix.Animals.WtCullCows[y].CumWt, c.CowRevenue, c.DiscountedCowRevenue, c.nCowsOpen, c.nCowsOld)
 		}
 
 	}
 
-	return TotalDiscountedNetRevenuePerMating / float64(nYears-StartYearOfNetReturns+1)
+	return TotalDiscountedNetRevenuePerMating / float64(nYears-ix.StartYearOfNetReturns+1)
 
 }
 
 // Cost per year for cows
-func CowCosts(nYears int) float64 {
+func (ix *Index) CowCosts(nYears int) float64 {
 
-	variableCostsByYearCows = make(map[int]float64)
+	ix.variableCostsByYearCows = make(map[int]float64)
 
-	for _, c := range animal.Records {
+	for _, c := range ix.Animals.Records {
 		if c.Sex == animal.Cow && c.YearBorn > 0 {
 			for _, a := range c.CowAum {
-				if a.Year >= StartYearOfNetReturns {
-					variableCostsByYearCows[a.Year] += a.Aum * AumCost[a.MonthOfYear-1]
+				if a.Year >= ix.StartYearOfNetReturns {
+					ix.variableCostsByYearCows[a.Year] += a.Aum * ix.AumCost[a.MonthOfYear-1]
 				}
 			}
 		}
 	}
 
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 		fmt.Println("\nCow costs:")
 		fmt.Println("Year        $ Cost  $ Discounted          $ Net/Exp")
 	}
 	var cumDc float64
-	for y := StartYearOfNetReturns; y <= nYears; y++ {
-		period := float64(y - StartYearOfNetReturns)
-		df := math.Pow(1.+DiscountRate, period)
-		dr := variableCostsByYearCows[y] / df
+	for y := ix.StartYearOfNetReturns; y <= nYears; y++ {
+		period := float64(y - ix.StartYearOfNetReturns)
+		df := math.Pow(1.+ix.DiscountRate, period)
+		dr := ix.variableCostsByYearCows[y] / df
+
+		netPerExposure := dr / float64(ix.Animals.CowsExposedPerYear[y])
+		cumDc += netPerExposure
+
+		if ix.OutputMode == "verbose" {
+			fmt.Printf("%5d     %10.2f  %10.2f        %10.2f\n", y, ix.variableCostsByYearCows[y], dr, netPerExposure)
+		}
+	}
+
+	return cumDc / float64(nYears-ix.StartYearOfNetReturns+1)
+}
+
+// Cost per year of the synchronization protocols and semen, sexed semen,
+// embryo transfer and genomic testing of heifers, charged to the year bred
+func (ix *Index) BreedingCosts(nYears int) float64 {
+
+	if len(ix.Animals.BreedingCosts) == 0 {
+		return 0
+	}
+
+	if ix.OutputMode == "verbose" {
+		fmt.Println("\nSynchronization, AI, sexed semen, embryo transfer and genomic testing costs:")
+		fmt.Println("Year  N Synchronized  N AI Pregnant   $ AI Cost  N Sexed  $ Sexed Cost  N Transfers  N ET Pregnant  $ ET Cost  N Tested  $ Test Cost  $ Discounted    $ Net/Exp")
+	}
+	var cumDc float64
+	for y := ix.StartYearOfNetReturns; y <= nYears; y++ {
+		c := ix.Animals.BreedingCosts[y]
+		period := float64(y - ix.StartYearOfNetReturns)
+		dc := (c.Cost + c.SexedCost + c.ETCost + c.TestCost) / math.Pow(1.+ix.DiscountRate, period)
+
+		netPerExposure := dc / float64(ix.Animals.CowsExposedPerYear[y])
+		cumDc += netPerExposure
+
+		if ix.OutputMode == "verbose" {
+			fmt.Printf("%5d %15d %14d  %10.2f  %7d  %12.2f  %11d  %13d  %9.2f  %8d  %11.2f  %11.2f  %11.2f\n", y, c.Synchronized, c.AIPregnant, c.Cost,
+				c.Sexed, c.SexedCost, c.Transfers, c.ETPregnant, c.ETCost, c.Tested, c.TestCost, dc, netPerExposure)
+		}
+	}
+
+	return cumDc / float64(nYears-ix.StartYearOfNetReturns+1)
+}
+
+// Labor and veterinary cost per year of the calvings in each calving ease
+// category, charged to the year born
+func (ix *Index) CalvingCosts(nYears int) float64 {
+
+	if len(ix.Animals.CalvingCosts) == 0 {
+		return 0
+	}
+
+	if ix.OutputMode == "verbose" {
+		fmt.Println("\nCalving ease costs:")
+		fmt.Println("Year  N Calvings  N Calves Died  N Cows Died     $ Labor       $ Vet  $ Discounted    $ Net/Exp")
+	}
+	var cumDc float64
+	for y := ix.StartYearOfNetReturns; y <= nYears; y++ {
+		c := ix.Animals.CalvingCosts[y]
+		period := float64(y - ix.StartYearOfNetReturns)
+		dc := (c.Labor + c.Vet) / math.Pow(1.+ix.DiscountRate, period)
 
-		netPerExposure := dr / float64(animal.CowsExposedPerYear[y])
+		netPerExposure := dc / float64(ix.Animals.CowsExposedPerYear[y])
 		cumDc += netPerExposure
 
-		if *logger.OutputMode == "verbose" {
-			fmt.Printf("%5d     %10.2f  %10.2f        %10.2f\n", y, variableCostsByYearCows[y], dr, netPerExposure)
+		if ix.OutputMode == "verbose" {
+			fmt.Printf("%5d %11d %14d %12d  %10.2f  %10.2f  %12.2f  %11.2f\n", y, c.Calvings, c.CalfDeaths, c.CowDeaths,
+				c.Labor, c.Vet, dc, netPerExposure)
 		}
 	}
 
-	return cumDc / float64(nYears-StartYearOfNetReturns+1)
+	return cumDc / float64(nYears-ix.StartYearOfNetReturns+1)
 }
 
 // Process an index with sale at weaning
-func EvaluateWeaningIndex(nYears int, burninMarker int, param map[string]interface{}, gvCholesky mat.Cholesky, rvCholesky mat.Cholesky) float64 {
+func (ix *Index) EvaluateWeaningIndex(nYears int) (float64, error) {
 
 	var base animal.Component_t
 	base.Component = "D"
 	base.TraitName = "base"
-	IndexNetReturns := weaningSale(nYears) // Discounted and per mating
-	IndexNetReturns += cullSale(nYears)    // Discounted and per mating
-	IndexNetReturns -= CowCosts(nYears)
+	IndexNetReturns := ix.weaningSale(nYears) // Discounted and per mating
+	IndexNetReturns += ix.cullSale(nYears)    // Discounted and per mating
+	IndexNetReturns -= ix.CowCosts(nYears)
+	IndexNetReturns -= ix.BreedingCosts(nYears)
+	IndexNetReturns -= ix.CalvingCosts(nYears)
 
-	if *logger.OutputMode == "verbose" {
+	if ix.OutputMode == "verbose" {
 
-		fmt.Printf("\nPlanning Horizon (in years):                                            %12d\n", nYears-StartYearOfNetReturns+1)
+		fmt.Printf("\nPlanning Horizon (in years):                                            %12d\n", nYears-ix.StartYearOfNetReturns+1)
 		//fmt.Printf("%d Year Discounted Net Returns to land, management and labor per exp accum: %12.2f\n", nYears-StartYearOfNetReturns+1, NetReturns)
-		fmt.Printf("%d year Discounted Net Returns to land, management and labor per exposure:  %12.2f\n", nYears-StartYearOfNetReturns+1,
+		fmt.Printf("%d year Discounted Net Returns to land, management and labor per exposure:  %12.2f\n", nYears-ix.StartYearOfNetReturns+1,
 			IndexNetReturns)
 		//} else {
 
@@ -389,18 +448,18 @@ func EvaluateWeaningIndex(nYears int, burninMarker int, param map[string]interfa
 		fmt.Println("NOTE: all net values are returns to land, management and labor")
 	}
 
-	return IndexNetReturns
+	return IndexNetReturns, ix.Err()
 }
 
 // Average weaning costs per mating accross years of simulation
 // Not used by weaning index but used by bg, fat cattle, and grade and yield
-func calculateWeaningCostsPerMating(nYears int) float64 {
+func (ix *Index) calculateWeaningCostsPerMating(nYears int) float64 {
 
 	var totalDiscountedWeaningCostPerMating float64
 
-	for y := StartYearOfNetReturns; y <= nYears; y++ {
-		totalDiscountedWeaningCostPerMating += (weaningGrossCostsByYear[y].DiscountedSteerCosts + weaningGrossCostsByYear[y].DiscountedHeiferCosts) /
-			float64(animal.CowsExposedPerYear[y])
+	for y := ix.StartYearOfNetReturns; y <= nYears; y++ {
+		totalDiscountedWeaningCostPerMating += (ix.weaningGrossCostsByYear[y].DiscountedSteerCosts + ix.weaningGrossCostsByYear[y].DiscountedHeiferCosts) /
+			float64(ix.Animals.CowsExposedPerYear[y])
 	}
-	return totalDiscountedWeaningCostPerMating / float64(nYears-StartYearOfNetReturns+1)
+	return totalDiscountedWeaningCostPerMating / float64(nYears-ix.StartYearOfNetReturns+1)
 }
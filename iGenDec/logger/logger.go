@@ -32,8 +32,17 @@ var OutputMode *string // verbose or model
 var User *string       // name of this user running this run
 var Seed *int64        // Random number generator seed
 
+// Name of the log file for this seed.  Seed may not be set when iGenDec is used as a library
+func logFileName() string {
+	var seed int64
+	if Seed != nil {
+		seed = *Seed
+	}
+	return "log.iGenDec." + strconv.FormatInt(seed, 10)
+}
+
 func LogWriter(message string) {
-	f, err := os.OpenFile("log.iGenDec."+strconv.FormatInt(*Seed, 10), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	f, err := os.OpenFile(logFileName(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		log.Println(err)
 	}
@@ -44,7 +53,7 @@ func LogWriter(message string) {
 	return
 }
 func LogWriterFatal(message string) {
-	f, err := os.OpenFile("log.iGenDec."+strconv.FormatInt(*Seed, 10), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	f, err := os.OpenFile(logFileName(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		log.Println(err)
 	}
@@ -53,7 +62,7 @@ func LogWriterFatal(message string) {
 	logger := log.New(f, "iGenDec ", log.LstdFlags)
 	logger.Println(message)
 
-	if *OutputMode == "verbose" {
+	if OutputMode != nil && *OutputMode == "verbose" {
 		fmt.Println(message)
 	}
 	os.Exit(1)
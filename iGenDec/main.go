@@ -23,77 +23,182 @@ SOFTWARE.
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	//"os"
+	"io/ioutil"
+	"log"
+	"os"
+
 	"github.com/blgolden/iGenDecModel/iGenDec/animal"
+	"github.com/blgolden/iGenDecModel/iGenDec/config"
 	"github.com/blgolden/iGenDecModel/iGenDec/ecoIndex"
 	"github.com/blgolden/iGenDecModel/iGenDec/logger"
+	"github.com/blgolden/iGenDecModel/iGenDec/simulation"
 	"github.com/blgolden/iGenDecModel/iGenDec/varStuff"
 )
 
 var version = "beta0.0.7"
 
-var burninMarker int // Length of the Burnin Records in Records[]
-
-func printTables() {
-	if *logger.OutputMode == "verbose" || *logger.OutputMode == "conception" {
-
-		var cowRate, heiferRate float64
-
-		// Print the breeding summary table
-		fmt.Printf("               ________Cows___________________________   | _____________Heifers___________________\n")
-		fmt.Printf("Year   Herd    Exposed    Bred    Open    Rate     Old   |  Exposed   Bred    Open    Rate    Died\n")
-		for i := animal.Burnin + 1; i <= nYears; i++ {
-
-			for n := range animal.Herds {
-				var h animal.HerdYear_t
-				h.Year = i
-				h.Herd = n
-
-				t := animal.BreedingRecordsYearTable[h]
-
-				fmt.Printf("%4d   %-7s %7d %7d %7d %7.1f %7d   | %7d %7d %7d %7.1f %7d\n",
-					h.Year,
-					h.Herd,
-					t.CowsExposed,
-					t.CowsBred,
-					t.CowsCulledOpen,
-					float64(t.CowsBred)/float64(t.CowsExposed)*100.,
-					t.CowsCulledOld,
-					t.HeifersExposed,
-					t.HeifersBred,
-					t.HeifersCulledOpen,
-					float64(t.HeifersBred)/float64(t.HeifersExposed)*100.,
-					t.HeifersDiedCalving)
-
-				cowRate = cowRate + float64(t.CowsBred)/float64(t.CowsExposed)*100.
-				heiferRate = heiferRate + float64(t.HeifersBred)/float64(t.HeifersExposed)*100.
-			}
+var paramFile *string  // Name of the parameter file
+var indexParm *string  // Name of the parameter file for configuring the index
+var bump *string       // Component to bump 1 unit up after burnin
+var genomicTest *bool  // Genomic test the replacement heifer candidates after burnin
+var saveBurnin *string // File to write the state after the burnin to
+var loadBurnin *string // File of a saved burnin to start from
+var output *string     // text or json
+
+func main() {
+
+	// iGenDec validate -genParm=... [-indexParm=...] checks the files without simulating
+	validateOnly := len(os.Args) > 1 && os.Args[1] == "validate"
+	if validateOnly {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
+	parseArgs()
+
+	if validateOnly {
+		os.Exit(validate(*paramFile, *indexParm))
+	}
+
+	p, err := simulation.LoadParams(*paramFile, *indexParm)
+	if err != nil {
+		fatal(err)
+	}
+
+	sim, err := simulation.New(p, simulation.Options{Seed: *logger.Seed, Bump: *bump, OutputMode: *logger.OutputMode, GenomicTest: *genomicTest})
+	if err != nil {
+		fatal(err)
+	}
+
+	if *loadBurnin != "" {
+		data, err := ioutil.ReadFile(*loadBurnin)
+		if err != nil {
+			logger.LogWriterFatal("Failed to open burnin file " + *loadBurnin)
+		}
+		if err = sim.Restore(data); err != nil {
+			fatal(err)
 		}
-		fmt.Printf("Average:                             %10.2f                                  %10.2f\n", cowRate/float64(nYears-animal.Burnin), heiferRate/float64(nYears-animal.Burnin))
+	}
+
+	if *saveBurnin != "" {
+		if err = sim.BurnIn(context.Background()); err != nil {
+			fatal(err)
+		}
+		data, err := sim.Snapshot()
+		if err != nil {
+			fatal(err)
+		}
+		if err = ioutil.WriteFile(*saveBurnin, data, 0644); err != nil {
+			logger.LogWriterFatal("Failed to write burnin file " + *saveBurnin)
+		}
+	}
+
+	result, err := sim.Run(context.Background())
+	if err != nil {
+		fatal(err)
+	}
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err = enc.Encode(result.Document); err != nil {
+			fatal(err)
+		}
+	} else if *indexParm != "" && *logger.OutputMode != "verbose" {
+		fmt.Printf("%f", result.NetReturns)
 	}
 }
 
-func main() {
+// The model returns errors rather than exiting so the report is made here
+func fatal(err error) {
+	message := err.Error()
+
+	// Problems with the parameter files rather than the run
+	var pe config.ParamErrors
+	var p *config.ParamError
+	var h *animal.HeterosisError
+	var a *animal.AgeDistError
+	var m *varStuff.MatrixError
+	var price *ecoIndex.PriceError
+	if errors.As(err, &pe) || errors.As(err, &p) || errors.As(err, &h) || errors.As(err, &a) ||
+		errors.As(err, &m) || errors.As(err, &price) {
+		message += "\nRun iGenDec validate with the same -genParm and -indexParm to list every problem"
+	}
+
+	logger.LogWriterFatal(message)
+}
+
+// Parse the arg list looking for the input hjson file
+func parseArgs() {
+	// paramFile = "master.hjson"
 
-	initSimulation() // Initialize everything
+	paramFile = flag.String("genParm", "", "The iGenDec parameter file (required)")
+	indexParm = flag.String("indexParm", "", "The parameter file for specifying the index")
+	logger.OutputMode = flag.String("outputMode", "verbose", "'verbose'(default) or 'model'")
+	logger.User = flag.String("user", "admin", "user=[Username]")
 
-	animal.MakeFoundationCowHerd(varStuff.GvCholesky, varStuff.RvCholesky, param)
+	bump = flag.String("bump", "", "Component to bump 1 unit up after burnin (optional)")
+	genomicTest = flag.Bool("genomicTest", false, "Genomic test the replacement heifers after burnin with the genomicTest keys (optional)")
 
-	animal.MakeFoundationHeifers(varStuff.GvCholesky, varStuff.RvCholesky, param)
+	logger.Seed = flag.Int64("seed", 1234, "Random number generator seed (int64)")
 
-	animal.MakeFoundationBulls(varStuff.GvCholesky, varStuff.RvCholesky, param)
+	saveBurnin = flag.String("saveBurnin", "", "Write the state after the burnin to this file (optional)")
+	loadBurnin = flag.String("loadBurnin", "", "Start from a burnin saved with -saveBurnin instead of simulating it (optional)")
 
-	simulateYears()
+	output = flag.String("output", "text", "'text'(default) or 'json' for one result document on stdout")
 
-	printTables()
+	flag.Parse()
 
-	if *indexParm != "" {
-		ecoIndex.ProcessNetReturns(indexParm, nYears, burninMarker, param, varStuff.GvCholesky, varStuff.RvCholesky)
+	if *output == "json" { // Nothing else may be written to stdout
+		*logger.OutputMode = "json"
+	} else if *output != "text" {
+		logger.LogWriterFatal("-output must be text or json")
 	}
 
-	animal.DumpRecords()
+	if *logger.OutputMode == "verbose" {
+		fmt.Printf("\n\t*** iGenDec ver %v ***\n\n", version)
+	}
+
+	if *paramFile == "" {
+		if *logger.OutputMode == "verbose" {
+			fmt.Printf("Error: A parameter file name must be provided on the command line\n\tiGenDec -genParm=[file name]\n")
+			// Print out a syntax message
+			syntax := `Usage of ./iGenDec [validate]:
+  validate
+	Check the parameter files and list every problem found instead of simulating
+  -genParm string
+    	The iGenDec parameter file (required)
+  -indexParm string
+	The index setup hjson file (optional)
+  -outputMode string
+    	'verbose'(default) or 'model' (default "verbose")
+  -seed int
+    	Random number generator seed (int64) (default 1234)
+  -user string
+    	user=[Username] (default "admin")
+  -bump string,string,float
+	Name of the genetic component to bump the bulls 1 unit after burnin - e.g. WW,D,1.
+  -genomicTest
+	Genomic test the replacement heifers after burnin with the genomicTestAccuracy and genomicTestCost keys (optional)
+  -saveBurnin string
+	Write the state after the burnin to this file (optional)
+  -loadBurnin string
+	Start from a burnin saved with -saveBurnin with the same parameters and seed (optional)
+  -output string
+	'text'(default) or 'json' to write one result document to stdout`
+
+			fmt.Printf("\n%s\n\n", syntax)
+			log.Fatal(errors.New("no parameter file name provided"))
+		} else {
+			//fmt.Printf("error:noParameterFile\n")
+			logger.LogWriter("no parameter file name provided")
+			os.Exit(1)
 
-	animal.DumpBreedingRecords()
+		}
+	}
 
 }
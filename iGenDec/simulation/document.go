@@ -0,0 +1,201 @@
+// document
+//
+// The result of one run as a single structure for -output=json
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package simulation
+
+import (
+	"sort"
+
+	"github.com/blgolden/iGenDecModel/iGenDec/ecoIndex"
+)
+
+// Document holds what the verbose tables print so programs need not scrape them
+type Document struct {
+	GenParm              string           `json:"genParm"`
+	IndexParm            string           `json:"indexParm,omitempty"`
+	Seed                 int64            `json:"seed"`
+	Bump                 string           `json:"bump,omitempty"`
+	Burnin               int              `json:"burnin"`
+	PlanningHorizon      int              `json:"planningHorizon"`
+	Breeding             []BreedingYear_t `json:"breeding"`
+	CowsExposed          []CowsExposed_t  `json:"cowsExposed"`
+	Evaluation           []Evaluation_t   `json:"evaluation,omitempty"` // Only with evaluationTraits
+	Inbreeding           []Inbreeding_t   `json:"inbreeding"`
+	Heterosis            []Heterosis_t    `json:"heterosis"`
+	CalvingEase          []CalvingEase_t  `json:"calvingEase,omitempty"` // Only with calvingEase
+	Genome               []Genome_t       `json:"genome,omitempty"`      // Only with a genome
+	Index                *ecoIndex.Report `json:"index,omitempty"`       // nil without an index parameter file
+	NetReturnPerExposure float64          `json:"netReturnPerExposure"`
+}
+
+// A herd's line of the breeding table
+type BreedingYear_t struct {
+	Year                 int    `json:"year"`
+	Herd                 string `json:"herd"`
+	CowsExposed          int    `json:"cowsExposed"`
+	CowsBred             int    `json:"cowsBred"`
+	CowsCulledOpen       int    `json:"cowsCulledOpen"`
+	CowsCulledOld        int    `json:"cowsCulledOld"`
+	HeifersExposed       int    `json:"heifersExposed"`
+	HeifersBred          int    `json:"heifersBred"`
+	HeifersCulledOpen    int    `json:"heifersCulledOpen"`
+	HeifersDiedCalving   int    `json:"heifersDiedCalving"`
+	CowsDiedCalving      int    `json:"cowsDiedCalving,omitempty"`
+	HeifersCulledSurplus int    `json:"heifersCulledSurplus,omitempty"`
+	BullsBought          int    `json:"bullsBought,omitempty"`
+	BullsCulledOld       int    `json:"bullsCulledOld,omitempty"`
+	BullsCulledInjured   int    `json:"bullsCulledInjured,omitempty"`
+}
+
+// How well one year's evaluation estimated the breeding values of the calves born the year before
+type Evaluation_t struct {
+	Year         int     `json:"year"`
+	Trait        string  `json:"trait"`
+	Component    string  `json:"component"`
+	Calves       int     `json:"calves"`
+	MeanTrue     float64 `json:"meanTrue"`
+	MeanEbv      float64 `json:"meanEbv"`
+	MeanAccuracy float64 `json:"meanAccuracy"`
+	Correlation  float64 `json:"correlation"`
+}
+
+// Inbreeding of the calves born in a year
+type Inbreeding_t struct {
+	Year   int     `json:"year"`
+	Calves int     `json:"calves"`
+	Mean   float64 `json:"mean"`
+	Max    float64 `json:"max"`
+}
+
+// Heterosis retained, as a proportion of an F1's, in the calves born in a year and their dams
+type Heterosis_t struct {
+	Year   int     `json:"year"`
+	Calves int     `json:"calves"`
+	Calf   float64 `json:"calf"`
+	Cows   int     `json:"cows"`
+	Cow    float64 `json:"cow"`
+}
+
+// The calvings of a year by calving ease category, the deaths and their costs
+type CalvingEase_t struct {
+	Year       int            `json:"year"`
+	Calvings   int            `json:"calvings"`
+	Categories map[string]int `json:"categories"`
+	CalfDeaths int            `json:"calfDeaths"`
+	CowDeaths  int            `json:"cowDeaths"`
+	Labor      float64        `json:"labor"`
+	Vet        float64        `json:"vet"`
+}
+
+// QTL segregating in the calves born in a year and the variance of their
+// breeding values in the order of the genetic components
+type Genome_t struct {
+	Year        int       `json:"year"`
+	Calves      int       `json:"calves"`
+	Segregating float64   `json:"segregating"`
+	Variance    []float64 `json:"variance"`
+}
+
+type CowsExposed_t struct {
+	Year int `json:"year"`
+	Cows int `json:"cows"`
+}
+
+// Build the document after Run
+func (sim *Simulation) document(netReturns float64) *Document {
+
+	d := new(Document)
+	d.GenParm = sim.ParamFile
+	d.IndexParm = sim.IndexParamFile
+	d.Seed = sim.Animals.Rng.Seed()
+	d.Bump = sim.Animals.BumpComponent
+	d.Burnin = sim.Animals.Burnin
+	d.PlanningHorizon = sim.Animals.YearsPlanningHorizon
+	d.NetReturnPerExposure = netReturns
+
+	for hy, t := range sim.Animals.BreedingRecordsYearTable {
+		d.Breeding = append(d.Breeding, BreedingYear_t{
+			Year:                 hy.Year,
+			Herd:                 hy.Herd,
+			CowsExposed:          t.CowsExposed,
+			CowsBred:             t.CowsBred,
+			CowsCulledOpen:       t.CowsCulledOpen,
+			CowsCulledOld:        t.CowsCulledOld,
+			HeifersExposed:       t.HeifersExposed,
+			HeifersBred:          t.HeifersBred,
+			HeifersCulledOpen:    t.HeifersCulledOpen,
+			HeifersDiedCalving:   t.HeifersDiedCalving,
+			CowsDiedCalving:      t.CowsDiedCalving,
+			HeifersCulledSurplus: t.HeifersCulledSurplus,
+			BullsBought:          t.BullsBought,
+			BullsCulledOld:       t.BullsCulledOld,
+			BullsCulledInjured:   t.BullsCulledInjured})
+	}
+	sort.Slice(d.Breeding, func(i, j int) bool {
+		if d.Breeding[i].Year != d.Breeding[j].Year {
+			return d.Breeding[i].Year < d.Breeding[j].Year
+		}
+		return d.Breeding[i].Herd < d.Breeding[j].Herd
+	})
+
+	for y, n := range sim.Animals.CowsExposedPerYear {
+		d.CowsExposed = append(d.CowsExposed, CowsExposed_t{Year: y, Cows: n})
+	}
+	sort.Slice(d.CowsExposed, func(i, j int) bool { return d.CowsExposed[i].Year < d.CowsExposed[j].Year })
+
+	for _, e := range sim.Animals.EvaluationTable {
+		d.Evaluation = append(d.Evaluation, Evaluation_t{
+			Year:         e.Year,
+			Trait:        e.Component.TraitName,
+			Component:    e.Component.Component,
+			Calves:       e.N,
+			MeanTrue:     e.MeanTrue,
+			MeanEbv:      e.MeanEbv,
+			MeanAccuracy: e.MeanAccuracy,
+			Correlation:  e.Correlation})
+	}
+
+	for _, y := range sim.Animals.InbreedingByYear() {
+		d.Inbreeding = append(d.Inbreeding, Inbreeding_t{Year: y.Year, Calves: y.Calves, Mean: y.Mean, Max: y.Max})
+	}
+
+	for _, y := range sim.Animals.HeterosisByYear() {
+		d.Heterosis = append(d.Heterosis, Heterosis_t{Year: y.Year, Calves: y.Calves, Calf: y.Calf, Cows: y.Cows, Cow: y.Cow})
+	}
+
+	for y, c := range sim.Animals.CalvingCosts {
+		d.CalvingEase = append(d.CalvingEase, CalvingEase_t{Year: y, Calvings: c.Calvings, Categories: c.Categories,
+			CalfDeaths: c.CalfDeaths, CowDeaths: c.CowDeaths, Labor: c.Labor, Vet: c.Vet})
+	}
+	sort.Slice(d.CalvingEase, func(i, j int) bool { return d.CalvingEase[i].Year < d.CalvingEase[j].Year })
+
+	for _, y := range sim.Animals.GenomeByYear() {
+		d.Genome = append(d.Genome, Genome_t{Year: y.Year, Calves: y.Calves, Segregating: y.Segregating, Variance: y.Variance})
+	}
+
+	if sim.Index != nil {
+		d.Index = sim.Index.Report(sim.nYears)
+	}
+
+	return d
+}
@@ -0,0 +1,844 @@
+// initSimulation
+package simulation
+
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+import (
+	"fmt"
+
+	"github.com/blgolden/iGenDecModel/iGenDec/animal"
+	"github.com/blgolden/iGenDecModel/iGenDec/config"
+
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	//	"time"
+
+	"github.com/blgolden/iGenDecModel/iGenDec/varStuff"
+)
+
+// Initialize the simulation
+func (sim *Simulation) initSimulation() error {
+
+	if sim.OutputMode == "verbose" {
+		if sim.Param.Comment != "" {
+			fmt.Printf("Comment: %v\n\n", sim.Param.Comment)
+		}
+	}
+
+	// Trait list
+	if sim.OutputMode == "verbose" {
+		fmt.Print("Traits:")
+	}
+
+	sim.Animals.TraitMean = make(map[string]float64)
+
+	// Process the Traits: key from master.hjson and set the means as a mapped slice
+	for _, t := range sim.Param.Traits {
+		if sim.OutputMode == "verbose" {
+			fmt.Printf("\t%v %v\n", t.Name, t.Mean)
+		}
+		sim.Animals.Traits = append(sim.Animals.Traits, t.Name)
+		sim.Animals.TraitMean[t.Name] = t.Mean
+	}
+
+	// Genetic components list
+	if sim.OutputMode == "verbose" {
+		fmt.Print("Genetic Components:")
+	}
+	for _, c := range sim.Param.Components {
+		if sim.OutputMode == "verbose" {
+			fmt.Printf("\t%v, %v\n", c.TraitName, c.Component)
+		}
+		sim.Animals.Components = append(sim.Animals.Components, c.TraitName+","+c.Component)
+		sim.Animals.ComponentList = append(sim.Animals.ComponentList, c)
+	}
+	sim.Animals.NFoundationBulls = sim.Param.NFoundationBulls
+	sim.Animals.AgeDist = sim.Param.AgeDist
+
+	if sim.Covariances == nil { // Not already factored by the caller and shared
+		var err error
+		if sim.Covariances, err = factorCovariances(sim.Param, sim.OutputMode); err != nil {
+			return fmt.Errorf("%s: %w", sim.ParamFile, err)
+		}
+	}
+	sim.Animals.SetResidualStdDevs(sim.Covariances.VcMatrix["residual"])
+
+	// Breeding season parameters
+	nHerds := len(sim.Param.Herds)
+	if sim.OutputMode == "verbose" {
+		fmt.Printf("\nNumber of herds: %d\n", nHerds)
+	}
+	if sim.Index != nil {
+		if err := sim.Index.LoadIndexComponents(); err != nil {
+			return err
+		}
+		if sim.Index.WhatSaleEndpoint() != "weaning" {
+			var ok bool
+			sim.Index.BackgroundDays, ok = sim.Index.Param["backgroundDays"].(float64)
+			if !ok {
+				return &config.ParamError{Key: "backgroundDays", Msg: "key not found in index parameter file"}
+			}
+			if sim.Index.BackgroundDays <= 0 {
+				sim.Index.BackgroundDays = 1 // In case these are calf fed we need at least 1 day to get the feedlot in weight
+			}
+			sim.Animals.BackgroundDays = sim.Index.BackgroundDays
+		}
+		sim.Animals.IndexType = sim.Index.WhatSaleEndpoint()
+		if sim.Animals.IndexType == "fatcattle" || sim.Animals.IndexType == "slaughtercattle" {
+			var ok bool
+			if sim.Animals.DaysOnFeed, ok = sim.Index.Param["daysOnFeed"].(float64); !ok {
+				return &config.ParamError{Key: "daysOnFeed", Msg: "key not found in fat cattle economic index file"}
+			}
+		}
+		if sim.Animals.IndexType == "slaughtercattle" {
+			sim.Index.InitGrid()
+		}
+	}
+
+	if sim.Index != nil {
+		sim.Animals.IndexTerminal = sim.Index.IsIndexTerminal()
+	}
+	if sim.Animals.IndexTerminal { // Terminal indexes do not want cow herd ages to change
+
+		if sim.Index.WhatSaleEndpoint() != "weaning" {
+			sim.Animals.Burnin = 1
+			sim.Animals.YearsPlanningHorizon = 2
+		} else {
+			sim.Animals.Burnin = 1
+			sim.Animals.YearsPlanningHorizon = 1
+		}
+	} else {
+		if sim.Param.Burnin <= 0 || sim.Param.PlanningHorizon <= 0 {
+			return &config.ParamError{Key: "burnin", Msg: "burnin and planningHorizon must be greater than 0 in " + sim.ParamFile}
+		}
+		sim.Animals.Burnin = sim.Param.Burnin
+		sim.Animals.YearsPlanningHorizon = sim.Param.PlanningHorizon
+	}
+	sim.nYears = sim.Animals.Burnin + sim.Animals.YearsPlanningHorizon
+	//}
+
+	sim.Animals.CalfAumAt500 = sim.Param.CalfAum
+	sim.Animals.CowAumAt1000 = sim.Param.CowAum
+
+	sim.Animals.Herds = make(map[string]animal.Herd)
+	for _, h := range sim.Param.Herds {
+		if sim.OutputMode == "verbose" {
+			fmt.Printf("\t%v, %d, %d, %d, %v, %v\n", h.Name, h.NumberCows, h.StartBreeding, h.BreedingSeasonLen,
+				h.SeasonConceptionRate, h.CalvingDeathLossRate)
+		}
+		var thisHerd animal.Herd // Need to build in multiple herds
+		thisHerd.HerdName = h.Name
+		thisHerd.NumberCows = h.NumberCows
+		thisHerd.StartBreeding = animal.Date(h.StartBreeding)
+		thisHerd.BreedingSeasonLen = animal.Date(h.BreedingSeasonLen)
+		thisHerd.CowConceptionRate = conceptionPerCycle(int64(thisHerd.BreedingSeasonLen), h.SeasonConceptionRate)
+		thisHerd.Mean3CycleRate = seasonConceptionRate(3.0, thisHerd.CowConceptionRate) // 3.0 cycles because that is what stay is based on
+		thisHerd.InitialCalvingDeathLessRate = h.CalvingDeathLossRate
+		for _, b := range sim.Param.BullManagement {
+			if b.Herd == h.Name {
+				thisHerd.BullServiceLife = b.ServiceLife
+				thisHerd.CowsPerBull = b.CowsPerBull
+				thisHerd.BullInjuryRate = b.InjuryRate
+			}
+		}
+		for _, m := range sim.Param.MatingPlans {
+			if m.Herd == h.Name {
+				thisHerd.MatingPlan = m.Plan
+				thisHerd.RotationBreeds = m.RotationBreeds
+				thisHerd.TerminalBreed = m.TerminalBreed
+				thisHerd.TerminalProportion = m.TerminalProportion
+			}
+		}
+
+		thisHerd.NBorn = make([]float64, sim.nYears+1+2)
+		thisHerd.SumBirthDates = make([]float64, sim.nYears+1+2) // 2 extra years of simulation after planning horizon to get heifers out, etc
+
+		sim.Animals.Herds[thisHerd.HerdName] = thisHerd
+
+	}
+	sim.Animals.SortHerdNames()
+
+	if sim.OutputMode == "verbose" {
+		fmt.Printf("\n\tFinished loading %v...\n\n", sim.ParamFile)
+	}
+
+	sim.loadBreedEffects()
+	sim.loadHeterosis()
+	sim.loadCowHerdBreedComposition()
+	sim.loadBullBatteryBreedComposition()
+	sim.loadCurrentCalvesBreedComposition() // What the trait means are made from for calf traits
+	sim.loadBreedTraitSexAod()
+	sim.loadTraitAgeEffects()
+	sim.loadFoundationBullsMerit()
+	if err := sim.loadGeneticTrendBulls(); err != nil {
+		return err
+	}
+	if err := sim.loadHeiferSelection(); err != nil {
+		return err
+	}
+	if err := sim.loadEvaluation(); err != nil {
+		return err
+	}
+	sim.loadInbreedingDepression()
+	for _, c := range sim.Param.Genome {
+		sim.Animals.GenomeLayout = append(sim.Animals.GenomeLayout, animal.ChromosomeSet_t{Number: c.Number, Length: c.Length, Markers: c.Markers, QTL: c.QTL})
+	}
+	if err := sim.loadSynchronization(); err != nil {
+		return err
+	}
+	if err := sim.loadCalvingEase(); err != nil {
+		return err
+	}
+
+	sim.adjustBreedEffects()
+
+	sim.Animals.WtCullCows = make(map[int]animal.Sales_t) // Accumulate the weight of cull cows
+	sim.Animals.NHeifersBred = make(map[int]int)
+
+	sim.openOutputFiles()
+
+	sim.initializeTables()
+
+	// Initialize the CD distribution
+	dvar := varStuff.VarFromMatrix(sim.Animals.GeneticIndex("CD", "D"), sim.Covariances.VcMatrix["genetic"])
+	mvar := varStuff.VarFromMatrix(sim.Animals.GeneticIndex("CD", "M"), sim.Covariances.VcMatrix["genetic"])
+	rvar := varStuff.VarFromMatrix(sim.Animals.ResidualIndex("CD"), sim.Covariances.VcMatrix["residual"])
+	sim.Animals.CDVar = dvar + mvar + rvar
+
+	return nil
+}
+
+// Adjust the breed effects for the composition of the current calves
+// such that the current calves average effect is zero.  This is done
+// so that the mean input are the current calves, and cows effects
+// Because the input values deviate from Angus (or whatever is zeroed)
+func (sim *Simulation) adjustBreedEffects() {
+
+	for component, breedEffects := range sim.Animals.Breeds {
+		if breedEffects.CowOrCalf == "Calf" {
+			var adj float64
+			for _, c := range sim.Animals.CurrentCalvesBreedCompositionTable {
+				p := c.Proportion
+				for _, breed := range sim.Param.Breeds { // In order so the sum is the same every run
+					adj += p * c.BreedProportions[breed] * breedEffects.Effects[breed]
+				}
+			}
+
+			for breed, eff := range breedEffects.Effects {
+				breedEffects.Effects[breed] = eff - adj
+			}
+			sim.Animals.Breeds[component] = breedEffects
+		} else { // Is cow trait
+			var adj float64
+			for _, c := range sim.Animals.FoundationCowHerdBreedCompositionTable {
+				p := c.Proportion
+				for _, breed := range sim.Param.Breeds { // In order so the sum is the same every run
+					adj += p * c.BreedProportions[breed] * breedEffects.Effects[breed]
+				}
+			}
+
+			for breed, eff := range breedEffects.Effects {
+				breedEffects.Effects[breed] = eff - adj
+			}
+			sim.Animals.Breeds[component] = breedEffects
+		}
+	}
+}
+
+// Initialize summary talbes
+func (sim *Simulation) initializeTables() {
+
+	// table to summarize the breeding records
+	sim.Animals.BreedingRecordsYearTable = make(map[animal.HerdYear_t]animal.BreedingRecordsTable_t)
+
+	sim.Animals.CowsExposedPerYear = make(map[int]int)
+	sim.Animals.BreedingCosts = make(map[int]animal.BreedingCost_t)
+	sim.Animals.CalvingCosts = make(map[int]animal.CalvingCost_t)
+}
+
+// Read the TraitAgeEffects from the master hjson
+// Trait name and slope in units/day of age
+func (sim *Simulation) loadTraitAgeEffects() {
+
+	sim.Animals.TraitAgeEffects = make(map[string]animal.InterceptSlope_t)
+
+	for _, t := range sim.Param.TraitAgeEffects {
+		var tis animal.InterceptSlope_t
+		tis.Slope = t.Slope
+		tis.Age = t.Age
+
+		sim.Animals.TraitAgeEffects[t.Trait] = tis
+	}
+}
+
+// Read the BreedTraitSexAod factors
+// from the hjson file
+func (sim *Simulation) loadBreedTraitSexAod() {
+
+	sim.Animals.BreedTraitSexAod = make(map[animal.BTS_t]float64)
+
+	// Load a table of the breed, trait, sex, AOD factors
+	for _, c := range sim.Param.BreedTraitSexAod {
+		for k, f := range c.Effect {
+			var b animal.BTS_t
+			b.Breed = c.Breed
+			b.Trait = c.Trait
+			b.Sex = c.Sex
+			b.Aod = k
+
+			sim.Animals.BreedTraitSexAod[b] = f
+		}
+	}
+
+	//fmt.Println(animal.BreedTraitSexAod)
+}
+
+// Read in the HeterosisCodes: of breed-class
+// Designate the HeterosisCodes: for each breed
+func (sim *Simulation) loadHeterosis() {
+
+	sim.Animals.HeterosisCodes = make(map[string]string)
+
+	// Load a table of the breed to breed classification codes
+	for _, c := range sim.Param.HeterosisCodes {
+		sim.Animals.HeterosisCodes[c.Breed] = c.Code
+	}
+
+	if sim.OutputMode == "verbose" {
+		fmt.Println("Heterosis Codes: ", sim.Animals.HeterosisCodes)
+	}
+
+	// Load the trait by breed classification cross F1 values
+	sim.Animals.HeterosisCrossClasses = append(sim.Animals.HeterosisCrossClasses, sim.Param.HeterosisCrossClasses...)
+
+	if sim.OutputMode == "verbose" {
+		fmt.Println("Heterosis Cross Classes: ", sim.Animals.HeterosisCrossClasses)
+	}
+
+	sim.Animals.HeterosisValues = make(map[animal.Component_t]animal.HeterosisValues_t, len(sim.Param.HeterosisValues))
+
+	// Load the table of values for each trait-component
+	for _, v := range sim.Param.HeterosisValues {
+		h := NewHeterosisValue(v.Component.TraitName, v.Component.Component)
+
+		for k, f := range v.Values {
+			h.Values[sim.Animals.HeterosisCrossClasses[k]] = f
+		}
+
+		sim.Animals.HeterosisValues[v.Component] = h
+	}
+}
+
+// Do this so we can map the Values
+func NewHeterosisValue(traitName string, component string) animal.HeterosisValues_t {
+	var h animal.HeterosisValues_t
+	h.TraitName = traitName
+	h.Component = component
+	h.Values = make(map[string]float64)
+	return h
+}
+
+// Load the merit of the foundation bulls
+func (sim *Simulation) loadFoundationBullsMerit() {
+	if sim.OutputMode == "verbose" {
+		fmt.Println("\nInitial Bulls merit: ")
+	}
+	for _, m := range sim.Param.MeritFoundationBulls {
+		sim.Animals.BullMerit = append(sim.Animals.BullMerit, m.Value)
+	}
+
+}
+
+// Load the yearly genetic trend of purchased bulls in the order of the genetic components
+func (sim *Simulation) loadGeneticTrendBulls() error {
+	if len(sim.Param.GeneticTrendBulls) == 0 {
+		return nil
+	}
+	sim.Animals.BullTrend = make([]float64, len(sim.Animals.ComponentList))
+	for i, m := range sim.Param.GeneticTrendBulls {
+		idx := sim.Animals.GeneticIndex(m.Component.TraitName, m.Component.Component)
+		if idx < 0 {
+			return &config.ParamError{Key: "geneticTrendBulls", Row: 2*i + 2, Msg: m.Component.TraitName + "," + m.Component.Component + " is not in Components"}
+		}
+		sim.Animals.BullTrend[idx] = m.Value
+	}
+	if sim.OutputMode == "verbose" {
+		fmt.Println("Genetic trend of purchased bulls: ", sim.Animals.BullTrend)
+	}
+	return nil
+}
+
+// Load the replacement heifer selection policy
+func (sim *Simulation) loadHeiferSelection() error {
+	st := sim.Animals
+	st.HeiferSelection = sim.Param.HeiferSelection
+	st.HeiferSelectionTrait = strings.TrimPrefix(sim.Param.HeiferSelectionTrait, "-")
+	st.HeiferLowestFirst = strings.HasPrefix(sim.Param.HeiferSelectionTrait, "-")
+	st.HeiferIndexAccuracy = sim.Param.HeiferIndexAccuracy
+	st.HeiferSurplus = sim.Param.HeiferSurplus
+	st.GenomicTestAccuracy = sim.Param.GenomicTestAccuracy
+	st.GenomicTestCost = sim.Param.GenomicTestCost
+
+	if st.GenomicTest && st.GenomicTestAccuracy == 0 {
+		return &config.ParamError{Key: "genomicTestAccuracy", Msg: "key not found, genomic testing needs it"}
+	}
+	if st.GenomicTest && st.HeiferSelection != animal.SelectIndex {
+		return &config.ParamError{Key: "heiferSelection", Msg: "genomic testing needs the " + animal.SelectIndex + " policy"}
+	}
+
+	if len(sim.Param.HeiferIndexWeights) == 0 {
+		return nil
+	}
+	st.HeiferIndexWeights = make([]float64, len(st.ComponentList))
+	for i, m := range sim.Param.HeiferIndexWeights {
+		idx := st.GeneticIndex(m.Component.TraitName, m.Component.Component)
+		if idx < 0 {
+			return &config.ParamError{Key: "heiferIndexWeights", Row: 2*i + 2, Msg: m.Component.TraitName + "," + m.Component.Component + " is not in Components"}
+		}
+		st.HeiferIndexWeights[idx] = m.Value
+	}
+
+	// Standard deviation of the true index, sqrt(w'Gw)
+	g := sim.Covariances.VcMatrix["genetic"]
+	var v float64
+	for i, wi := range st.HeiferIndexWeights {
+		for j, wj := range st.HeiferIndexWeights {
+			v += wi * wj * g.At(i, j)
+		}
+	}
+	st.HeiferIndexStdDev = math.Sqrt(v)
+
+	if sim.OutputMode == "verbose" {
+		fmt.Printf("Heifer selection: %s, index weights %v, index std dev %.3f, accuracy %.2f, surplus %.2f\n",
+			st.HeiferSelection, st.HeiferIndexWeights, st.HeiferIndexStdDev, st.HeiferIndexAccuracy, st.HeiferSurplus)
+		if st.GenomicTest {
+			fmt.Printf("Genomic testing after the burnin: accuracy %.2f, $%.2f per heifer\n", st.GenomicTestAccuracy, st.GenomicTestCost)
+		}
+	}
+	return nil
+}
+
+// Inbreeding depression by trait and component from the inbreedingDepression key
+func (sim *Simulation) loadInbreedingDepression() {
+	sim.Animals.InbreedingDepression = make(map[animal.Component_t]float64)
+	for _, m := range sim.Param.InbreedingDepression {
+		sim.Animals.InbreedingDepression[m.Component] = m.Value
+	}
+	if sim.OutputMode == "verbose" && len(sim.Param.InbreedingDepression) > 0 {
+		fmt.Printf("Inbreeding depression per 1%% inbreeding: %v\n", sim.Animals.InbreedingDepression)
+	}
+}
+
+// Load the AI sires and give the herds their synchronization protocols and sexed semen
+func (sim *Simulation) loadSynchronization() error {
+	st := sim.Animals
+
+	sires := make(map[string]int)
+	for k, s := range sim.Param.AISires {
+		sires[s.Name] = k
+		st.AISires = append(st.AISires, animal.AISire_t{Name: s.Name, Breed: s.Breed, SemenCost: s.SemenCost, Merit: make(map[int]float64)})
+	}
+	for i, m := range sim.Param.AISireMerit {
+		k, ok := sires[m.Name]
+		if !ok {
+			return &config.ParamError{Key: "aiSireMerit", Row: i + 1, Msg: "sire " + m.Name + " is not in aiSires"}
+		}
+		idx := st.GeneticIndex(m.Merit.Component.TraitName, m.Merit.Component.Component)
+		if idx < 0 {
+			return &config.ParamError{Key: "aiSireMerit", Row: i + 1, Msg: m.Merit.Component.TraitName + "," + m.Merit.Component.Component + " is not in Components"}
+		}
+		st.AISires[k].Merit[idx] = m.Merit.Value
+	}
+
+	for i, p := range sim.Param.Synchronization {
+		herd, ok := st.Herds[p.Herd]
+		if !ok {
+			return &config.ParamError{Key: "synchronization", Row: i + 1, Msg: "herd " + p.Herd + " is not in herds"}
+		}
+		protocol := animal.Synchronization_t{Class: p.Class, AIDay: p.AIDay, ConceptionRate: p.ConceptionRate, Cost: p.Cost, CleanupDay: p.CleanupDay}
+		for _, name := range p.Sires {
+			k, ok := sires[name]
+			if !ok {
+				return &config.ParamError{Key: "synchronization", Row: i + 1, Msg: "sire " + name + " is not in aiSires"}
+			}
+			protocol.Sires = append(protocol.Sires, k)
+		}
+		for _, x := range sim.Param.SexedSemen {
+			if x.Herd == p.Herd && x.Class == p.Class {
+				protocol.SexedFemales = x.Females
+				protocol.SexedConception = x.RelativeConception
+				protocol.SexedCost = x.Cost
+			}
+		}
+		herd.Synchronization = append(herd.Synchronization, protocol)
+		st.Herds[p.Herd] = herd
+
+		if sim.OutputMode == "verbose" {
+			fmt.Printf("Synchronization of the %v herd's %s: AI day %d, conception %v, $%v per female, cleanup day %d, sires %v\n",
+				p.Herd, p.Class, p.AIDay, p.ConceptionRate, p.Cost, p.CleanupDay, p.Sires)
+			if protocol.SexedFemales > 0 {
+				fmt.Printf("\tsexed semen: %v heifer calves, conception x %v, $%v extra per unit\n",
+					protocol.SexedFemales, protocol.SexedConception, protocol.SexedCost)
+			}
+		}
+	}
+	return sim.loadEmbryoTransfer()
+}
+
+// Load the embryo lines and give the herds their embryo transfer
+func (sim *Simulation) loadEmbryoTransfer() error {
+	st := sim.Animals
+
+	lines := make(map[string]int)
+	for k, e := range sim.Param.Embryos {
+		lines[e.Name] = k
+		st.Embryos = append(st.Embryos, animal.Embryo_t{Name: e.Name, Breed: e.Breed, Cost: e.Cost, Merit: make(map[int]float64)})
+	}
+	for i, m := range sim.Param.EmbryoMerit {
+		k, ok := lines[m.Name]
+		if !ok {
+			return &config.ParamError{Key: "embryoMerit", Row: i + 1, Msg: "embryo line " + m.Name + " is not in embryos"}
+		}
+		idx := st.GeneticIndex(m.Merit.Component.TraitName, m.Merit.Component.Component)
+		if idx < 0 {
+			return &config.ParamError{Key: "embryoMerit", Row: i + 1, Msg: m.Merit.Component.TraitName + "," + m.Merit.Component.Component + " is not in Components"}
+		}
+		st.Embryos[k].Merit[idx] = m.Merit.Value
+	}
+
+	for i, e := range sim.Param.EmbryoTransfer {
+		herd, ok := st.Herds[e.Herd]
+		if !ok {
+			return &config.ParamError{Key: "embryoTransfer", Row: i + 1, Msg: "herd " + e.Herd + " is not in herds"}
+		}
+		et := animal.EmbryoTransfer_t{Class: e.Class, Proportion: e.Proportion, TransferDay: e.TransferDay,
+			PregnancyRate: e.PregnancyRate, Cost: e.Cost, CleanupDay: e.CleanupDay}
+		for _, name := range e.Embryos {
+			k, ok := lines[name]
+			if !ok {
+				return &config.ParamError{Key: "embryoTransfer", Row: i + 1, Msg: "embryo line " + name + " is not in embryos"}
+			}
+			et.Embryos = append(et.Embryos, k)
+		}
+		herd.EmbryoTransfer = append(herd.EmbryoTransfer, et)
+		st.Herds[e.Herd] = herd
+
+		if sim.OutputMode == "verbose" {
+			fmt.Printf("Embryo transfer in the %v herd's %s: %v of them on day %d, pregnancy %v, $%v per transfer, cleanup day %d, embryos %v\n",
+				e.Herd, e.Class, e.Proportion, e.TransferDay, e.PregnancyRate, e.Cost, e.CleanupDay, e.Embryos)
+		}
+	}
+	return nil
+}
+
+// Give the herds their calving ease categories, the cumulative frequencies of
+// the categories by dam age and their carry-over effects
+func (sim *Simulation) loadCalvingEase() error {
+	st := sim.Animals
+
+	for i, c := range sim.Param.CalvingEase {
+		herd, ok := st.Herds[c.Herd]
+		if !ok {
+			return &config.ParamError{Key: "calvingEase", Row: i + 1, Msg: "herd " + c.Herd + " is not in herds"}
+		}
+		herd.CalvingEase = append(herd.CalvingEase, animal.CalvingEase_t{Category: c.Category, CalfDeath: c.CalfDeath,
+			CowDeath: c.CowDeath, Labor: c.Labor, Vet: c.Vet})
+		st.Herds[c.Herd] = herd
+	}
+
+	for i, f := range sim.Param.CalvingEaseFrequencies {
+		herd, ok := st.Herds[f.Herd]
+		if !ok || len(f.Frequencies) != len(herd.CalvingEase) {
+			return &config.ParamError{Key: "calvingEaseFrequencies", Row: i + 1,
+				Msg: "needs a frequency for each of the calvingEase categories of herd " + f.Herd}
+		}
+		var sum float64
+		for _, p := range f.Frequencies {
+			sum += p
+		}
+		if sum <= 0 {
+			return &config.ParamError{Key: "calvingEaseFrequencies", Row: i + 1, Msg: "frequencies must sum to 1"}
+		}
+		a := animal.CalvingEaseAge_t{Age: f.Age}
+		var cum float64
+		for _, p := range f.Frequencies {
+			cum += p / sum
+			a.Cumulative = append(a.Cumulative, cum)
+		}
+		herd.CalvingEaseAges = append(herd.CalvingEaseAges, a)
+		sort.SliceStable(herd.CalvingEaseAges, func(i, j int) bool { return herd.CalvingEaseAges[i].Age < herd.CalvingEaseAges[j].Age })
+		st.Herds[f.Herd] = herd
+	}
+
+	for i, d := range sim.Param.Dystocia {
+		herd := st.Herds[d.Herd]
+		k := -1
+		for j, c := range herd.CalvingEase {
+			if c.Category == d.Category {
+				k = j
+			}
+		}
+		if k < 0 {
+			return &config.ParamError{Key: "dystocia", Row: i + 1, Msg: d.Category + " is not a calvingEase category of herd " + d.Herd}
+		}
+		herd.Dystocia = append(herd.Dystocia, animal.Dystocia_t{Category: k, Age: d.Age, PostpartumDelay: d.PostpartumDelay, WeaningPenalty: d.WeaningPenalty})
+		sort.SliceStable(herd.Dystocia, func(i, j int) bool { return herd.Dystocia[i].Age < herd.Dystocia[j].Age })
+		st.Herds[d.Herd] = herd
+	}
+
+	for _, name := range st.HerdNames {
+		herd := st.Herds[name]
+		if len(herd.CalvingEase) > 0 && len(herd.CalvingEaseAges) == 0 {
+			return &config.ParamError{Key: "calvingEaseFrequencies", Msg: "herd " + name + " has calvingEase categories but no frequencies"}
+		}
+		if len(herd.CalvingEase) > 0 && sim.OutputMode == "verbose" {
+			fmt.Printf("Calving ease of the %v herd:\n", name)
+			for _, c := range herd.CalvingEase {
+				fmt.Printf("\t%s: calf death %v, cow death %v, labor $%v, vet $%v\n", c.Category, c.CalfDeath, c.CowDeath, c.Labor, c.Vet)
+			}
+			for _, a := range herd.CalvingEaseAges {
+				fmt.Printf("\tdam age %d: cumulative frequencies %.4f\n", a.Age, a.Cumulative)
+			}
+			for _, d := range herd.Dystocia {
+				fmt.Printf("\t%s, dam age %d: postpartum delay %d days, weaning weight -%v\n",
+					herd.CalvingEase[d.Category].Category, d.Age, d.PostpartumDelay, d.WeaningPenalty)
+			}
+		}
+	}
+	return nil
+}
+
+// Variances of the traits in the yearly genetic evaluation
+func (sim *Simulation) loadEvaluation() error {
+	st := sim.Animals
+	g := sim.Covariances.VcMatrix["genetic"]
+	r := sim.Covariances.VcMatrix["residual"]
+
+	for i, trait := range sim.Param.EvaluationTraits {
+		t := animal.EvaluationTrait_t{Trait: trait}
+		t.Direct = st.GeneticIndex(trait, "D")
+		t.Maternal = st.GeneticIndex(trait, "M")
+		if t.Direct < 0 {
+			return &config.ParamError{Key: "evaluationTraits", Row: i + 1, Msg: trait + ",D is not in Components"}
+		}
+		t.G[0][0] = g.At(t.Direct, t.Direct)
+		if t.Maternal >= 0 {
+			t.G[0][1] = g.At(t.Direct, t.Maternal)
+			t.G[1][0] = t.G[0][1]
+			t.G[1][1] = g.At(t.Maternal, t.Maternal)
+		}
+		idx := st.ResidualIndex(trait)
+		t.Re = r.At(idx, idx)
+		st.Evaluation = append(st.Evaluation, t)
+
+		if sim.OutputMode == "verbose" {
+			fmt.Printf("Evaluating %s with G %v and residual variance %.3f\n", trait, t.G, t.Re)
+		}
+	}
+	return nil
+}
+
+// Read the breed effects table from the master.hjson file
+// And map according to trait name
+func (sim *Simulation) loadBreedEffects() {
+
+	// The list of breed names from the 1st row of the BreedEffects: hjson key
+	sim.Animals.BreedsList = append(sim.Animals.BreedsList, sim.Param.Breeds...)
+
+	sim.Animals.Breeds = make(map[animal.Component_t]animal.BreedEffects_t)
+
+	// Read the trait by breed effects values
+	for _, e := range sim.Param.BreedEffects {
+
+		if sim.OutputMode == "verbose" {
+			fmt.Printf("\t%v,%v,%v", e.Component.TraitName, e.Component.Component, e.CowOrCalf)
+			for _, f := range e.Effects {
+				fmt.Printf(",%v", f)
+			}
+			fmt.Println()
+		}
+
+		var b animal.BreedEffects_t
+		b.TraitName = e.Component.TraitName
+		b.Component = e.Component.Component
+		b.CowOrCalf = e.CowOrCalf
+
+		b.Effects = make(map[string]float64)
+
+		for j, f := range e.Effects {
+			b.Effects[sim.Animals.BreedsList[j]] = f
+		}
+
+		sim.Animals.Breeds[e.Component] = b
+	}
+}
+
+// Read in the cows herd breed composition from COwHerdBreedComposition: key in master.hjson
+func (sim *Simulation) loadCowHerdBreedComposition() {
+
+	if sim.OutputMode == "verbose" {
+		fmt.Println("\nInitial cow herd breed composition: ")
+	}
+	sim.Animals.FoundationCowHerdBreedCompositionTable = sim.breedCompositionTable(sim.Param.CowHerdBreedComposition, "cows")
+}
+
+// Read in the bull battery breed composition from BullBatteryBreedComposition: key in master.hjson
+func (sim *Simulation) loadBullBatteryBreedComposition() {
+
+	if sim.OutputMode == "verbose" {
+		fmt.Println("\nInitial bull battery breed composition: ")
+	}
+	sim.Animals.BullBatteryBreedCompositionTable = sim.breedCompositionTable(sim.Param.BullBatteryBreedComposition, "bulls")
+}
+
+// Read in the current calf crop breed composition from CurrentCalvessBreedComposition: key in master.hjson
+// This is used to adjust the breed effect and heterosis effects for calf traits.
+func (sim *Simulation) loadCurrentCalvesBreedComposition() {
+
+	if sim.OutputMode == "verbose" {
+		fmt.Println("\nInitial calves breed composition: ")
+	}
+	sim.Animals.CurrentCalvesBreedCompositionTable = sim.breedCompositionTable(sim.Param.CurrentCalvesBreedComposition, "calves")
+}
+
+// Convert percents to the cumulative proportions used to sample a composition
+func (sim *Simulation) breedCompositionTable(rows []config.BreedComposition_t, what string) (table []animal.BreedComposition_t) {
+
+	var top float64
+
+	for _, r := range rows {
+
+		top += r.Percent / 100.
+
+		if sim.OutputMode == "verbose" {
+			fmt.Printf("\t%3.0f%% of %s are:\n", r.Percent, what)
+		}
+		var b animal.BreedComposition_t
+		b.Proportion = top
+		b.BreedProportions = make(map[string]float64)
+		for _, bp := range r.Breeds {
+			b.BreedProportions[bp.Breed] = bp.Percent / 100.
+			if sim.OutputMode == "verbose" {
+				fmt.Printf("\t\t%-8s %3.0f%%\n", bp.Breed, bp.Percent)
+			}
+		}
+		table = append(table, b)
+	}
+	return table
+}
+
+// Open the output files in master.hjson
+func (sim *Simulation) openOutputFiles() {
+
+	if sim.Param.CowAgeFileName != "" {
+		sim.Animals.CowAgeFile, _ = os.Create(sim.Param.CowAgeFileName)
+	}
+
+	sim.Animals.RecordsDumpFile = sim.Param.RecordsDump
+	sim.Animals.BreedingRecordsDumpFile = sim.Param.BreedingRecordsDump
+
+	if sim.Param.PhenotypeFile != "" {
+		sim.Animals.PhenotypeFile = sim.Param.PhenotypeFile
+		sim.Animals.PhenotypeOutputTrait = sim.Param.PhenotypeOutputTrait
+		f, _ := os.Create(sim.Animals.PhenotypeFile)
+		sim.Animals.PhenotypeFilePointer = f
+	}
+	if sim.Param.StayPhenotypeFile != "" {
+		sim.Animals.StayPhenotypeFilePointer, _ = os.Create(sim.Param.StayPhenotypeFile)
+	}
+	if sim.Param.HPPhenotypeFile != "" {
+		sim.Animals.HPPhenotypeFilePointer, _ = os.Create(sim.Param.HPPhenotypeFile)
+	}
+	if sim.Param.CDPhenotypeFile != "" {
+		sim.Animals.CDPhenotypeFilePointer, _ = os.Create(sim.Param.CDPhenotypeFile)
+	}
+	if sim.Param.CarcassPhenotypeFile != "" {
+		sim.Animals.CarcassPhenotypeFile, _ = os.Create(sim.Param.CarcassPhenotypeFile)
+		fmt.Fprintln(sim.Animals.CarcassPhenotypeFile, "Id YearBorn CarcassWeight QualityGrade YieldGrade pricePerPound gridPrice progPremium BackFatThickness RibEyArea calf.MarblingScore")
+	}
+}
+
+// Find the conception rate per cycle using
+// a lookup table and return
+func conceptionPerCycle(l int64, c float64) float64 {
+
+	if c >= 1.0 {
+		c = .99999
+	}
+
+	baseCycles := l / 21
+
+	m := l % 21
+
+	var frac float64
+
+	if m == 0 {
+		frac = 1.0
+	} else {
+		frac = 1. - float64(m)/21.0
+	}
+
+	var baseA float64
+	var baseC float64
+
+	// What is a for base cycles
+	for a := .01; a <= 1.0; a = a + .01 {
+
+		baseC = seasonConceptionRate(baseCycles, a)
+
+		if c <= baseC {
+			baseA = a
+			break
+		}
+	}
+
+	// What is A for base+1 cycles
+	for a := .01; a <= 1.0; a = a + .01 {
+		nextC := seasonConceptionRate(baseCycles+1, a)
+		if c <= nextC {
+			deltaA := baseA - a
+
+			rate := a + frac*deltaA
+
+			return rate
+		}
+	}
+
+	return 1.0
+}
+
+// Calculate the season's conception rate for a given number
+// of cycles and a per cycle conception rate
+func seasonConceptionRate(cycles int64, a float64) float64 {
+
+	var cumulativeConception float64
+	var c int64
+
+	for c = 0; c < cycles; c++ {
+		cumulativeConception = cumulativeConception + (1.0-cumulativeConception)*a
+	}
+
+	return cumulativeConception
+}
@@ -0,0 +1,157 @@
+// simulateYears.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package simulation
+
+import (
+	"context"
+	"github.com/blgolden/iGenDecModel/iGenDec/animal"
+	//"errors"
+	"fmt"
+
+	"strconv"
+	"strings"
+)
+
+// Simulate the planning horizon after the burnin
+func (sim *Simulation) simulateYears(ctx context.Context) error {
+
+	sim.burninMarker = len(sim.Animals.Records)
+
+	if sim.Animals.BumpComponent != "" {
+		var c animal.BumpComponent_t
+		s := strings.Split(sim.Animals.BumpComponent, ",")
+		if len(s) != 3 {
+			return &BumpError{Bump: sim.Animals.BumpComponent, Msg: "must have three values separated by a comma - e.g., 'WW,D,1'"}
+		}
+		c.TraitName = strings.TrimSpace(s[0])
+		c.Component = strings.TrimSpace(s[1])
+		var err error
+		if c.Value, err = strconv.ParseFloat(strings.TrimSpace(s[2]), 64); err != nil {
+			return &BumpError{Bump: sim.Animals.BumpComponent, Msg: "the amount is not a number"}
+		}
+
+		if err = sim.bumpComponent(c); err != nil {
+			return err
+		}
+
+		if sim.Index != nil {
+			if err = sim.Covariances.ConstrainedFactor(c, sim.Animals, sim.Index.IndexComponents); err != nil {
+				return err
+			}
+		}
+	}
+
+	return sim.Animals.SimulateBase(ctx, sim.Covariances.GvCholesky, sim.Covariances.RvCholesky)
+}
+
+// Run the simulation for the burnin
+func (sim *Simulation) burnInSimulation(ctx context.Context) error {
+	if sim.OutputMode == "verbose" {
+		fmt.Printf("\n\tBeginning simulation for %v years\n", sim.nYears)
+	}
+
+	for year := 1; year <= sim.Animals.Burnin; year++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		sim.Animals.Evaluate(year)
+		for _, n := range sim.Animals.HerdNames {
+			h := sim.Animals.Herds[n]
+			sim.Animals.ManageBulls(&h, year, sim.Covariances.GvCholesky, sim.Covariances.RvCholesky)
+			//fmt.Println("LOC 1")
+			sim.Animals.Breed(&h, year)
+			//fmt.Println("LOC 2")
+			sim.Animals.Calve(&h, year, sim.Covariances.GvCholesky, sim.Covariances.RvCholesky)
+			//fmt.Println("LOC 3")
+			sim.Animals.CullOpen(&h, year)
+			//fmt.Println("LOC 4")
+			sim.Animals.WriteCowAgeDistribution(sim.Animals.Herds, year-1)
+			//fmt.Println("LOC 5")
+			sim.Animals.CullOld(&h, year)
+			sim.Animals.CullSurplusHeifers(&h, year)
+			//fmt.Println("LOC 6")
+			sim.Animals.DetermineCowAum(&h, year)
+			//fmt.Println("LOC 7")
+		}
+		if err := sim.Animals.Err(); err != nil {
+			return err
+		}
+	}
+
+	if sim.Index != nil {
+		sim.Index.SetActiveCowList()
+	}
+
+	return nil
+}
+
+// Bump a trait's value by 1 unit
+func (sim *Simulation) bumpComponent(trait animal.BumpComponent_t) error {
+
+	var notInIndexList []int
+	for i, c := range sim.Animals.ComponentList {
+		//fmt.Println("LOC 0", i, c)
+		if sim.Index == nil || !sim.Index.IsInIndex(c) {
+			notInIndexList = append(notInIndexList, i)
+		}
+	}
+	idx := sim.Animals.GeneticIndex(trait.TraitName, trait.Component)
+	//fmt.Println("LOC 1", notInIndexList, idx)
+	if idx < 0 {
+		return &BumpError{Bump: sim.Animals.BumpComponent, Msg: "the component is not in the Components list"}
+	}
+	// Bulls bought after the burnin get the same change
+	sim.Animals.BullBump = make([]float64, len(sim.Animals.ComponentList))
+	sim.Animals.BullBump[idx] += trait.Value
+	for _, t := range notInIndexList {
+		tVar := sim.Covariances.VcMatrix["genetic"].At(idx, idx)
+		tCov := sim.Covariances.VcMatrix["genetic"].At(idx, t)
+		sim.Animals.BullBump[t] += trait.Value * tCov / tVar
+	}
+
+	for _, n := range sim.Animals.HerdNames {
+		h := sim.Animals.Herds[n]
+
+		for _, b := range h.Bulls {
+			bv := b.BreedingValue.AtVec(idx) + trait.Value
+			sim.Animals.Records[b.Id-1].BreedingValue.SetVec(idx, bv)
+			for _, t := range notInIndexList {
+				tVar := sim.Covariances.VcMatrix["genetic"].At(idx, idx)
+				tCov := sim.Covariances.VcMatrix["genetic"].At(idx, t)
+				bv := b.BreedingValue.AtVec(t) + trait.Value*tCov/tVar
+				//fmt.Println("LOC 2", b.Id, bv, trait.Value, b.BreedingValue.AtVec(t), t, tVar, tCov)
+				sim.Animals.Records[b.Id-1].BreedingValue.SetVec(t, bv)
+			}
+		}
+
+	}
+
+	// and so do the AI sires
+	for _, s := range sim.Animals.AISires {
+		bv := sim.Animals.Records[s.Id-1].BreedingValue
+		bv.SetVec(idx, bv.AtVec(idx)+sim.Animals.BullBump[idx])
+		for _, t := range notInIndexList {
+			bv.SetVec(t, bv.AtVec(t)+sim.Animals.BullBump[t])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+// simulation
+//
+// One in-process run of the iGenDec herd simulation
+package simulation
+
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/blgolden/iGenDecModel/iGenDec/animal"
+	"github.com/blgolden/iGenDecModel/iGenDec/config"
+	"github.com/blgolden/iGenDecModel/iGenDec/ecoIndex"
+	"github.com/blgolden/iGenDecModel/iGenDec/logger"
+	"github.com/blgolden/iGenDecModel/iGenDec/varStuff"
+)
+
+// Version of the simulation's results.  Bump it in every change that makes the
+// same parameter files and seed give different net returns, so replicates
+// cached by earlier model code are not reused.
+const ModelVersion = 3
+
+// Params are the parsed hjson parameter files.  They are only read by a
+// Simulation so one Params can be shared by any number of simulations.
+type Params struct {
+	GenParmFile   string                 // Name of the parameter file
+	IndexParmFile string                 // Name of the parameter file for configuring the index
+	Model         map[string]interface{} // setup the map of the array of json name:value pairs - notice "interface{}"
+	Index         map[string]interface{} // nil if there is no index parameter file
+
+	Gen       *config.GenParm   // The checked and typed model parameters
+	IndexParm *config.IndexParm // The checked and typed index parameters, nil without an index
+
+	Covariances *varStuff.Covariances // Factored once by FactorCovariances and shared, otherwise nil
+}
+
+// Options that differ between replicates of the same Params
+type Options struct {
+	Seed       int64  // Random number generator seed
+	Bump       string // Component to bump 1 unit up after burnin - e.g., WW,D,1 (optional)
+	OutputMode string // verbose, model, conception, etc.
+
+	GenomicTest bool // Genomic test the heifer candidates after the burnin, from the genomicTest keys
+}
+
+// Result of one simulation run
+type Result struct {
+	NetReturns float64   // Discounted net returns per cow exposed
+	Document   *Document // The tables behind NetReturns
+}
+
+// Simulation owns all of the state of one run of the model
+type Simulation struct {
+	OutputMode string
+
+	Param          *config.GenParm // The model parameters
+	ParamFile      string          // Name of the parameter file for messages
+	IndexParamFile string          // Name of the index parameter file, if any
+
+	Animals     *animal.State
+	Index       *ecoIndex.Index // nil when no index parameter file was given
+	Covariances *varStuff.Covariances
+
+	nYears       int  // Burnin + YearsPlanningHorizon
+	burninMarker int  // Length of the Burnin Records in Records[]
+	burnedIn     bool // The foundation and burnin have been simulated or restored
+}
+
+// Read the model and optional index hjson files and check them together
+func LoadParams(genParm string, indexParm string) (*Params, error) {
+
+	p := new(Params)
+	p.GenParmFile = genParm
+	p.IndexParmFile = indexParm
+
+	var err error
+	if p.Model, err = config.ReadHjson(genParm); err != nil {
+		return nil, err
+	}
+	if p.Gen, err = config.NewGenParm(p.Model); err != nil {
+		return nil, fmt.Errorf("%s:\n%w", genParm, err)
+	}
+	if indexParm != "" {
+		if p.Index, err = config.ReadHjson(indexParm); err != nil {
+			return nil, err
+		}
+		if p.IndexParm, err = config.NewIndexParm(p.Index); err != nil {
+			return nil, fmt.Errorf("%s:\n%w", indexParm, err)
+		}
+	}
+
+	// Everything iGenDec validate finds, so bad files are rejected before any simulating
+	if errs := config.Validate(p.Gen, p.IndexParm); len(errs) > 0 {
+		files := genParm
+		if indexParm != "" {
+			files += " and " + indexParm
+		}
+		return nil, fmt.Errorf("%s:\n%w", files, errs)
+	}
+	for _, w := range config.PriceGaps(p.IndexParm) { // Only a problem if a calf is sold at that weight
+		logger.LogWriter(fmt.Sprintf("WARNING: %s: %v", indexParm, w))
+	}
+
+	return p, nil
+}
+
+// Decompose the genetic and residual covariance matrices once so every
+// simulation made from these Params shares the same Cholesky factors
+func (p *Params) FactorCovariances() (err error) {
+	p.Covariances, err = factorCovariances(p.Gen, "quiet")
+	return err
+}
+
+func factorCovariances(g *config.GenParm, outputMode string) (vs *varStuff.Covariances, err error) {
+	vs = varStuff.NewCovariances(outputMode)
+	if vs.GvCholesky, err = vs.DecompVar("genetic", g.Genetic); err != nil {
+		return nil, err
+	}
+	if vs.RvCholesky, err = vs.DecompVar("residual", g.Residual); err != nil {
+		return nil, err
+	}
+	return vs, nil
+}
+
+// Set up a simulation from the parameters.  Nothing is simulated until Run.
+func New(p *Params, opt Options) (*Simulation, error) {
+
+	sim := new(Simulation)
+	sim.OutputMode = opt.OutputMode
+	sim.Param = p.Gen
+	sim.ParamFile = p.GenParmFile
+	sim.IndexParamFile = p.IndexParmFile
+
+	sim.Animals = animal.NewState(opt.Seed, opt.OutputMode)
+	sim.Animals.BumpComponent = opt.Bump
+	sim.Animals.GenomicTest = opt.GenomicTest
+
+	sim.Covariances = p.Covariances
+
+	if p.Index != nil {
+		var err error
+		if sim.Index, err = ecoIndex.NewIndex(p.Index, sim.Animals, opt.OutputMode); err != nil {
+			return nil, fmt.Errorf("%s: %w", p.IndexParmFile, err)
+		}
+	}
+
+	if err := sim.initSimulation(); err != nil {
+		return nil, err
+	}
+
+	return sim, nil
+}
+
+// Simulate the herd and value it with the index
+func (sim *Simulation) Run(ctx context.Context) (Result, error) {
+
+	var result Result
+
+	if err := sim.BurnIn(ctx); err != nil {
+		return result, err
+	}
+
+	if err := sim.simulateYears(ctx); err != nil {
+		return result, err
+	}
+
+	sim.printTables()
+
+	if sim.Index != nil {
+		var err error
+		if result.NetReturns, err = sim.Index.ProcessNetReturns(sim.nYears); err != nil {
+			return result, err
+		}
+	}
+	result.Document = sim.document(result.NetReturns)
+
+	sim.Animals.DumpRecords()
+
+	sim.Animals.DumpBreedingRecords()
+
+	return result, nil
+}
+
+// Make the foundation herd and simulate the burnin years.  Run does this
+// itself unless it has already been done or a snapshot was restored.
+func (sim *Simulation) BurnIn(ctx context.Context) error {
+
+	if sim.burnedIn {
+		return nil
+	}
+
+	if err := sim.Animals.MakeGenome(sim.Covariances.GvCholesky); err != nil {
+		return err
+	}
+
+	if _, err := sim.Animals.MakeFoundationCowHerd(sim.Covariances.GvCholesky, sim.Covariances.RvCholesky); err != nil {
+		return err
+	}
+
+	sim.Animals.MakeFoundationHeifers(sim.Covariances.GvCholesky, sim.Covariances.RvCholesky)
+
+	sim.Animals.MakeFoundationBulls(sim.Covariances.GvCholesky, sim.Covariances.RvCholesky)
+
+	sim.Animals.MakeAISires(sim.Covariances.GvCholesky, sim.Covariances.RvCholesky)
+	sim.Animals.MakeEmbryoDonors(sim.Covariances.GvCholesky, sim.Covariances.RvCholesky)
+
+	if err := sim.Animals.Err(); err != nil {
+		return err
+	}
+
+	if err := sim.burnInSimulation(ctx); err != nil {
+		return err
+	}
+
+	sim.burnedIn = true
+	return nil
+}
+
+// Serialise the state after the burnin so other runs with the same
+// parameters and seed can start from it
+func (sim *Simulation) Snapshot() ([]byte, error) {
+	if !sim.burnedIn {
+		return nil, errors.New("snapshot requested before the burnin was simulated")
+	}
+	return sim.Animals.Snapshot()
+}
+
+// Start from a snapshot rather than simulating the foundation and burnin.
+// The snapshot must come from a simulation with the same parameters.
+func (sim *Simulation) Restore(data []byte) error {
+	if err := sim.Animals.Restore(data); err != nil {
+		return err
+	}
+	sim.burnedIn = true
+	return nil
+}
+
+func (sim *Simulation) printTables() {
+	if sim.OutputMode == "verbose" || sim.OutputMode == "conception" {
+
+		var cowRate, heiferRate float64
+
+		// Print the breeding summary table
+		fmt.Printf("               ________Cows___________________________   | _____________Heifers___________________\n")
+		fmt.Printf("Year   Herd    Exposed    Bred    Open    Rate     Old   |  Exposed   Bred    Open    Rate    Died\n")
+		for i := sim.Animals.Burnin + 1; i <= sim.nYears; i++ {
+
+			for _, n := range sim.Animals.HerdNames {
+				var h animal.HerdYear_t
+				h.Year = i
+				h.Herd = n
+
+				t := sim.Animals.BreedingRecordsYearTable[h]
+
+				fmt.Printf("%4d   %-7s %7d %7d %7d %7.1f %7d   | %7d %7d %7d %7.1f %7d\n",
+					h.Year,
+					h.Herd,
+					t.CowsExposed,
+					t.CowsBred,
+					t.CowsCulledOpen,
+					float64(t.CowsBred)/float64(t.CowsExposed)*100.,
+					t.CowsCulledOld,
+					t.HeifersExposed,
+					t.HeifersBred,
+					t.HeifersCulledOpen,
+					float64(t.HeifersBred)/float64(t.HeifersExposed)*100.,
+					t.HeifersDiedCalving)
+
+				cowRate = cowRate + float64(t.CowsBred)/float64(t.CowsExposed)*100.
+				heiferRate = heiferRate + float64(t.HeifersBred)/float64(t.HeifersExposed)*100.
+			}
+		}
+		fmt.Printf("Average:                             %10.2f                                  %10.2f\n", cowRate/float64(sim.nYears-sim.Animals.Burnin), heiferRate/float64(sim.nYears-sim.Animals.Burnin))
+
+		sim.Animals.PrintInbreeding()
+		sim.Animals.PrintHeterosis()
+		sim.Animals.PrintCalvingEase()
+		sim.Animals.PrintGenome()
+	}
+}
@@ -0,0 +1,69 @@
+// simulation_test.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package simulation
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// Run a replicate of the three breed parameters
+func runThreeBreeds(t *testing.T, p *Params, seed int64, bump string) Result {
+	sim, err := New(p, Options{Seed: seed, Bump: bump, OutputMode: "quiet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := sim.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// The same seed repeats bit for bit, with and without a bump, when the
+// herd, bulls and calves are crosses of three breeds
+func TestSeedRepeats(t *testing.T) {
+	p, err := LoadParams("testdata/threeBreeds.hjson", "testdata/wean.hjson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.FactorCovariances(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, bump := range []string{"", "WW,D,1"} {
+		for _, seed := range []int64{1, 2, 3} {
+			first := runThreeBreeds(t, p, seed, bump)
+			for i := 0; i < 3; i++ {
+				again := runThreeBreeds(t, p, seed, bump)
+				if math.Float64bits(again.NetReturns) != math.Float64bits(first.NetReturns) {
+					t.Fatalf("seed %d bump %q: net returns %v then %v", seed, bump, first.NetReturns, again.NetReturns)
+				}
+				if !reflect.DeepEqual(again.Document, first.Document) {
+					t.Fatalf("seed %d bump %q: the tables differ between runs", seed, bump)
+				}
+			}
+		}
+	}
+}
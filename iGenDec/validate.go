@@ -0,0 +1,83 @@
+// validate
+//
+// iGenDec validate lists every problem in a genParm/indexParm pair
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/blgolden/iGenDecModel/iGenDec/config"
+)
+
+// Check the parameter files and print every problem found.  Returns the exit status
+func validate(genParm string, indexParm string) int {
+
+	var problems config.ParamErrors
+
+	// Parsing errors are ParamErrors too and are listed with the others
+	collect := func(err error) {
+		var pe config.ParamErrors
+		if errors.As(err, &pe) {
+			problems = append(problems, pe...)
+		}
+	}
+
+	model, err := config.ReadHjson(genParm)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	g, err := config.NewGenParm(model)
+	collect(err)
+
+	var x *config.IndexParm
+	if indexParm != "" {
+		index, err := config.ReadHjson(indexParm)
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		x, err = config.NewIndexParm(index)
+		collect(err)
+	}
+
+	problems = append(problems, config.Validate(g, x)...)
+	problems = append(problems, config.PriceGaps(x)...) // Only warnings when running
+
+	files := genParm
+	if indexParm != "" {
+		files += " and " + indexParm
+	}
+	if len(problems) == 0 {
+		fmt.Printf("No problems found in %s\n", files)
+		return 0
+	}
+
+	fmt.Printf("%d problem(s) found in %s:\n", len(problems), files)
+	for _, p := range problems {
+		fmt.Printf("\t%v\n", p)
+	}
+	return 1
+}
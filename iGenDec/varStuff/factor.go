@@ -26,94 +26,87 @@ import (
 
 	"github.com/blgolden/iGenDecModel/iGenDec/animal"
 
-	"errors"
 	"math"
 
-	"github.com/blgolden/iGenDecModel/iGenDec/ecoIndex"
-	"github.com/blgolden/iGenDecModel/iGenDec/logger"
-
 	"gonum.org/v1/gonum/mat"
 )
 
-var GvCholesky mat.Cholesky // The decomposed genetic covariance matrix
-var RvCholesky mat.Cholesky // The decomposed residual covariance matrix
-var VcMatrix map[string]mat.Symmetric
+// A covariance matrix that cannot be used
+type MatrixError struct {
+	Name   string // genetic or residual
+	Reason string
+}
+
+func (e *MatrixError) Error() string {
+	return fmt.Sprintf("the %s covariance matrix %s", e.Name, e.Reason)
+}
+
+// Covariances holds the genetic and residual covariance matrices of one
+// simulation and their Cholesky factors.
+type Covariances struct {
+	OutputMode string // verbose, model, etc.
+
+	GvCholesky mat.Cholesky // The decomposed genetic covariance matrix
+	RvCholesky mat.Cholesky // The decomposed residual covariance matrix
+	VcMatrix   map[string]mat.Symmetric
+
+	Vc map[string]Matvec_t
+}
 
 type Matvec_t struct {
 	v []float64
 }
 
-var Vc map[string]Matvec_t
+// Return an empty set of covariances
+func NewCovariances(outputMode string) *Covariances {
+	vs := new(Covariances)
+	vs.OutputMode = outputMode
+	vs.Vc = make(map[string]Matvec_t)
+	vs.VcMatrix = make(map[string]mat.Symmetric)
+	return vs
+}
 
 // Generic Decompose a covariance matrix
-func DecompVar(name string, param map[string]interface{}) (v mat.Cholesky) {
-	// Convert the values from the interface to float64 slice
-	array, ok := param[name].([]interface{})
-	if !ok {
-		logger.LogWriterFatal("'" + name + "' key not found")
-	}
+func (vs *Covariances) DecompVar(name string, values []float64) (v mat.Cholesky, err error) {
 
 	var m Matvec_t
-	for i := range array {
-		m.v = append(m.v, array[i].(float64))
-	}
-	Vc[name] = m
+	m.v = append(m.v, values...)
+	vs.Vc[name] = m
 
 	// Assign the slice to a NxN gonum matrix type
-	n := int(math.Sqrt(float64(len(Vc[name].v))))
-	if len(Vc[name].v)%n != 0 {
-		if *logger.OutputMode == "verbose" {
-			panic(errors.New("The variance matrix in the parameter file is not square"))
-		} else {
-			logger.LogWriterFatal("The variance matrix in the parameter file is not square")
-		}
+	n := int(math.Sqrt(float64(len(vs.Vc[name].v))))
+	if n == 0 || n*n != len(vs.Vc[name].v) {
+		return v, &MatrixError{Name: name, Reason: "is not square"}
 	}
-	if *logger.OutputMode == "verbose" {
+	if vs.OutputMode == "verbose" {
 		fmt.Printf("The "+name+" covariance matrix is %v x %v\n", n, n)
 	}
 
-	VcMatrix[name] = mat.NewSymDense(n, Vc[name].v)
-
-	// Stayability needs a residual std dev so stubbed in here
-	if name == "residual" {
-		rindx := animal.ResidualIndex("STAY")
-		if rindx >= 0 {
-			animal.ResidualStayStdDev = math.Sqrt(VcMatrix[name].At(rindx, rindx))
-			if *logger.OutputMode == "verbose" {
-				fmt.Println("The Stayability residual standard deviation is: ", animal.ResidualStayStdDev)
-			}
-		}
-		rindx = animal.ResidualIndex("HP")
-		if rindx >= 0 {
-			animal.ResidualHpStdDev = math.Sqrt(VcMatrix[name].At(rindx, rindx))
-			if *logger.OutputMode == "verbose" {
-				fmt.Println("The Hepfer Pregnancy residual standard deviation is: ", animal.ResidualHpStdDev)
-			}
-		}
-	}
+	vs.VcMatrix[name] = mat.NewSymDense(n, vs.Vc[name].v)
 
 	//fv := mat.Formatted(vcMatrix, mat.Prefix("      "), mat.Squeeze())
-	if *logger.OutputMode == "verbose" {
+	if vs.OutputMode == "verbose" {
 		fmt.Printf("vcMatrix = \n")
-		MatPrint(VcMatrix[name])
+		MatPrint(vs.VcMatrix[name])
 	}
 
 	// Get the inverse using gonum
+	v, ok := vs.Factor(name, vs.VcMatrix[name])
+	if !ok {
+		return v, &MatrixError{Name: name, Reason: "is not positive definite"}
+	}
 
-	v, _ = Factor(name, VcMatrix[name])
-
-	return v
+	return v, nil
 }
 
-func Factor(name string, vcMatrix mat.Symmetric) (v mat.Cholesky, ok bool) {
-
+func (vs *Covariances) Factor(name string, vcMatrix mat.Symmetric) (v mat.Cholesky, ok bool) {
 	if ok = v.Factorize(vcMatrix); !ok {
 		return v, ok
 	}
 
 	// This can be commented out in production
 	// Extract the factorization and check that it equals the original matrix.
-	if *logger.OutputMode == "verbose" {
+	if vs.OutputMode == "verbose" {
 		var t mat.TriDense
 		v.LTo(&t)
 		var test mat.Dense
@@ -121,7 +114,6 @@ func Factor(name string, vcMatrix mat.Symmetric) (v mat.Cholesky, ok bool) {
 		fmt.Println()
 		fmt.Printf("check: L * L^T = \n         %0.4v\n", mat.Formatted(&test, mat.Prefix("          ")))
 	}
-
 	return v, ok
 }
 
@@ -131,33 +123,36 @@ func MatPrint(X mat.Matrix) {
 	fmt.Printf("%v\n", fa)
 }
 
+// Still can't constrain the covariances until all submatrices are PD
+const constrainCovariances = false
+
 // Set covariance of all index components to zero except the bumped component
-func ConstrainedFactor(c animal.BumpComponent_t) {
+func (vs *Covariances) ConstrainedFactor(c animal.BumpComponent_t, st *animal.State, indexComponents []animal.Component_t) error {
 
-	// Still can't do this until all submatrices are PD
-	return
+	if !constrainCovariances {
+		return nil
+	}
 
 	// genetic covaraince matrix
-	thisTraitIndx := animal.GeneticIndex(c.TraitName, c.Component)
-	_, cols := VcMatrix["genetic"].Dims()
-	for _, x := range ecoIndex.IndexComponents {
+	thisTraitIndx := st.GeneticIndex(c.TraitName, c.Component)
+	_, cols := vs.VcMatrix["genetic"].Dims()
+	for _, x := range indexComponents {
 		if !(c.TraitName == x.TraitName && c.Component == x.Component) {
-			indx := animal.GeneticIndex(x.TraitName, x.Component)
+			indx := st.GeneticIndex(x.TraitName, x.Component)
 			loc := Index2D(indx, thisTraitIndx, cols)
-			Vc["genetic"].v[loc] = 0.0
+			vs.Vc["genetic"].v[loc] = 0.0
 			loc = Index2D(thisTraitIndx, indx, cols)
-			Vc["genetic"].v[loc] = 0.0
+			vs.Vc["genetic"].v[loc] = 0.0
 		}
 	}
 	var ok bool
-	VcMatrix["genetic"] = mat.NewSymDense(cols, Vc["genetic"].v)
-	GvCholesky, ok = Factor("genetic", VcMatrix["genetic"])
+	vs.VcMatrix["genetic"] = mat.NewSymDense(cols, vs.Vc["genetic"].v)
+	vs.GvCholesky, ok = vs.Factor("genetic", vs.VcMatrix["genetic"])
 	if !ok {
-		logger.LogWriterFatal("Could not factorize genetic for " + c.TraitName + c.Component)
+		return &MatrixError{Name: "genetic", Reason: "is not positive definite when constrained for " + c.TraitName + "," + c.Component}
 	}
-	// Skip the residual because the submatrices are not pd
-	return
 
+	// Skip the residual because the submatrices are not pd
 	/*
 		// residual covaraince matrix
 		thisTraitIndx = animal.ResidualIndex(c.TraitName)
@@ -171,13 +166,12 @@ func ConstrainedFactor(c animal.BumpComponent_t) {
 				Vc["residual"].v[loc] = 0.0
 			}
 		}
-
 		VcMatrix["residual"] = mat.NewSymDense(cols, Vc["residual"].v)
 		//MatPrint(VcMatrix["residual"])
 		//RvCholesky = Factor("residual", VcMatrix["residual"])
-
 		return
 	*/
+	return nil
 }
 
 // Return the 1D index of a 2D matrix stored as array
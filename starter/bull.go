@@ -0,0 +1,277 @@
+// bull.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+
+	"github.com/blgolden/iGenDecModel/iGenDec/logger"
+
+	"github.com/hjson/hjson-go"
+)
+
+// An EPD of a candidate bull, or of the reference bull without an accuracy
+type bullEpd_t struct {
+	Trait     string  `json:"trait"` // CE is valued as CD with the sign changed, like the outputFile
+	Component string  `json:"component"`
+	Epd       float64 `json:"epd"`
+	Accuracy  float64 `json:"accuracy"` // BIF accuracy
+}
+
+// A candidate bull from the -bulls file
+type bull_t struct {
+	Name        string      `json:"name"`
+	Price       float64     `json:"price"`
+	Years       int         `json:"years"`       // Expected breeding seasons of service
+	Salvage     float64     `json:"salvage"`     // Value as a cull at the end of service
+	CowsPerBull float64     `json:"cowsPerBull"` // Optional, the herd's ratio when 0
+	Epds        []bullEpd_t `json:"epds"`
+}
+
+// The -bulls file.  Reference is the EPD of the bulls the herd would
+// otherwise buy, EPDs not given are 0.
+type bullFile_t struct {
+	Reference []bullEpd_t `json:"reference"`
+	Bulls     []bull_t    `json:"bulls"`
+}
+
+// The value of one candidate bull
+type bullValue_t struct {
+	Name           string   `json:"name"`
+	Price          float64  `json:"price"`
+	Years          int      `json:"years"`
+	CowsPerBull    float64  `json:"cowsPerBull"`
+	ValuePerCow    float64  `json:"valuePerExposure"`    // Index value of his EPDs over the reference
+	StdDevPerCow   float64  `json:"stddevPerExposure"`   // From the EPD accuracies
+	BreakevenPrice float64  `json:"breakevenPrice"`      // Price that makes the NPV 0
+	NPV            float64  `json:"npv"`                 // Expected net present value of buying him
+	StdDevNPV      float64  `json:"stddevNpv"`           // Its standard deviation from the EPD accuracies
+	Low            float64  `json:"low"`                 // 10th percentile of the NPV
+	High           float64  `json:"high"`                // 90th percentile
+	ProbProfitable float64  `json:"probabilityProfit"`   // That the NPV is more than 0
+	NotValued      []string `json:"notValued,omitempty"` // EPDs not in the index
+}
+
+// z of the 90th percentile of a normal
+const z90 = 1.2815515655446004
+
+// starter value-bull values the candidate bulls in -bulls with the MEV
+func valueBull() {
+
+	bullsFile := flag.String("bulls", "", "hjson file of the candidate bulls (required)")
+
+	e := initialize()
+
+	if *bullsFile == "" {
+		logger.LogWriterFatal("value-bull needs a -bulls file of candidate bulls")
+	}
+	bf, err := loadBulls(*bullsFile)
+	if err != nil {
+		logger.LogWriterFatal(err.Error())
+	}
+
+	if err = e.simulateIndexComponents(context.Background()); err != nil {
+		logger.LogWriterFatal(err.Error())
+	}
+	e.loadGeneticVariances()
+
+	values, err := e.valueBulls(bf)
+	if err != nil {
+		logger.LogWriterFatal(err.Error())
+	}
+
+	switch *logger.OutputMode {
+	case "web":
+		data, _ := json.Marshal(values)
+		fmt.Println(string(data))
+	default:
+		e.publishIndex()
+		publishBullValues(values)
+	}
+}
+
+// Read and check the candidate bulls
+func loadBulls(fileName string) (*bullFile_t, error) {
+
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("reading bulls: %w", err)
+	}
+	var m interface{}
+	if err = hjson.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("unmarshalling bulls %s: %w", fileName, err)
+	}
+	if data, err = json.Marshal(m); err != nil {
+		return nil, fmt.Errorf("remarshalling bulls %s: %w", fileName, err)
+	}
+	bf := new(bullFile_t)
+	if err = json.Unmarshal(data, bf); err != nil {
+		return nil, fmt.Errorf("unmarshalling bulls %s: %w", fileName, err)
+	}
+
+	if len(bf.Bulls) == 0 {
+		return nil, fmt.Errorf("%s: no bulls", fileName)
+	}
+	for _, b := range bf.Bulls {
+		if b.Years < 1 {
+			return nil, fmt.Errorf("%s: bull %q must have at least 1 year of service", fileName, b.Name)
+		}
+		if b.Price < 0 || b.Salvage < 0 || b.CowsPerBull < 0 {
+			return nil, fmt.Errorf("%s: bull %q price, salvage and cowsPerBull must not be negative", fileName, b.Name)
+		}
+		for _, d := range b.Epds {
+			if d.Accuracy < 0 || d.Accuracy > 1 {
+				return nil, fmt.Errorf("%s: bull %q %s,%s accuracy %v must be from 0 to 1", fileName, b.Name, d.Trait, d.Component, d.Accuracy)
+			}
+		}
+	}
+	return bf, nil
+}
+
+// The row of the MEV table an EPD is valued with and the sign of its MEV
+func (e *estimate_t) mevFor(d bullEpd_t) (int, float64) {
+	trait, sign := d.Trait, 1.
+	if trait == "CE" {
+		trait, sign = "CD", -1.
+	}
+	for i, m := range e.mevTable {
+		if m.trait == trait && m.component == d.Component {
+			return i, sign
+		}
+	}
+	return -1, 0
+}
+
+// The herd's cows per bull, from bullManagement or else the foundation
+// herds, weighted by the herds' cows
+func (e *estimate_t) cowsPerBull() (float64, error) {
+	g := e.params.Gen
+	if g.NFoundationBulls <= 0 {
+		return 0, fmt.Errorf("%s: nFoundationBulls must be positive to give the herd's cows per bull", e.params.GenParmFile)
+	}
+	var cows, ratio float64
+	for _, h := range g.Herds {
+		r := float64(h.NumberCows) / float64(g.NFoundationBulls)
+		for _, m := range g.BullManagement {
+			if m.Herd == h.Name {
+				r = m.CowsPerBull
+			}
+		}
+		cows += float64(h.NumberCows)
+		ratio += r * float64(h.NumberCows)
+	}
+	return ratio / cows, nil
+}
+
+// Value each bull's progeny over his years of service.  MEV are already the
+// discounted net returns per exposure of the progeny's expressions, so each
+// year of service is worth the index value of his EPDs over the reference
+// times his cows, discounted to the purchase.  The uncertainty is the
+// prediction error of his EPDs, (1 - BIF accuracy) times the genetic SD.
+func (e *estimate_t) valueBulls(bf *bullFile_t) ([]bullValue_t, error) {
+
+	rate := e.params.IndexParm.DiscountRate
+
+	reference := make(map[int]float64)
+	for _, d := range bf.Reference {
+		i, sign := e.mevFor(d)
+		if i < 0 {
+			return nil, fmt.Errorf("reference EPD %s,%s is not an index component", d.Trait, d.Component)
+		}
+		reference[i] = sign * d.Epd
+	}
+
+	var values []bullValue_t
+	for _, b := range bf.Bulls {
+		v := bullValue_t{Name: b.Name, Price: b.Price, Years: b.Years, CowsPerBull: b.CowsPerBull}
+		if v.CowsPerBull == 0 {
+			var err error
+			if v.CowsPerBull, err = e.cowsPerBull(); err != nil {
+				return nil, err
+			}
+		}
+
+		epds := make(map[int]float64)
+		var variance float64
+		for _, d := range b.Epds {
+			i, sign := e.mevFor(d)
+			if i < 0 {
+				v.NotValued = append(v.NotValued, d.Trait+","+d.Component)
+				continue
+			}
+			epds[i] = sign * d.Epd
+			pe := e.mevTable[i].mev * (1 - d.Accuracy) * e.mevTable[i].geneticStdDev // mev*2 by the EPD error SD
+			variance += pe * pe
+		}
+		for i, m := range e.mevTable {
+			v.ValuePerCow += m.mev * 2 * (epds[i] - reference[i]) // MEV apply to EBV, twice the EPD
+		}
+		v.StdDevPerCow = math.Sqrt(variance)
+
+		var exposures float64 // Discounted to the purchase
+		for t := 0; t < b.Years; t++ {
+			exposures += v.CowsPerBull / math.Pow(1+rate, float64(t))
+		}
+		salvage := b.Salvage / math.Pow(1+rate, float64(b.Years))
+
+		v.BreakevenPrice = v.ValuePerCow*exposures + salvage
+		v.NPV = v.BreakevenPrice - b.Price
+		v.StdDevNPV = v.StdDevPerCow * exposures
+		v.Low = v.NPV - z90*v.StdDevNPV
+		v.High = v.NPV + z90*v.StdDevNPV
+		switch {
+		case v.StdDevNPV > 0:
+			v.ProbProfitable = 0.5 * (1 + math.Erf(v.NPV/(v.StdDevNPV*math.Sqrt2)))
+		case v.NPV > 0:
+			v.ProbProfitable = 1
+		}
+
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// Write the bull values to the screen
+func publishBullValues(values []bullValue_t) {
+
+	fmt.Println("\t ______________________________________________________________________________________________________________")
+	fmt.Println("\t| Bull                 |      Price | Yrs | Cows/Bull |  $/Exposure |   Breakeven |        NPV |  SD(NPV) | P(NPV>0) |")
+	fmt.Println("\t|______________________|____________|_____|___________|_____________|_____________|____________|__________|__________|")
+	for _, v := range values {
+		fmt.Printf("\t| %-20.20s | %10.2f | %3d | %9.1f | %11.2f | %11.2f | %10.2f | %8.2f | %8.2f |\n",
+			v.Name, v.Price, v.Years, v.CowsPerBull, v.ValuePerCow, v.BreakevenPrice, v.NPV, v.StdDevNPV, v.ProbProfitable)
+	}
+	fmt.Println("\t|______________________________________________________________________________________________________________|")
+	fmt.Printf("\tNPV from 10th to 90th percentile:\n")
+	for _, v := range values {
+		fmt.Printf("\t  %-20s %10.2f to %10.2f\n", v.Name, v.Low, v.High)
+		if len(v.NotValued) > 0 {
+			fmt.Printf("\t  %-20s EPDs not in the index: %v\n", "", v.NotValued)
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,154 @@
+// cache.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/blgolden/iGenDecModel/iGenDec/simulation"
+)
+
+// genParm keys that do not change a replicate's net returns
+var genParmIgnored = []string{"Comment", "comment", "target-database", "cowagefilename", "phenotypeFile",
+	"stayPhenotypeFile", "HPPhenotypeFile", "CDPhenotypeFile", "CarcassPhenotypeFile"}
+
+// indexParm keys that do not change a replicate's net returns.  Prices, costs and
+// the index components all do.  Only net returns are cached, not the animals
+// behind them, so a replicate cannot be re-priced: editing any price or cost
+// gives a new hash and every replicate is simulated again.
+var indexParmIgnored = []string{"Comment", "comment"}
+
+// Layout of the cache.  Bump it when what is stored for a replicate changes.
+// Changes to the model's results are covered by simulation.ModelVersion, which
+// is also in the hash.
+const cacheFormat = 1
+
+// Net returns of finished replicates stored under
+// dir/<parameter hash>/<trait>,<component>,<bump>/<seed>
+type replicateCache_t struct {
+	dir string // Caching is off when empty
+}
+
+// The cache is shared by every run of starter for this user
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "iGenDec", "replicates")
+}
+
+// The cache for one pair of parameter files.  An empty dir turns caching off.
+func newReplicateCache(dir string, params *simulation.Params) (*replicateCache_t, error) {
+	if dir == "" {
+		return &replicateCache_t{}, nil
+	}
+	hash, err := paramHash(params)
+	if err != nil {
+		return nil, err
+	}
+	return &replicateCache_t{dir: filepath.Join(dir, hash)}, nil
+}
+
+// Hash the parsed hjson so formatting, comments and key order don't matter
+func paramHash(params *simulation.Params) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(fmt.Sprintf("%s model %d cache %d\n", version, simulation.ModelVersion, cacheFormat)))
+	for _, p := range []struct {
+		m       map[string]interface{}
+		ignored []string
+	}{{params.Model, genParmIgnored}, {params.Index, indexParmIgnored}} {
+		m := make(map[string]interface{}, len(p.m))
+		for k, v := range p.m {
+			m[k] = v
+		}
+		for _, k := range p.ignored {
+			delete(m, k)
+		}
+		b, err := json.Marshal(m) // map keys are sorted
+		if err != nil {
+			return "", err
+		}
+		h.Write(b)
+		h.Write([]byte("\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32], nil
+}
+
+// Directory of one bump, e.g. WW,D,1.0 or base
+func (rc *replicateCache_t) bumpDir(bump string) string {
+	if bump == "" {
+		bump = "base"
+	}
+	return filepath.Join(rc.dir, strings.Replace(bump, string(filepath.Separator), "_", -1))
+}
+
+// Net returns of a replicate if it has been simulated before
+func (rc *replicateCache_t) get(seed int, bump string) (float64, bool) {
+	if rc.dir == "" {
+		return 0, false
+	}
+	b, err := ioutil.ReadFile(filepath.Join(rc.bumpDir(bump), strconv.Itoa(seed)))
+	if err != nil {
+		return 0, false
+	}
+	nr, err := strconv.ParseFloat(strings.TrimSpace(string(b)), 64)
+	if err != nil {
+		return 0, false
+	}
+	return nr, true
+}
+
+// Store a replicate's net returns.  The file is renamed into place so an
+// interrupted run never leaves a partial value behind.
+func (rc *replicateCache_t) put(seed int, bump string, netReturns float64) error {
+	if rc.dir == "" {
+		return nil
+	}
+	dir := rc.bumpDir(bump)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, ".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(strconv.FormatFloat(netReturns, 'g', -1, 64) + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(dir, strconv.Itoa(seed)))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,205 @@
+// database.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hjson/hjson-go"
+)
+
+// Optional table in -database-path of the adjustments that put the EPDs of
+// each breed's database on a common base
+var filenameAcrossBreed = "across_breed.hjson"
+
+// A row of the across breed table.  Databases without rows are the base breed.
+type acrossBreed_t struct {
+	Database   string  `json:"database"` // Directory of the breed's database in -database-path, one of target-database
+	Key        string  `json:"name"`     // Trait,Comp of the xref
+	Adjustment float64 `json:"adjustment"`
+}
+
+// An animal of a target database with its fields by their xref name
+type dbAnimal_t struct {
+	database string
+	row      int // Line of its csv after the header
+	fields   map[string]string
+}
+
+// The animals of the target databases, their EPDs adjusted to a common base
+type database_t struct {
+	names    []string // Directories of the databases in -database-path
+	animals  []dbAnimal_t
+	fields   map[string]bool // Names in any of the xrefs
+	selected []string        // Names of the select fields in the order of the xrefs
+}
+
+// The target-database key names one database or a list of them
+func (e *estimate_t) targetDatabases() []string {
+	var names []string
+	switch t := e.params.Model["target-database"].(type) {
+	case string:
+		names = append(names, t)
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				names = append(names, s)
+			}
+		}
+	}
+	var databases []string
+	for _, n := range names {
+		if n = strings.TrimSpace(n); n != "" {
+			databases = append(databases, n)
+		}
+	}
+	return databases
+}
+
+// Read the target databases, if there are any, each with its own xref, adjust
+// them to a common base and link them to the MEV table
+func (e *estimate_t) loadDatabase(databasePath string) (*database_t, error) {
+	names := e.targetDatabases()
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	if databasePath == "" {
+		return nil, fmt.Errorf("target-database specified but -database-path parameter not set")
+	}
+
+	adjustments, err := loadAcrossBreed(databasePath)
+	if err != nil {
+		return nil, err
+	}
+	listed := make(map[string]bool)
+	for _, database := range names {
+		listed[database] = true
+	}
+	for i, adj := range adjustments { // A misspelt breed would otherwise go unadjusted
+		if !listed[adj.Database] {
+			return nil, fmt.Errorf("%s row %d: database %s is not in target-database %v", filenameAcrossBreed, i+1, adj.Database, names)
+		}
+	}
+
+	db := &database_t{names: names, fields: make(map[string]bool)}
+	selected := make(map[string]bool)
+	for _, database := range names {
+		comppath := filepath.Join(databasePath, database)
+		csvfilename, err := findFilename(comppath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", database, err)
+		}
+		compFile := filepath.Join(comppath, csvfilename)
+		csvFile, err := os.Open(compFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open datafile %s: %w", compFile, err)
+		}
+		dataMap := CSVToMap(csvFile)
+		csvFile.Close()
+
+		xref, err := loadXref(comppath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", database, err)
+		}
+
+		var fields []Field
+		for _, x := range xref {
+			fields = append(fields, x)
+			db.fields[x.Key] = true
+		}
+		sort.Slice(fields, func(i, j int) bool { return fields[i].idx < fields[j].idx })
+		for _, x := range fields {
+			if x.Select && !selected[x.Key] {
+				selected[x.Key] = true
+				db.selected = append(db.selected, x.Key)
+			}
+		}
+
+		for row, c := range dataMap {
+			a := dbAnimal_t{database: database, row: row + 1, fields: make(map[string]string)}
+			for _, x := range fields {
+				a.fields[x.Key] = c[x.Header]
+			}
+			for _, adj := range adjustments {
+				if adj.Database != database {
+					continue
+				}
+				if v, err := strconv.ParseFloat(strings.TrimSpace(a.fields[adj.Key]), 64); err == nil {
+					a.fields[adj.Key] = strconv.FormatFloat(math.Round((v+adj.Adjustment)*1e6)/1e6, 'f', -1, 64) // No float noise in the exports
+				}
+			}
+			db.animals = append(db.animals, a)
+		}
+
+		if e.outputMode == "verbose" {
+			fmt.Printf("Read %d animals from the %s database\n", len(dataMap), database)
+		}
+	}
+
+	if len(names) > 1 && len(adjustments) == 0 && e.outputMode == "verbose" {
+		fmt.Printf("WARNING: no %s in %s, the EPDs of %v are compared on their own bases\n", filenameAcrossBreed, databasePath, names)
+	}
+
+	e.linkDatabaseAndMevTable(db)
+
+	return db, nil
+}
+
+// Read the across breed adjustments, none when there is no table
+func loadAcrossBreed(databasePath string) ([]acrossBreed_t, error) {
+
+	data, err := ioutil.ReadFile(filepath.Join(databasePath, filenameAcrossBreed))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading across breed adjustments: %w", err)
+	}
+
+	var m interface{}
+	if err = hjson.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("unmarshalling %s: %w", filenameAcrossBreed, err)
+	}
+	if data, err = json.Marshal(m); err != nil {
+		return nil, fmt.Errorf("remarshalling %s: %w", filenameAcrossBreed, err)
+	}
+	var adjustments []acrossBreed_t
+	if err = json.Unmarshal(data, &adjustments); err != nil {
+		return nil, fmt.Errorf("unmarshalling %s: %w", filenameAcrossBreed, err)
+	}
+
+	for i, a := range adjustments {
+		if a.Database == "" || len(strings.Split(a.Key, ",")) != 2 {
+			return nil, fmt.Errorf("%s row %d: needs a database and a Trait,Comp name", filenameAcrossBreed, i+1)
+		}
+	}
+	return adjustments, nil
+}
@@ -0,0 +1,360 @@
+// estimate.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/blgolden/iGenDecModel/iGenDec/animal"
+	"github.com/blgolden/iGenDecModel/iGenDec/ecoIndex"
+	"github.com/blgolden/iGenDecModel/iGenDec/logger"
+	"github.com/blgolden/iGenDecModel/iGenDec/simulation"
+
+	"github.com/remeh/sizedwaitgroup"
+	"gonum.org/v1/gonum/stat"
+)
+
+type mevTable_t struct {
+	trait            string
+	component        string
+	meanNetReturns   float64
+	stddevNetReturns float64
+	mev              float64
+	stddevMeanNR     float64
+	correlation      float64 // between traits and index when a dataset is named.
+	emphasis         float64 // percent emphasis of this trait
+	geneticStdDev    float64 // genetic variance of this component
+	inDatabase       bool    // The target databases have EPDs of this component
+}
+
+// Net returns of one replicate or the reason it failed
+type replicate_t struct {
+	seed       int
+	netReturns float64
+	err        error
+}
+
+// Progress of an estimate as each component is bumped
+type progress_t struct {
+	Trait     string  `json:"trait"`
+	Component string  `json:"component"`
+	State     string  `json:"state"` // burnin, running or done
+	Mean      float64 `json:"mean,omitempty"`
+	StdDev    float64 `json:"stddev,omitempty"`
+	Mev       float64 `json:"mev,omitempty"`
+}
+
+// One estimate of the MEV table: the base and every index component
+// simulated nSamples times from the same seeds
+type estimate_t struct {
+	params     *simulation.Params // Parsed hjson shared by all replicates
+	index      ecoIndex.Index     // Only the index parameters and components are used here
+	nSamples   int                // Number of simulations to spawn per bump
+	seeds      []int              // seeds used for each simulation
+	burnins    [][]byte           // snapshot after the burnin for each seed, nil if every replicate is cached
+	outputMode string
+	cache      *replicateCache_t
+
+	progress func(p progress_t) // Called as each component starts and finishes (optional)
+
+	mevTable                      []mevTable_t // Table of marginal economic values
+	bmean, bstddev, indexErrorVar float64
+}
+
+// Set up an estimate from loaded and factored parameters
+func newEstimate(params *simulation.Params, nSamples int, seed int64, outputMode string, cacheDir string) (*estimate_t, error) {
+
+	e := new(estimate_t)
+	e.params = params
+	e.nSamples = nSamples
+	e.outputMode = outputMode
+
+	e.index.Param = params.Index
+	if err := e.index.LoadIndexComponents(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if e.cache, err = newReplicateCache(cacheDir, params); err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+
+	for i := 0; i < nSamples; i++ {
+		e.seeds = append(e.seeds, rng.Intn(100000))
+	}
+
+	return e, nil
+}
+
+func (e *estimate_t) report(p progress_t) {
+	if e.progress != nil {
+		e.progress(p)
+	}
+}
+
+// The bump of a component, empty for the base
+func bumpFor(comp animal.Component_t) string {
+	if comp.TraitName == "base" {
+		return ""
+	}
+	if comp.TraitName == "STAY" || comp.TraitName == "HP" {
+		return comp.TraitName + "," + comp.Component + ",.01"
+	}
+	return comp.TraitName + "," + comp.Component + ",1.0"
+}
+
+// Spawn a go routine for each sample
+// This is the go routine
+func (e *estimate_t) multistart(ctx context.Context, swg *sizedwaitgroup.SizedWaitGroup, comp animal.Component_t, sample int, c chan replicate_t) {
+
+	defer swg.Done()
+
+	bump := bumpFor(comp)
+
+	r := replicate_t{seed: e.seeds[sample]}
+	defer func() { // A panic fails the replicate, serve keeps running
+		if p := recover(); p != nil {
+			r.err = fmt.Errorf("simulation panicked: %v", p)
+			c <- r
+		}
+	}()
+
+	var ok bool
+	if r.netReturns, ok = e.cache.get(r.seed, bump); ok {
+		c <- r
+		return
+	}
+
+	// A seed whose replicates were all cached has no burnin.  If one of its
+	// entries has since been deleted Run simulates the burnin itself.
+	sim, err := simulation.New(e.params, simulation.Options{Seed: int64(e.seeds[sample]), Bump: bump, OutputMode: "quiet"})
+	if err == nil && e.burnins[sample] != nil {
+		err = sim.Restore(e.burnins[sample])
+	}
+	if err != nil {
+		r.err = err
+		c <- r
+		return
+	}
+
+	result, err := sim.Run(ctx)
+	r.netReturns = result.NetReturns
+	r.err = err
+
+	if err == nil {
+		if err = e.cache.put(r.seed, bump, r.netReturns); err != nil {
+			logger.LogWriter("caching replicate: " + err.Error())
+		}
+	}
+
+	c <- r
+}
+
+// Launch a simulation with a bump trait e.g., WW,D
+func (e *estimate_t) launchSimulations(ctx context.Context, comp animal.Component_t) (float64, float64, error) {
+
+	var results []float64
+
+	start := time.Now()
+
+	if e.outputMode == "verbose" || e.outputMode == "table" {
+		fmt.Println("Bumping: ", comp.TraitName, comp.Component)
+	}
+
+	swg := sizedwaitgroup.New(runtime.NumCPU())
+
+	ch := make(chan replicate_t, e.nSamples) // Buffered channel for results
+
+	for i := 0; i < e.nSamples; i++ {
+		swg.Add()
+		go e.multistart(ctx, &swg, comp, i, ch)
+	}
+
+	swg.Wait()
+
+	for i := 0; i < e.nSamples; i++ {
+		r := <-ch
+		if r.err != nil {
+			if ctx.Err() != nil {
+				return 0, 0, ctx.Err()
+			}
+			return 0, 0, fmt.Errorf("Simulation of %s,%s with seed %d failed: %w", comp.TraitName, comp.Component, r.seed, r.err)
+		}
+		results = append(results, r.netReturns)
+	}
+
+	elapsed := time.Since(start)
+
+	mean, variance := stat.MeanVariance(results, nil)
+	if e.outputMode == "verbose" {
+		fmt.Println("N Samples:", e.nSamples, "\nMean:", mean, "\nStdDev:", math.Sqrt(variance), "\nStdDev(Mean):", math.Sqrt(variance/float64(e.nSamples)))
+		fmt.Println("Total time:", elapsed, "Time per sample:", elapsed.Seconds()/float64(e.nSamples), "Using", runtime.NumCPU(), "CPUs")
+	}
+
+	if comp.TraitName == debugTrait && debug {
+		fp, _ := os.OpenFile("Samples", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		defer fp.Close()
+		for i, v := range results {
+			fmt.Fprintln(fp, i, v, e.seeds[i])
+		}
+	}
+	return mean, math.Sqrt(variance), nil
+}
+
+// True if the base and every bump of a seed are in the cache
+func (e *estimate_t) cached(seed int) bool {
+	if _, ok := e.cache.get(seed, ""); !ok {
+		return false
+	}
+	for _, co := range e.index.IndexComponents {
+		if _, ok := e.cache.get(seed, bumpFor(co)); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Simulate the burnin once for each seed.  The base and every bump start from it.
+func (e *estimate_t) burnInSeeds(ctx context.Context) error {
+
+	e.burnins = make([][]byte, e.nSamples)
+	errs := make([]error, e.nSamples)
+
+	swg := sizedwaitgroup.New(runtime.NumCPU())
+	for i := 0; i < e.nSamples; i++ {
+		if e.cached(e.seeds[i]) {
+			continue
+		}
+		swg.Add()
+		go func(i int) {
+			defer swg.Done()
+			defer func() {
+				if p := recover(); p != nil {
+					errs[i] = fmt.Errorf("simulation panicked: %v", p)
+				}
+			}()
+			sim, err := simulation.New(e.params, simulation.Options{Seed: int64(e.seeds[i]), OutputMode: "quiet"})
+			if err == nil {
+				err = sim.BurnIn(ctx)
+			}
+			if err == nil {
+				e.burnins[i], err = sim.Snapshot()
+			}
+			errs[i] = err
+		}(i)
+	}
+	swg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("Burnin with seed %d failed: %w", e.seeds[i], err)
+		}
+	}
+	return nil
+}
+
+// Bump each index component by 1 (STAY by .01) and construct the table of MEV
+func (e *estimate_t) simulateIndexComponents(ctx context.Context) error {
+
+	e.report(progress_t{Trait: "base", State: "burnin"})
+	if err := e.burnInSeeds(ctx); err != nil {
+		return err
+	}
+
+	var baseComp animal.Component_t // is nil
+	baseComp.TraitName = "base"
+
+	var err error
+	e.report(progress_t{Trait: "base", State: "running"})
+	if e.bmean, e.bstddev, err = e.launchSimulations(ctx, baseComp); err != nil {
+		return err
+	}
+	e.report(progress_t{Trait: "base", State: "done", Mean: e.bmean, StdDev: e.bstddev})
+
+	n := float64(e.nSamples)
+
+	for _, co := range e.index.IndexComponents {
+		e.report(progress_t{Trait: co.TraitName, Component: co.Component, State: "running"})
+		m, s, err := e.launchSimulations(ctx, co)
+		if err != nil {
+			return err
+		}
+		var mev mevTable_t
+		mev.component = co.Component
+		mev.trait = co.TraitName
+		mev.meanNetReturns = m
+		mev.stddevNetReturns = s
+		mev.mev = m - e.bmean
+		mev.stddevMeanNR = math.Sqrt(s * s / n)
+
+		e.indexErrorVar += (s*s)/n + (e.bstddev*e.bstddev)/n
+
+		e.mevTable = append(e.mevTable, mev)
+		e.report(progress_t{Trait: co.TraitName, Component: co.Component, State: "done", Mean: m, StdDev: s, Mev: mev.mev})
+	}
+
+	return nil
+}
+
+// Load the master hjson so that the variance components can be used to calculate the percent emphasis
+func (e *estimate_t) loadGeneticVariances() {
+
+	// Get the traits and components in the order they appear in the VC matrix
+	componentList := e.params.Gen.Components
+
+	// Get the vc matrix
+	Vc := e.params.Gen.Genetic
+
+	n := int(math.Sqrt(float64(len(Vc))))
+	for l, c := range e.mevTable {
+		for j, g := range componentList {
+			if c.trait == g.TraitName && c.component == g.Component {
+				c.geneticStdDev = math.Sqrt(Vc[Index2D(j, j, n)])
+				if c.trait == "STAY" {
+					c.geneticStdDev = c.geneticStdDev * 100.
+				}
+				e.mevTable[l] = c
+			}
+		}
+	}
+
+	// calculate the emphasis values
+	var sumE float64
+	for i := range e.mevTable {
+		sumE += math.Abs(e.mevTable[i].mev) * e.mevTable[i].geneticStdDev
+	}
+	for i := range e.mevTable {
+		e.mevTable[i].emphasis = math.Abs(e.mevTable[i].mev) * e.mevTable[i].geneticStdDev / sumE
+	}
+
+}
@@ -9,3 +9,5 @@ require (
 	golang.org/x/exp v0.0.0-20201008143054-e3b2a7f2fdc7 // indirect
 	gonum.org/v1/gonum v0.8.1
 )
+
+replace github.com/blgolden/iGenDecModel/iGenDec => ../iGenDec
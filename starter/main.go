@@ -22,6 +22,7 @@ SOFTWARE.
 package main
 
 import (
+	"context"
 	//"bytes"
 	"encoding/csv"
 	"encoding/json"
@@ -31,132 +32,29 @@ import (
 	"io/ioutil"
 	"log"
 	"math"
-	"math/rand"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"runtime"
 	"strconv"
 
-	"github.com/blgolden/iGenDecModel/iGenDec/animal"
-	"github.com/blgolden/iGenDecModel/iGenDec/ecoIndex"
 	"github.com/blgolden/iGenDecModel/iGenDec/logger"
-
-	"strings"
-	"time"
+	"github.com/blgolden/iGenDecModel/iGenDec/simulation"
 
 	"github.com/hjson/hjson-go"
-	"github.com/remeh/sizedwaitgroup"
-	"gonum.org/v1/gonum/stat"
 )
 
 var debug bool = false // write a trait's sample values to Samples file for debugging.
 var debugTrait string = "base"
 
-var version string = "beta0.0.4"
+var version string = "beta0.0.5"
 var modelParam *string
 var indexParam *string
-var results []float64
 var numberSpawned int // Number of simulations to spawn per bump
-var seeds []int       // seeds used for each simulation
-var paramMaster map[string]interface{}
 var outputFile *string
 var databasePath *string
-var bmean, bstddev, indexErrorVar float64
-
-type mevTable_t struct {
-	trait            string
-	component        string
-	meanNetReturns   float64
-	stddevNetReturns float64
-	mev              float64
-	stddevMeanNR     float64
-	correlation      float64 // between traits and index when a dataset is named.
-	emphasis         float64 // percent emphasis of this trait
-	geneticStdDev    float64 // genetic variance of this component
-	headerName       string  // If there's a datafile of EPDs this gets set to the header value
-}
-
-// Table of marginal economic values
-var mevTable []mevTable_t
-
-// Spawn a go routine for each sample
-// This is the go routine
-func multistart(swg *sizedwaitgroup.SizedWaitGroup, comp animal.Component_t, seed string, c chan float64) {
-
-	defer swg.Done()
-
-	var bump string
-	if comp.TraitName != "base" {
-		if comp.TraitName == "STAY" || comp.TraitName == "HP" {
-			bump = "-bump=" + comp.TraitName + "," + comp.Component + ",.01"
-		} else {
-			bump = "-bump=" + comp.TraitName + "," + comp.Component + ",1.0"
-		}
-	}
-
-	model := "-genParm=" + *modelParam
-	index := "-indexParm=" + *indexParam
-
-	a := "-seed=" + seed
-
-	response, er := exec.Command("iGenDec", model, index, "-outputMode=quiet", a, bump).CombinedOutput()
-
-	if er != nil {
-		log.Fatal(er)
-	}
-
-	v, _ := strconv.ParseFloat(string(response), 64)
-
-	c <- v
-
-}
-
-//Launch a simulation with a bump trait e.g., WW,D
-func launchSimulations(comp animal.Component_t) (float64, float64) {
-
-	results = nil
-
-	start := time.Now()
-
-	if *logger.OutputMode == "verbose" || *logger.OutputMode == "table" {
-		fmt.Println("Bumping: ", comp.TraitName, comp.Component)
-	}
-
-	swg := sizedwaitgroup.New(runtime.NumCPU())
-
-	ch := make(chan float64, numberSpawned) // Buffered channel for results
-
-	for i := 0; i < numberSpawned; i++ {
-		swg.Add()
-		seed := strconv.Itoa(seeds[i])
-		go multistart(&swg, comp, seed, ch)
-	}
-
-	swg.Wait()
-
-	for i := 0; i < numberSpawned; i++ {
-		v := <-ch
-		results = append(results, v)
-	}
-
-	elapsed := time.Since(start)
-
-	mean, variance := stat.MeanVariance(results, nil)
-	if *logger.OutputMode == "verbose" {
-		fmt.Println("N Samples:", numberSpawned, "\nMean:", mean, "\nStdDev:", math.Sqrt(variance), "\nStdDev(Mean):", math.Sqrt(variance/float64(numberSpawned)))
-		fmt.Println("Total time:", elapsed, "Time per sample:", elapsed.Seconds()/float64(numberSpawned), "Using", runtime.NumCPU(), "CPUs")
-	}
-
-	if comp.TraitName == debugTrait && debug {
-		fp, _ := os.OpenFile("Samples", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		defer fp.Close()
-		for i, v := range results {
-			fmt.Fprintln(fp, i, v, seeds[i])
-		}
-	}
-	return mean, math.Sqrt(variance)
-}
+var cacheDir *string
+var rankOutput *string   // csv or json file of the target database ranked by the index
+var minAccuracy *float64 // Animals with a lower accuracy for an index EPD are not ranked
+var epdLimits *string    // Trait,Comp,min,max;... limits on the EPDs of the ranked animals
 
 // Parse the arg list looking for the input hjson file
 func parseArgs() {
@@ -170,6 +68,10 @@ func parseArgs() {
 	isVersion := flag.Bool("version", false, "prints the version number of starter")
 	outputFile = flag.String("outputFile", "", "Optional jjson file of MEV")
 	databasePath = flag.String("database-path", "", "Path top level directory where the EPD data are stored")
+	cacheDir = flag.String("cacheDir", defaultCacheDir(), "Directory of cached replicate net returns, '' turns caching off, price or cost edits invalidate it")
+	rankOutput = flag.String("rankOutput", "", "Optional csv or json file of the target database ranked by the index")
+	minAccuracy = flag.Float64("minAccuracy", 0, "Lowest accuracy of an index EPD of a ranked animal")
+	epdLimits = flag.String("epdLimits", "", "Limits on the EPDs of the ranked animals, e.g. 'CE,D,8,;BW,D,,2'")
 
 	flag.Parse()
 
@@ -184,7 +86,13 @@ func parseArgs() {
 		if *logger.OutputMode == "verbose" {
 
 			// Print out a syntax message
-			syntax := `Usage of ./starter:
+			syntax := `Usage of ./starter [serve | genomic-roi | value-bull]:
+  genomic-roi
+	Compare the net returns with and without genomic testing of the replacement heifers
+	from the genomicTestAccuracy and genomicTestCost keys in paired replicates
+  value-bull -bulls=file
+	Net present value of each candidate bull in the hjson file from his EPDs,
+	accuracies, price, years of service and salvage value
   -genParm string
     	The iGenDec parameter file (required)
   -indexParm string
@@ -202,7 +110,18 @@ func parseArgs() {
   -version
 	Print the version number and exit
   -database-path string
-    Path to the top level directory where the EPD data are stored`
+    Path to the top level directory where the EPD data are stored, a directory
+    for each database of target-database and an optional across_breed.hjson
+  -cacheDir string
+	Directory of cached replicate net returns, '' turns caching off.  Editing
+	a price or cost in the indexParm invalidates the cache.
+  -rankOutput string
+	Optional .csv or .json file of the target database ranked by the index
+  -minAccuracy float
+	Animals with a lower accuracy for any index EPD are not ranked (default 0)
+  -epdLimits string
+	Trait,Comp,min,max limits separated by ; on the ranked animals' EPDs,
+	an empty min or max is no limit - e.g. 'CE,D,8,' for bulls to use on heifers`
 
 			fmt.Printf("\n%s\n\n", syntax)
 			logger.LogWriterFatal("no parameter file name provided")
@@ -214,116 +133,23 @@ func parseArgs() {
 }
 
 // Read the arguments and initialize the list of index components
-func initialize() {
+func initialize() *estimate_t {
 
 	parseArgs()
 
-	loadIndexParam()
-
-	ecoIndex.LoadIndexComponents()
-
-	rand.Seed(*logger.Seed)
-
-	for i := 0; i < numberSpawned; i++ {
-		seeds = append(seeds, rand.Intn(100000))
-	}
-
-	results = make([]float64, numberSpawned)
-}
-
-// Read in the parameter hjson file and setup the map of param[key] pairs
-func loadIndexParam() {
-
-	hjsonFile, err := os.Open(*indexParam)
+	params, err := simulation.LoadParams(*modelParam, *indexParam)
 	if err != nil {
-		if *logger.OutputMode == "verbose" {
-			fmt.Println("Failed to open " + *indexParam)
-			fmt.Println(err)
-		} else {
-			logger.LogWriter("Failed to open parameter file " + *indexParam)
-			os.Exit(1)
-		}
-
+		logger.LogWriterFatal(err.Error())
 	}
-
-	defer hjsonFile.Close()
-
-	byteValue, _ := ioutil.ReadAll(hjsonFile)
-
-	// Translate the byte array into the mapped array of name:value pairs
-	if er := hjson.Unmarshal(byteValue, &ecoIndex.ParamIndex); er != nil {
-		logger.LogWriterFatal("Failed to unmarshal hjson")
+	if err = params.FactorCovariances(); err != nil { // Shared by every replicate
+		logger.LogWriterFatal(err.Error())
 	}
-}
 
-// Load the master hjson so that the variance components can be used to calculate the percent emphasis
-func loadGeneticVariances() {
-	hjsonFile, err := os.Open(*modelParam)
+	e, err := newEstimate(params, numberSpawned, *logger.Seed, *logger.OutputMode, *cacheDir)
 	if err != nil {
-		if *logger.OutputMode == "verbose" {
-			fmt.Println("Failed to open " + *modelParam)
-			fmt.Println(err)
-		} else {
-			logger.LogWriter("Failed to open parameter file " + *modelParam)
-			os.Exit(1)
-		}
-	}
-
-	defer hjsonFile.Close()
-
-	byteValue, _ := ioutil.ReadAll(hjsonFile)
-
-	if er := hjson.Unmarshal(byteValue, &paramMaster); er != nil {
-		logger.LogWriterFatal("Failed to unmarshal general hjson")
-	}
-
-	carray, ok := paramMaster["Components"].([]interface{})
-	if !ok {
-		logger.LogWriterFatal("'Components:' key not found in general parameters hjson.")
-	}
-
-	// Get the traits and components in the order they appear in the VC matrix
-	for i := range carray {
-		animal.Components = append(animal.Components, strings.TrimSpace(carray[i].(string)))
-		v := strings.TrimSpace(carray[i].(string))
-		splt := strings.Split(v, ",")
-		var c animal.Component_t
-
-		c.TraitName = strings.TrimSpace(splt[0])
-		c.Component = strings.TrimSpace(splt[1]) // comp = D or M
-		animal.ComponentList = append(animal.ComponentList, c)
-	}
-
-	// Get the vc matrix
-	var Vc []float64
-
-	array, _ := paramMaster["genetic"].([]interface{})
-	for k := range array {
-		Vc = append(Vc, array[k].(float64))
-	}
-
-	n := int(math.Sqrt(float64(len(Vc))))
-	for l, c := range mevTable {
-		for j, g := range animal.ComponentList {
-			if c.trait == g.TraitName && c.component == g.Component {
-				c.geneticStdDev = math.Sqrt(Vc[Index2D(j, j, n)])
-				if c.trait == "STAY" {
-					c.geneticStdDev = c.geneticStdDev * 100.
-				}
-				mevTable[l] = c
-			}
-		}
-	}
-
-	// calculate the emphasis values
-	var sumE float64
-	for i := range mevTable {
-		sumE += math.Abs(mevTable[i].mev) * mevTable[i].geneticStdDev
-	}
-	for i := range mevTable {
-		mevTable[i].emphasis = math.Abs(mevTable[i].mev) * mevTable[i].geneticStdDev / sumE
+		logger.LogWriterFatal(err.Error())
 	}
-
+	return e
 }
 
 var filenameXref = "comp_fn_pairs.hjson"
@@ -351,54 +177,24 @@ func findFilename(databasePath string) (string, error) {
 
 // If there's a target database then calculate the correlations between the index
 // and the trait components
-func calculateCorrelations() {
-	database, ok := paramMaster["target-database"].(string)
-	if !ok || database == "" {
+func (e *estimate_t) calculateCorrelations(db *database_t) {
+	if db == nil {
 		return
 	}
-
-	if databasePath == nil {
-		if *logger.OutputMode == "verbose" {
-			fmt.Println("target-database specified but -database-path parameter not set")
-		}
-		logger.LogWriterFatal("target-database specified but -database-path parameter not set")
-	}
-	comppath := filepath.Join(*databasePath, database)
-	csvfilename, err := findFilename(comppath)
-	if err != nil {
-		logger.LogWriterFatal(err.Error())
-	}
-	compFile := filepath.Join(comppath, csvfilename)
-	csvFile, err := os.Open(compFile)
-	if err != nil {
-		if *logger.OutputMode == "verbose" {
-			fmt.Println("Failed to open " + compFile)
-			fmt.Println(err)
-		} else {
-			logger.LogWriter("Failed to open datafile " + compFile)
-			os.Exit(1)
-		}
-	}
-
-	defer csvFile.Close()
-
-	dataMap := CSVToMap(csvFile)
-	xref, _ := loadXref(comppath)
-
-	linkHeaderAndMevTable(xref)
+	dataMap := db.animals
 
 	// Calculate index values
 	var score []float64
 	var sumY, sumY2 float64
 	for _, c := range dataMap {
 		var s float64
-		for _, f := range mevTable {
-			if f.headerName != "" {
-				e, _ := strconv.ParseFloat(c[f.headerName], 64)
+		for _, f := range e.mevTable {
+			if f.inDatabase {
+				v, _ := strconv.ParseFloat(c.fields[f.databaseKey()], 64)
 				if f.trait == "CD" {
-					s += e * f.mev * -1.
+					s += v * f.mev * -1.
 				} else {
-					s += e * f.mev
+					s += v * f.mev
 				}
 			}
 		}
@@ -410,15 +206,15 @@ func calculateCorrelations() {
 	n := float64(len(score))
 	sqrtY := math.Sqrt(sumY2 - math.Pow(sumY, 2)/n)
 
-	for j, f := range mevTable {
-		if f.headerName != "" {
+	for j, f := range e.mevTable {
+		if f.inDatabase {
 
 			var sumX, sumX2, sumXY float64
 			for i, c := range dataMap {
-				e, _ := strconv.ParseFloat(c[f.headerName], 64)
-				sumX += e
-				sumX2 += e * e
-				sumXY += e * score[i]
+				v, _ := strconv.ParseFloat(c.fields[f.databaseKey()], 64)
+				sumX += v
+				sumX2 += v * v
+				sumXY += v * score[i]
 
 			}
 			sqrtX := math.Sqrt(sumX2 - math.Pow(sumX, 2)/n)
@@ -426,29 +222,29 @@ func calculateCorrelations() {
 			dividend := sumXY - ((sumX * sumY) / n)
 			divisor := sqrtX * sqrtY
 
-			mevTable[j].correlation = dividend / divisor
+			e.mevTable[j].correlation = dividend / divisor
 		}
 	}
 }
 
-// Set the header values in the mevTable
-func linkHeaderAndMevTable(xref map[string]Field) {
-	for _, x := range xref {
-		for i := range mevTable {
-			var key string
-			if mevTable[i].trait == "CD" {
-				key = "CE," + mevTable[i].component
-			} else {
-				key = mevTable[i].trait + "," + mevTable[i].component
-			}
-			if x.Key == key {
-				mevTable[i].headerName = x.Header
-			}
-		}
+// Mark the components of the mevTable the target databases have EPDs of
+func (e *estimate_t) linkDatabaseAndMevTable(db *database_t) {
+	for i := range e.mevTable {
+		e.mevTable[i].inDatabase = db.fields[e.mevTable[i].databaseKey()]
+	}
+}
+
+// The name of a component in the xref, calving difficulty is in the database as calving ease
+func (m mevTable_t) databaseKey() string {
+	if m.trait == "CD" {
+		return "CE," + m.component
 	}
+	return m.trait + "," + m.component
 }
 
-// Field describes a field in the CSV file read in from the comp_fn_pair.hjson file
+// Field describes a field in the CSV file read in from the comp_fn_pair.hjson file.
+// The name is Trait,Comp for an EPD and Trait,Comp,acc for its accuracy.  Fields
+// with select are written with the ranked animals to identify them.
 type Field struct {
 	Key     string `json:"name"`
 	Header  string `json:"header"`
@@ -518,57 +314,27 @@ func CSVToMap(reader io.Reader) []map[string]string {
 	return rows
 }
 
-// Bump each index component by 1 (STAY by .01) and construct the table of MEV
-func simulateIndexComponents() {
-
-	var baseComp animal.Component_t // is nil
-	baseComp.TraitName = "base"
-	var baseMev mevTable_t
-
-	bmean, bstddev = launchSimulations(baseComp)
-
-	baseMev.trait = "base"
-
-	n := float64(numberSpawned)
-
-	for _, co := range ecoIndex.IndexComponents {
-		m, s := launchSimulations(co)
-		var mev mevTable_t
-		mev.component = co.Component
-		mev.trait = co.TraitName
-		mev.meanNetReturns = m
-		mev.stddevNetReturns = s
-		mev.mev = m - bmean
-		mev.stddevMeanNR = math.Sqrt(s * s / n)
-
-		indexErrorVar += (s*s)/n + (bstddev*bstddev)/n
-
-		mevTable = append(mevTable, mev)
-	}
-
-}
-
 // Write a table of MEV to the screen
-func publishIndex() {
+func (e *estimate_t) publishIndex() {
 
 	fmt.Println("\t ________________________________________________________________________")
 	fmt.Println("\t| Trait  | Comp | Mean NRLML   | StdDev(NRLML) |     MEV    | SDMeanNRLML| ")
 	fmt.Println("\t|________|______|______________|_______________|____________|____________|")
-	fmt.Printf("\t| base   |  -   |  %10.2f  |    %10.2f |      -     |      -     |\n", bmean, bstddev)
+	fmt.Printf("\t| base   |  -   |  %10.2f  |    %10.2f |      -     |      -     |\n", e.bmean, e.bstddev)
 
-	for _, co := range mevTable {
+	for _, co := range e.mevTable {
 		fmt.Printf("\t|% 5s   |  %s   |  %10.2f  |    %10.2f | %10.2f | %10.2f |\n",
 			co.trait, co.component, co.meanNetReturns, co.stddevNetReturns, co.mev, co.stddevMeanNR)
 	}
 	fmt.Println("\t|________________________________________________________________________|")
 	fmt.Printf("\tNote, these MEV are to be applied to EBV, not EPD\n")
-	fmt.Printf("\t *Number of samples per bump: %d\n", numberSpawned)
-	fmt.Printf("\n\tStd Error of the Index: %10.2f\n\n", math.Sqrt(indexErrorVar))
+	fmt.Printf("\t *Number of samples per bump: %d\n", e.nSamples)
+	fmt.Printf("\n\tStd Error of the Index: %10.2f\n\n", math.Sqrt(e.indexErrorVar))
 }
 
 // write to stream
-func dumpMev() {
-	for _, co := range mevTable {
+func (e *estimate_t) dumpMev() {
+	for _, co := range e.mevTable {
 		fmt.Printf("%s,%s,%f\n", co.trait, co.component, co.mev*2.0) // Multiply by 2 to apply to EPD
 	}
 }
@@ -578,18 +344,48 @@ func main() {
 	verbose := "verbose"
 	logger.OutputMode = &verbose
 
-	initialize()
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+		serve()
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "genomic-roi" {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+		genomicROI()
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "value-bull" {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+		valueBull()
+		return
+	}
+
+	e := initialize()
 
-	simulateIndexComponents()
+	if err := e.simulateIndexComponents(context.Background()); err != nil {
+		logger.LogWriterFatal(err.Error())
+	}
 
-	loadGeneticVariances()
+	e.loadGeneticVariances()
 
-	calculateCorrelations()
+	database, err := e.loadDatabase(*databasePath)
+	if err != nil {
+		logger.LogWriterFatal(err.Error())
+	}
+	e.calculateCorrelations(database)
 
 	if *logger.OutputMode == "table" || *logger.OutputMode == "verbose" {
-		publishIndex()
+		e.publishIndex()
 	} else if *logger.OutputMode == "web" {
-		dumpMev()
+		e.dumpMev()
+	}
+
+	if *rankOutput != "" {
+		if err := e.rankDatabase(database, *rankOutput); err != nil {
+			logger.LogWriterFatal(err.Error())
+		}
 	}
 
 	if *outputFile != "" {
@@ -598,7 +394,7 @@ func main() {
 			logger.LogWriterFatal("Cannot open outputFile")
 		}
 		_, _ = f.WriteString("{\n   indexElement:[\n")
-		for _, co := range mevTable {
+		for _, co := range e.mevTable {
 			if co.trait == "CD" {
 				co.trait = "CE"
 				co.mev = co.mev * -1.0
@@ -0,0 +1,237 @@
+// rank.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// One animal of the target databases ranked by the index
+type rankedAnimal_t struct {
+	Database      string             `json:"database"`
+	Row           int                `json:"row"`              // Line of its database after the header
+	Fields        map[string]string  `json:"fields,omitempty"` // The xref fields with select
+	Index         float64            `json:"index"`            // $ per exposure of the MEV applied to the EPDs
+	Rank          int                `json:"rank"`
+	Percentile    int                `json:"percentile"`    // Top percent of the ranked animals, 1 is best
+	Epds          map[string]float64 `json:"epds"`          // By Trait,Comp of the xref, on the across breed base
+	Contributions map[string]float64 `json:"contributions"` // $ of each EPD to the index
+
+	accuracy float64 // Lowest of the index EPDs with an accuracy in the database
+}
+
+// A limit from -epdLimits, NaN when there is none
+type epdLimit_t struct {
+	key      string
+	min, max float64
+}
+
+// Parse the Trait,Comp,min,max;... of -epdLimits
+func parseEpdLimits(s string) ([]epdLimit_t, error) {
+	var limits []epdLimit_t
+	for _, l := range strings.Split(s, ";") {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		f := strings.Split(l, ",")
+		if len(f) != 4 {
+			return nil, fmt.Errorf("-epdLimits %q must be Trait,Comp,min,max", l)
+		}
+		limit := epdLimit_t{key: strings.TrimSpace(f[0]) + "," + strings.TrimSpace(f[1]), min: math.NaN(), max: math.NaN()}
+		for i, v := range []*float64{&limit.min, &limit.max} {
+			if t := strings.TrimSpace(f[2+i]); t != "" {
+				var err error
+				if *v, err = strconv.ParseFloat(t, 64); err != nil {
+					return nil, fmt.Errorf("-epdLimits %q: %w", l, err)
+				}
+			}
+		}
+		limits = append(limits, limit)
+	}
+	return limits, nil
+}
+
+// Rank the animals of the target databases on the index and write them,
+// sorted by the index, as csv or json by the file's extension.  Animals
+// missing an index EPD or outside the filters are not ranked.
+func (e *estimate_t) rankDatabase(db *database_t, fileName string) error {
+
+	if db == nil {
+		return fmt.Errorf("-rankOutput needs a target-database in %s", *modelParam)
+	}
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if ext != ".csv" && ext != ".json" {
+		return fmt.Errorf("-rankOutput %s must be a .csv or .json file", fileName)
+	}
+
+	limits, err := parseEpdLimits(*epdLimits)
+	if err != nil {
+		return err
+	}
+	for _, l := range limits {
+		if !db.fields[l.key] {
+			return fmt.Errorf("-epdLimits %s is not in %s", l.key, filenameXref)
+		}
+	}
+
+	var ranked []rankedAnimal_t
+	var missing, filtered int
+	for _, c := range db.animals {
+		a, ok := e.scoreAnimal(c)
+		if !ok {
+			missing++
+			continue
+		}
+		if !passes(c, a, limits) {
+			filtered++
+			continue
+		}
+		a.Database = c.database
+		a.Row = c.row
+		if len(db.selected) > 0 {
+			a.Fields = make(map[string]string)
+			for _, k := range db.selected {
+				a.Fields[k] = c.fields[k]
+			}
+		}
+		ranked = append(ranked, a)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Index > ranked[j].Index })
+	for i := range ranked {
+		ranked[i].Rank = i + 1
+		ranked[i].Percentile = int(math.Ceil(100 * float64(i+1) / float64(len(ranked))))
+	}
+
+	if e.outputMode == "verbose" || e.outputMode == "table" {
+		fmt.Printf("\tRanked %d of %d animals, %d missing an index EPD or accuracy, %d filtered out, written to %s\n\n",
+			len(ranked), len(db.animals), missing, filtered, fileName)
+	}
+
+	if ext == ".json" {
+		data, err := json.MarshalIndent(ranked, "", "  ")
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(fileName, data, 0644)
+	}
+	return e.writeRankCsv(ranked, db.selected, fileName)
+}
+
+// The index of an animal and each EPD's contribution.  Not ok if it is
+// missing an index EPD or the accuracy of one.
+func (e *estimate_t) scoreAnimal(c dbAnimal_t) (a rankedAnimal_t, ok bool) {
+
+	a.Epds = make(map[string]float64)
+	a.Contributions = make(map[string]float64)
+	a.accuracy = 1
+	for _, f := range e.mevTable {
+		if !f.inDatabase {
+			continue
+		}
+		key := f.databaseKey()
+		v, err := strconv.ParseFloat(strings.TrimSpace(c.fields[key]), 64)
+		if err != nil {
+			return a, false
+		}
+		mevEpd := f.mev * 2 // MEV apply to EBV
+		if f.trait == "CD" {
+			mevEpd = -mevEpd
+		}
+		a.Epds[key] = v
+		a.Contributions[key] = v * mevEpd
+		a.Index += v * mevEpd
+
+		if acc, found := c.fields[key+",acc"]; found {
+			r, err := strconv.ParseFloat(strings.TrimSpace(acc), 64)
+			if err != nil {
+				return a, false
+			}
+			a.accuracy = math.Min(a.accuracy, r)
+		}
+	}
+	return a, true
+}
+
+// An animal is ranked when all of its index EPDs are at least -minAccuracy
+// and its EPDs are within the -epdLimits
+func passes(c dbAnimal_t, a rankedAnimal_t, limits []epdLimit_t) bool {
+	if a.accuracy < *minAccuracy {
+		return false
+	}
+	for _, l := range limits {
+		v, err := strconv.ParseFloat(strings.TrimSpace(c.fields[l.key]), 64)
+		if err != nil || v < l.min || v > l.max { // NaN limits compare false
+			return false
+		}
+	}
+	return true
+}
+
+// Write the ranked animals as csv, their database, select fields, index and then the EPDs and their contributions
+func (e *estimate_t) writeRankCsv(ranked []rankedAnimal_t, selected []string, fileName string) error {
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var keys []string
+	for _, m := range e.mevTable {
+		if m.inDatabase {
+			keys = append(keys, m.databaseKey())
+		}
+	}
+
+	w := csv.NewWriter(f)
+	header := []string{"database", "row"}
+	header = append(header, selected...)
+	header = append(header, "index", "rank", "percentile")
+	for _, k := range keys {
+		header = append(header, k, k+" $")
+	}
+	w.Write(header)
+
+	for _, a := range ranked {
+		line := []string{a.Database, strconv.Itoa(a.Row)}
+		for _, k := range selected {
+			line = append(line, a.Fields[k])
+		}
+		line = append(line, fmt.Sprintf("%.2f", a.Index), strconv.Itoa(a.Rank), strconv.Itoa(a.Percentile))
+		for _, k := range keys {
+			line = append(line, strconv.FormatFloat(a.Epds[k], 'f', -1, 64), fmt.Sprintf("%.2f", a.Contributions[k]))
+		}
+		w.Write(line)
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,238 @@
+// roi.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"runtime"
+
+	"github.com/blgolden/iGenDecModel/iGenDec/animal"
+	"github.com/blgolden/iGenDecModel/iGenDec/logger"
+	"github.com/blgolden/iGenDecModel/iGenDec/simulation"
+
+	"github.com/remeh/sizedwaitgroup"
+	"gonum.org/v1/gonum/stat"
+)
+
+// Net returns per exposure of one seed without and with genomic testing of
+// the replacement heifers, both from the same burnin
+type roiPair_t struct {
+	seed     int
+	base     float64
+	tested   float64
+	testCost float64 // Discounted cost of the tests per exposure, already in tested
+	err      error
+}
+
+// The return on genomic testing of the heifer candidates
+type roiResult_t struct {
+	NSamples        int     `json:"nSamples"`
+	Accuracy        float64 `json:"accuracy"`     // Of the index policy without testing
+	TestAccuracy    float64 `json:"testAccuracy"` // and with it
+	CostPerHead     float64 `json:"costPerHead"`  // Per heifer tested
+	BaseMean        float64 `json:"baseMeanNetReturns"`
+	TestedMean      float64 `json:"testedMeanNetReturns"`
+	Difference      float64 `json:"difference"`       // Mean paired change in net returns per exposure, net of the tests
+	DifferenceSE    float64 `json:"differenceStdErr"` // Its standard error over the pairs
+	TestCost        float64 `json:"testCost"`         // Discounted cost of the tests per exposure
+	ROI             float64 `json:"roi"`              // Net return per $ of testing, Difference / TestCost
+	PairsProfitable float64 `json:"pairsProfitable"`  // Proportion of the pairs where testing paid
+}
+
+// starter genomic-roi compares the net returns with and without genomic
+// testing of the heifer candidates from the genomicTest keys
+func genomicROI() {
+
+	parseArgs()
+
+	if *indexParam == "" {
+		logger.LogWriterFatal("genomic-roi needs an -indexParm to value the net returns")
+	}
+
+	params, err := simulation.LoadParams(*modelParam, *indexParam)
+	if err != nil {
+		logger.LogWriterFatal(err.Error())
+	}
+	if params.Gen.GenomicTestAccuracy == 0 {
+		logger.LogWriterFatal("'genomicTestAccuracy:' key not found in " + *modelParam)
+	}
+	if params.Gen.HeiferSelection != animal.SelectIndex { // Tests only change the index policy's accuracy
+		logger.LogWriterFatal("genomic-roi needs 'heiferSelection: " + animal.SelectIndex + "' in " + *modelParam)
+	}
+	if err = params.FactorCovariances(); err != nil {
+		logger.LogWriterFatal(err.Error())
+	}
+
+	// Replicates are not cached, the pairs need the tested runs' costs
+	e, err := newEstimate(params, numberSpawned, *logger.Seed, *logger.OutputMode, "")
+	if err != nil {
+		logger.LogWriterFatal(err.Error())
+	}
+
+	result, err := e.genomicROI(context.Background())
+	if err != nil {
+		logger.LogWriterFatal(err.Error())
+	}
+
+	if *logger.OutputMode == "web" {
+		data, _ := json.Marshal(result)
+		fmt.Println(string(data))
+		return
+	}
+	result.publish()
+}
+
+// Run the pairs of replicates and summarise their differences
+func (e *estimate_t) genomicROI(ctx context.Context) (*roiResult_t, error) {
+
+	if err := e.burnInSeeds(ctx); err != nil {
+		return nil, err
+	}
+
+	swg := sizedwaitgroup.New(runtime.NumCPU())
+	pairs := make([]roiPair_t, e.nSamples)
+	for i := 0; i < e.nSamples; i++ {
+		swg.Add()
+		go func(i int) {
+			defer swg.Done()
+			defer func() {
+				if p := recover(); p != nil {
+					pairs[i] = roiPair_t{seed: e.seeds[i], err: fmt.Errorf("simulation panicked: %v", p)}
+				}
+			}()
+			pairs[i] = e.roiPair(ctx, i)
+		}(i)
+	}
+	swg.Wait()
+
+	r := new(roiResult_t)
+	r.NSamples = e.nSamples
+	r.Accuracy = e.params.Gen.HeiferIndexAccuracy
+	r.TestAccuracy = e.params.Gen.GenomicTestAccuracy
+	r.CostPerHead = e.params.Gen.GenomicTestCost
+
+	var base, tested, diffs []float64
+	var profitable int
+	for _, p := range pairs {
+		if p.err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("Genomic testing pair with seed %d failed: %w", p.seed, p.err)
+		}
+		base = append(base, p.base)
+		tested = append(tested, p.tested)
+		diffs = append(diffs, p.tested-p.base)
+		r.TestCost += p.testCost / float64(e.nSamples)
+		if p.tested > p.base {
+			profitable++
+		}
+	}
+
+	r.BaseMean = stat.Mean(base, nil)
+	r.TestedMean = stat.Mean(tested, nil)
+	var v float64
+	r.Difference, v = stat.MeanVariance(diffs, nil)
+	r.DifferenceSE = math.Sqrt(v / float64(e.nSamples))
+	if r.TestCost > 0 {
+		r.ROI = r.Difference / r.TestCost
+	}
+	r.PairsProfitable = float64(profitable) / float64(e.nSamples)
+
+	return r, nil
+}
+
+// Simulate a seed from its burnin without and then with the tests
+func (e *estimate_t) roiPair(ctx context.Context, sample int) roiPair_t {
+
+	p := roiPair_t{seed: e.seeds[sample]}
+
+	for _, test := range []bool{false, true} {
+		sim, err := simulation.New(e.params, simulation.Options{Seed: int64(p.seed), OutputMode: "quiet", GenomicTest: test})
+		if err == nil {
+			err = sim.Restore(e.burnins[sample])
+		}
+		var result simulation.Result
+		if err == nil {
+			result, err = sim.Run(ctx)
+		}
+		if err != nil {
+			p.err = err
+			return p
+		}
+		if !test {
+			p.base = result.NetReturns
+			continue
+		}
+		p.tested = result.NetReturns
+		p.testCost = testCostPerExposure(result.Document)
+	}
+
+	if e.outputMode == "verbose" {
+		fmt.Printf("Seed %6d: base %10.2f  tested %10.2f  test cost %8.2f\n", p.seed, p.base, p.tested, p.testCost)
+	}
+	return p
+}
+
+// The discounted cost of the tests per cow exposed, averaged over the years
+// of the net returns the same way the index charges it
+func testCostPerExposure(d *simulation.Document) float64 {
+
+	if d.Index == nil || d.Index.LastYear < d.Index.FirstYear {
+		return 0
+	}
+
+	exposed := make(map[int]int)
+	for _, c := range d.CowsExposed {
+		exposed[c.Year] = c.Cows
+	}
+
+	var cost float64
+	for _, l := range d.Index.Lines {
+		if l.Endpoint == "genomicTest" && exposed[l.Year] > 0 {
+			cost += l.Discounted / float64(exposed[l.Year])
+		}
+	}
+	return cost / float64(d.Index.LastYear-d.Index.FirstYear+1)
+}
+
+// Write the comparison to the screen
+func (r *roiResult_t) publish() {
+
+	fmt.Println("\t ________________________________________________________________")
+	fmt.Println("\t| Genomic testing of replacement heifers                         |")
+	fmt.Println("\t|________________________________________________________________|")
+	fmt.Printf("\t| Index accuracy without / with the test      %6.2f / %6.2f    |\n", r.Accuracy, r.TestAccuracy)
+	fmt.Printf("\t| Test cost per heifer                        %10.2f         |\n", r.CostPerHead)
+	fmt.Printf("\t| Mean NRLML without testing                  %10.2f         |\n", r.BaseMean)
+	fmt.Printf("\t| Mean NRLML with testing                     %10.2f         |\n", r.TestedMean)
+	fmt.Printf("\t| Paired difference (StdErr)                  %10.2f (%5.2f) |\n", r.Difference, r.DifferenceSE)
+	fmt.Printf("\t| Test cost per exposure                      %10.2f         |\n", r.TestCost)
+	fmt.Printf("\t| Return per $ of testing                     %10.2f         |\n", r.ROI)
+	fmt.Printf("\t| Pairs where testing paid                    %10.2f         |\n", r.PairsProfitable)
+	fmt.Println("\t|________________________________________________________________|")
+	fmt.Printf("\tThe difference is net of the test cost, so a return above 0 pays for the tests\n")
+	fmt.Printf("\t *Number of paired samples: %d\n\n", r.NSamples)
+}
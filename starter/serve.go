@@ -0,0 +1,445 @@
+// serve.go
+/*
+Copyright 2021 Bruce Golden and Matt Spangler
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blgolden/iGenDecModel/iGenDec/logger"
+	"github.com/blgolden/iGenDecModel/iGenDec/simulation"
+)
+
+// Job states
+const (
+	jobQueued    = "queued"
+	jobRunning   = "running"
+	jobDone      = "done"
+	jobFailed    = "failed"
+	jobCancelled = "cancelled"
+)
+
+// A request to estimate the MEV table
+type jobRequest_t struct {
+	GenParm   string `json:"genParm"`   // Path of the iGenDec parameter file on the server
+	IndexParm string `json:"indexParm"` // Path of the index parameter file on the server
+	NSamples  int    `json:"nSamples"`
+	Seed      int64  `json:"seed"`
+}
+
+// One row of the MEV table.  Mev applies to EBV, MevEpd to EPD.
+type mevRow_t struct {
+	Trait            string  `json:"trait"`
+	Component        string  `json:"component"`
+	MeanNetReturns   float64 `json:"meanNetReturns"`
+	StdDevNetReturns float64 `json:"stddevNetReturns"`
+	Mev              float64 `json:"mev"`
+	MevEpd           float64 `json:"mevEpd"`
+	StdDevMeanNR     float64 `json:"stddevMeanNetReturns"`
+	Emphasis         float64 `json:"emphasis"`
+	GeneticStdDev    float64 `json:"geneticStdDev"`
+}
+
+// The MEV table of a finished job
+type mevResult_t struct {
+	NSamples      int        `json:"nSamples"`
+	BaseMean      float64    `json:"baseMeanNetReturns"`
+	BaseStdDev    float64    `json:"baseStddevNetReturns"`
+	IndexStdError float64    `json:"indexStdError"`
+	Components    []mevRow_t `json:"components"`
+}
+
+// A queued, running or finished estimate
+type job_t struct {
+	Id        string       `json:"id"`
+	Request   jobRequest_t `json:"request"`
+	State     string       `json:"state"`
+	Error     string       `json:"error,omitempty"`
+	Submitted time.Time    `json:"submitted"`
+	Started   *time.Time   `json:"started,omitempty"`
+	Finished  *time.Time   `json:"finished,omitempty"`
+	Progress  []progress_t `json:"progress,omitempty"`
+	Result    *mevResult_t `json:"result,omitempty"`
+
+	e       *estimate_t
+	cancel  context.CancelFunc
+	changed chan struct{} // Closed and replaced whenever the job changes
+}
+
+// The job service.  Jobs wait in queue until one of the workers is free.
+type server_t struct {
+	mu     sync.Mutex
+	jobs   map[string]*job_t
+	nextId int
+	queue  chan *job_t
+}
+
+func finished(state string) bool {
+	return state == jobDone || state == jobFailed || state == jobCancelled
+}
+
+// Wake everyone waiting on the job.  Called with s.mu held.
+func (j *job_t) notify() {
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// Run the job service until the listener fails
+func serve() {
+
+	addr := flag.String("addr", "localhost:8080", "Address the job service listens on")
+	workers := flag.Int("workers", 1, "Number of jobs run at the same time")
+	queueLen := flag.Int("queue", 100, "Number of jobs that can wait to run")
+	logger.User = flag.String("user", "admin", "user=[Username]")
+	logger.Seed = flag.Int64("seed", 1234, "Random number generator seed used when a job does not give one")
+	cacheDir = flag.String("cacheDir", defaultCacheDir(), "Directory of cached replicate net returns, '' turns caching off, price or cost edits invalidate it")
+	flag.Parse()
+
+	if *workers < 1 {
+		*workers = 1
+	}
+
+	s := &server_t{jobs: make(map[string]*job_t), queue: make(chan *job_t, *queueLen)}
+	for i := 0; i < *workers; i++ {
+		go s.worker()
+	}
+
+	http.HandleFunc("/jobs", s.handleJobs)
+	http.HandleFunc("/jobs/", s.handleJob)
+
+	fmt.Printf("starter %s serving jobs on http://%s with %d worker(s)\n", version, *addr, *workers)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+// Take jobs from the queue and run them one at a time
+func (s *server_t) worker() {
+	for j := range s.queue {
+		s.mu.Lock()
+		if j.State != jobQueued { // cancelled while it waited
+			s.mu.Unlock()
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		j.cancel = cancel
+		j.State = jobRunning
+		now := time.Now()
+		j.Started = &now
+		j.notify()
+		s.mu.Unlock()
+
+		err := runJob(ctx, j.e)
+
+		s.mu.Lock()
+		end := time.Now()
+		j.Finished = &end
+		switch {
+		case ctx.Err() != nil:
+			j.State = jobCancelled
+		case err != nil:
+			j.State = jobFailed
+			j.Error = err.Error()
+			logger.LogWriter("job " + j.Id + ": " + err.Error())
+		default:
+			j.State = jobDone
+			j.Result = j.e.result()
+		}
+		j.e = nil // Let the burnin snapshots go
+		j.notify()
+		s.mu.Unlock()
+		cancel()
+	}
+}
+
+// Simulate a job's MEV table.  A panic fails the job instead of the server.
+func runJob(ctx context.Context, e *estimate_t) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+	if err = e.simulateIndexComponents(ctx); err == nil {
+		e.loadGeneticVariances()
+	}
+	return err
+}
+
+// Convert the finished MEV table
+func (e *estimate_t) result() *mevResult_t {
+	r := &mevResult_t{NSamples: e.nSamples, BaseMean: e.bmean, BaseStdDev: e.bstddev}
+	r.IndexStdError = math.Sqrt(e.indexErrorVar)
+	for _, co := range e.mevTable {
+		r.Components = append(r.Components, mevRow_t{
+			Trait:            co.trait,
+			Component:        co.component,
+			MeanNetReturns:   co.meanNetReturns,
+			StdDevNetReturns: co.stddevNetReturns,
+			Mev:              co.mev,
+			MevEpd:           co.mev * 2.0,
+			StdDevMeanNR:     co.stddevMeanNR,
+			Emphasis:         co.emphasis,
+			GeneticStdDev:    co.geneticStdDev,
+		})
+	}
+	return r
+}
+
+// Load the parameter files and queue the job.  Problems with the files are
+// reported to the client rather than left for the worker to find.
+func (s *server_t) submit(req jobRequest_t) (*job_t, error) {
+
+	if req.GenParm == "" || req.IndexParm == "" {
+		return nil, errors.New("genParm and indexParm are both required")
+	}
+	if req.NSamples < 2 {
+		return nil, errors.New("nSamples must be at least 2")
+	}
+	if req.Seed == 0 {
+		req.Seed = *logger.Seed
+	}
+
+	params, err := simulation.LoadParams(req.GenParm, req.IndexParm)
+	if err != nil {
+		return nil, err
+	}
+	if err = params.FactorCovariances(); err != nil {
+		return nil, err
+	}
+	e, err := newEstimate(params, req.NSamples, req.Seed, "quiet", *cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextId++
+	j := &job_t{
+		Id:        strconv.Itoa(s.nextId),
+		Request:   req,
+		State:     jobQueued,
+		Submitted: time.Now(),
+		Progress:  []progress_t{},
+		e:         e,
+		changed:   make(chan struct{}),
+	}
+	e.progress = func(p progress_t) {
+		s.mu.Lock()
+		j.Progress = append(j.Progress, p)
+		j.notify()
+		s.mu.Unlock()
+	}
+
+	select {
+	case s.queue <- j:
+	default:
+		s.nextId--
+		return nil, errQueueFull
+	}
+	s.jobs[j.Id] = j
+	return j, nil
+}
+
+var errQueueFull = errors.New("the job queue is full")
+
+// Cancel a queued or running job
+func (s *server_t) cancel(j *job_t) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch j.State {
+	case jobQueued:
+		j.State = jobCancelled
+		now := time.Now()
+		j.Finished = &now
+		j.e = nil
+		j.notify()
+	case jobRunning:
+		j.cancel() // The worker records the cancellation
+	}
+}
+
+// Write a copy of the job taken under the lock
+func (s *server_t) snapshot(j *job_t) job_t {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := *j
+	c.Progress = append([]progress_t(nil), j.Progress...)
+	return c
+}
+
+// GET lists the jobs, POST submits one
+func (s *server_t) handleJobs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.Lock()
+		list := make([]job_t, 0, len(s.jobs))
+		for _, j := range s.jobs {
+			c := *j
+			c.Progress = nil
+			c.Result = nil
+			list = append(list, c)
+		}
+		s.mu.Unlock()
+		sort.Slice(list, func(a, b int) bool {
+			x, _ := strconv.Atoi(list[a].Id)
+			y, _ := strconv.Atoi(list[b].Id)
+			return x < y
+		})
+		writeJSON(w, http.StatusOK, list)
+
+	case http.MethodPost:
+		var req jobRequest_t
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("reading the job request: %w", err))
+			return
+		}
+		j, err := s.submit(req)
+		if err == errQueueFull {
+			writeError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		w.Header().Set("Location", "/jobs/"+j.Id)
+		writeJSON(w, http.StatusAccepted, s.snapshot(j))
+
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New(r.Method+" is not supported"))
+	}
+}
+
+// /jobs/{id}, /jobs/{id}/progress and /jobs/{id}/mev
+func (s *server_t) handleJob(w http.ResponseWriter, r *http.Request) {
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/jobs/"), "/"), "/")
+	if len(parts) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	j, ok := s.jobs[parts[0]]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("no job "+parts[0]))
+		return
+	}
+
+	var what string
+	if len(parts) == 2 {
+		what = parts[1]
+	}
+
+	switch {
+	case what == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.snapshot(j))
+
+	case what == "" && r.Method == http.MethodDelete:
+		s.cancel(j)
+		writeJSON(w, http.StatusOK, s.snapshot(j))
+
+	case what == "progress" && r.Method == http.MethodGet:
+		s.streamProgress(w, r, j)
+
+	case what == "mev" && r.Method == http.MethodGet:
+		c := s.snapshot(j)
+		if c.State != jobDone {
+			writeError(w, http.StatusConflict, errors.New("job "+j.Id+" is "+c.State))
+			return
+		}
+		writeJSON(w, http.StatusOK, c.Result)
+
+	case what == "" || what == "progress" || what == "mev":
+		writeError(w, http.StatusMethodNotAllowed, errors.New(r.Method+" is not supported"))
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Write each progress event as a line of JSON as it happens.  The last line
+// is the finished job.
+func (s *server_t) streamProgress(w http.ResponseWriter, r *http.Request, j *job_t) {
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	sent := 0
+	for {
+		s.mu.Lock()
+		events := append([]progress_t(nil), j.Progress[sent:]...)
+		done := finished(j.State)
+		changed := j.changed
+		s.mu.Unlock()
+
+		for _, p := range events {
+			if enc.Encode(p) != nil {
+				return
+			}
+		}
+		sent += len(events)
+
+		if done {
+			c := s.snapshot(j)
+			c.Progress = nil
+			enc.Encode(c)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}